@@ -2,21 +2,31 @@
 package client
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
-	"github.com/your-username/hefestus/pkg/models"
+	"hefestus-api/internal/retry"
 )
 
 // Client encapsula um cliente HTTP para comunicação com a API Hefestus.
 type Client struct {
 	baseURL    string
+	apiKey     string
+	tenant     string
 	httpClient *http.Client
+
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
 }
 
 // ClientOption define uma função para configurar opções do cliente.
@@ -29,10 +39,43 @@ func WithTimeout(timeout time.Duration) ClientOption {
 	}
 }
 
-// WithBaseURL configura a URL base para o cliente.
+// WithBaseURL configura a URL base para o cliente, incluindo o prefixo /api.
 func WithBaseURL(baseURL string) ClientOption {
 	return func(c *Client) {
-		c.baseURL = baseURL
+		c.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithAPIKey envia a chave no header X-API-Key.
+func WithAPIKey(apiKey string) ClientOption {
+	return func(c *Client) {
+		c.apiKey = apiKey
+	}
+}
+
+// WithTenant escolhe o tenant pelo header X-Tenant-ID.
+func WithTenant(tenant string) ClientOption {
+	return func(c *Client) {
+		c.tenant = tenant
+	}
+}
+
+// WithRetry configura as novas tentativas após respostas 429 e 503: até
+// maxRetries tentativas extras, esperando baseDelay, 2*baseDelay, 4*baseDelay...
+// ou o Retry-After enviado pela API. Esperas maiores que maxDelay (como a cota
+// diária esgotada) não são feitas e o erro é retornado. maxRetries 0 desativa.
+func WithRetry(maxRetries int, baseDelay time.Duration, maxDelay time.Duration) ClientOption {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.baseDelay = baseDelay
+		c.maxDelay = maxDelay
+	}
+}
+
+// WithHTTPClient substitui o cliente HTTP, por exemplo para usar um Transport próprio.
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *Client) {
+		c.httpClient = httpClient
 	}
 }
 
@@ -43,6 +86,9 @@ func New(options ...ClientOption) *Client {
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		maxRetries: 3,
+		baseDelay:  time.Second,
+		maxDelay:   30 * time.Second,
 	}
 
 	// Aplica as opções de configuração
@@ -53,62 +99,244 @@ func New(options ...ClientOption) *Client {
 	return client
 }
 
-// SendErrorRequest envia um pedido de análise de erro para a API e retorna as resoluções sugeridas.
-func (c *Client) SendErrorRequest(ctx context.Context, request models.ErrorRequest) (*models.ErrorResponse, error) {
-	jsonData, err := json.Marshal(request)
-	if err != nil {
-		return nil, fmt.Errorf("falha ao serializar requisição: %w", err)
+// SendErrorRequest envia um pedido de análise de erro do domínio para a API e retorna as resoluções sugeridas.
+func (c *Client) SendErrorRequest(ctx context.Context, domain string, request ErrorRequest) (*ErrorResponse, error) {
+	var response ErrorResponse
+	if err := c.do(ctx, http.MethodPost, "/errors/"+url.PathEscape(domain), request, &response); err != nil {
+		return nil, err
 	}
+	return &response, nil
+}
+
+// StreamEvent é um evento intermediário do stream de análise, como o status
+// enviado quando a análise começa.
+type StreamEvent struct {
+	Event string
+	Data  json.RawMessage
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/errors", bytes.NewBuffer(jsonData))
+// SendErrorRequestStream faz a mesma análise de SendErrorRequest por
+// POST /errors/{domain}/stream, chamando onEvent (se não for nil) para cada
+// evento de progresso. O timeout do cliente HTTP não se aplica à leitura do
+// stream; o prazo da análise é controlado por ctx.
+func (c *Client) SendErrorRequestStream(ctx context.Context, domain string, request ErrorRequest, onEvent func(StreamEvent)) (*ErrorResponse, error) {
+	streamClient := *c.httpClient
+	streamClient.Timeout = 0
+
+	var response *ErrorResponse
+	err := c.exchange(ctx, &streamClient, http.MethodPost, "/errors/"+url.PathEscape(domain)+"/stream", request, "text/event-stream", func(body io.Reader) error {
+		var err error
+		response, err = readStream(body, onEvent)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("falha ao criar requisição: %w", err)
+		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/json")
+	return response, nil
+}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("falha na requisição HTTP: %w", err)
+// readStream lê os eventos Server-Sent Events até o result ou o error final.
+func readStream(body io.Reader, onEvent func(StreamEvent)) (*ErrorResponse, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 4<<20)
+
+	var event StreamEvent
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line != "" {
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "event":
+				event.Event = value
+			case "data":
+				data = append(data, value)
+			}
+			// Linhas iniciadas por ":" são comentários de keep-alive
+			continue
+		}
+
+		if event.Event == "" && len(data) == 0 {
+			continue
+		}
+		event.Data = json.RawMessage(strings.Join(data, "\n"))
+		switch event.Event {
+		case "result":
+			var response ErrorResponse
+			if err := json.Unmarshal(event.Data, &response); err != nil {
+				return nil, fmt.Errorf("falha ao decodificar resposta: %w", err)
+			}
+			return &response, nil
+		case "error":
+			apiErr := &Error{}
+			if err := json.Unmarshal(event.Data, &apiErr.APIError); err != nil || apiErr.Message == "" {
+				apiErr.APIError = APIError{}
+				apiErr.Body = string(event.Data)
+			}
+			apiErr.StatusCode = apiErr.Code
+			return nil, apiErr
+		default:
+			if onEvent != nil {
+				onEvent(event)
+			}
+		}
+		event, data = StreamEvent{}, nil
 	}
-	defer resp.Body.Close()
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("falha ao ler stream: %w", err)
+	}
+	return nil, errors.New("stream encerrado sem resultado")
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("status inesperado: %d, resposta: %s", resp.StatusCode, body)
+// HealthCheck verifica o status de saúde da API.
+func (c *Client) HealthCheck(ctx context.Context) (string, error) {
+	var status struct {
+		Status string `json:"status"`
 	}
+	if err := c.do(ctx, http.MethodGet, "/health", nil, &status); err != nil {
+		return "", err
+	}
+	return status.Status, nil
+}
 
-	var response models.ErrorResponse
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return nil, fmt.Errorf("falha ao decodificar resposta: %w", err)
+// Quota retorna o consumo da cota diária do cliente autenticado em cada domínio.
+func (c *Client) Quota(ctx context.Context) (*QuotaUsage, error) {
+	var usage QuotaUsage
+	if err := c.do(ctx, http.MethodGet, "/quota", nil, &usage); err != nil {
+		return nil, err
 	}
+	return &usage, nil
+}
 
-	return &response, nil
+// GetAction retorna o estado de uma ação de autocorreção.
+func (c *Client) GetAction(ctx context.Context, id string) (*ActionResult, error) {
+	var result ActionResult
+	if err := c.do(ctx, http.MethodGet, "/actions/"+url.PathEscape(id), nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
 }
 
-// HealthCheck verifica o status de saúde da API.
-func (c *Client) HealthCheck(ctx context.Context) (string, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/health", nil)
-	if err != nil {
-		return "", fmt.Errorf("falha ao criar requisição: %w", err)
+// ApproveAction dispara uma ação que aguarda aprovação; requer o escopo actions:approve.
+func (c *Client) ApproveAction(ctx context.Context, id string) (*ActionResult, error) {
+	var result ActionResult
+	if err := c.do(ctx, http.MethodPost, "/actions/"+url.PathEscape(id)+"/approve", nil, &result); err != nil {
+		return nil, err
 	}
+	return &result, nil
+}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("falha na requisição HTTP: %w", err)
+// SendAlertmanagerWebhook envia um payload do Alertmanager para análise dos alertas.
+func (c *Client) SendAlertmanagerWebhook(ctx context.Context, webhook AlertmanagerWebhook) (*IntegrationResult, error) {
+	return c.integration(ctx, "alertmanager", webhook)
+}
+
+// SendZabbixEvent envia um evento do media type webhook do Zabbix para análise.
+func (c *Client) SendZabbixEvent(ctx context.Context, event ZabbixEvent) (*IntegrationResult, error) {
+	return c.integration(ctx, "zabbix", event)
+}
+
+// SendArgoCDNotification envia uma notificação do Argo CD para análise.
+func (c *Client) SendArgoCDNotification(ctx context.Context, notification ArgoCDNotification) (*IntegrationResult, error) {
+	return c.integration(ctx, "argocd", notification)
+}
+
+func (c *Client) integration(ctx context.Context, source string, payload interface{}) (*IntegrationResult, error) {
+	var result IntegrationResult
+	if err := c.do(ctx, http.MethodPost, "/integrations/"+source, payload, &result); err != nil {
+		return nil, err
 	}
-	defer resp.Body.Close()
+	return &result, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("status inesperado: %d, resposta: %s", resp.StatusCode, body)
+// do envia a requisição com exchange e decodifica a resposta JSON em out.
+func (c *Client) do(ctx context.Context, method string, path string, payload interface{}, out interface{}) error {
+	return c.exchange(ctx, c.httpClient, method, path, payload, "", func(body io.Reader) error {
+		if err := json.NewDecoder(body).Decode(out); err != nil {
+			return fmt.Errorf("falha ao decodificar resposta: %w", err)
+		}
+		return nil
+	})
+}
+
+// exchange envia a requisição com httpClient, repetindo-a após respostas 429 e
+// 503 conforme WithRetry, e entrega o corpo das respostas 200 a read.
+func (c *Client) exchange(ctx context.Context, httpClient *http.Client, method string, path string, payload interface{}, accept string, read func(io.Reader) error) error {
+	var jsonData []byte
+	if payload != nil {
+		var err error
+		if jsonData, err = json.Marshal(payload); err != nil {
+			return fmt.Errorf("falha ao serializar requisição: %w", err)
+		}
 	}
 
-	var status struct {
-		Status string `json:"status"`
+	wait := c.baseDelay
+	for attempt := 0; ; attempt++ {
+		err := c.send(ctx, httpClient, method, path, jsonData, accept, read)
+		var apiErr *Error
+		if !errors.As(err, &apiErr) || !apiErr.Temporary() || attempt >= c.maxRetries {
+			return err
+		}
+
+		if apiErr.RetryAfter > 0 {
+			wait = apiErr.RetryAfter
+		}
+		if wait > c.maxDelay {
+			return err
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w: %w", ctx.Err(), err)
+		case <-timer.C:
+		}
+		wait *= 2
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
-		return "", fmt.Errorf("falha ao decodificar resposta: %w", err)
+}
+
+// send faz uma única tentativa com as credenciais configuradas.
+func (c *Client) send(ctx context.Context, httpClient *http.Client, method string, path string, jsonData []byte, accept string, read func(io.Reader) error) error {
+	var body io.Reader
+	if jsonData != nil {
+		body = bytes.NewReader(jsonData)
 	}
 
-	return status.Status, nil
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("falha ao criar requisição: %w", err)
+	}
+	if jsonData != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+	if c.tenant != "" {
+		req.Header.Set("X-Tenant-ID", c.tenant)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("falha na requisição HTTP: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// As integrações respondem 202 quando a análise segue em segundo plano
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		apiErr := &Error{StatusCode: resp.StatusCode, RetryAfter: retry.ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now())}
+		if json.Unmarshal(data, &apiErr.APIError) != nil || apiErr.Message == "" {
+			apiErr.APIError = APIError{}
+			apiErr.Body = string(data)
+		}
+		return apiErr
+	}
+
+	return read(resp.Body)
 }
@@ -0,0 +1,218 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestClient aponta o cliente para um servidor httptest com esperas curtas
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return New(WithBaseURL(server.URL), WithAPIKey("secret"), WithRetry(3, time.Millisecond, time.Second))
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func TestSendErrorRequest(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/errors/kubernetes" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if got := r.Header.Get("X-API-Key"); got != "secret" {
+			t.Errorf("X-API-Key = %q", got)
+		}
+		var request ErrorRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.ErrorDetails != "OOMKilled" {
+			t.Errorf("unexpected body %+v (%v)", request, err)
+		}
+		writeJSON(w, http.StatusOK, ErrorResponse{Message: "ok", Error: &ErrorSolution{Causa: "Falta de memória"}})
+	})
+
+	response, err := c.SendErrorRequest(context.Background(), "kubernetes", ErrorRequest{ErrorDetails: "OOMKilled"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Error == nil || response.Error.Causa != "Falta de memória" {
+		t.Errorf("unexpected response %+v", response)
+	}
+}
+
+func TestSendAlertmanagerWebhookAccepted(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/integrations/alertmanager" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		writeJSON(w, http.StatusAccepted, IntegrationResult{Received: 1, Queued: 1, Items: []IntegrationItem{{ID: "a1", Status: "queued"}}})
+	})
+
+	result, err := c.SendAlertmanagerWebhook(context.Background(), AlertmanagerWebhook{Alerts: []Alert{{Status: "firing", Fingerprint: "a1"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Queued != 1 || len(result.Items) != 1 || result.Items[0].Status != "queued" {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func TestRetryHonoursRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			writeJSON(w, http.StatusServiceUnavailable, APIError{Code: 503, Message: "Indisponível"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+
+	status, err := c.HealthCheck(context.Background())
+	if err != nil || status != "ok" {
+		t.Fatalf("HealthCheck() = %q, %v", status, err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("server called %d times, want 2", got)
+	}
+}
+
+func TestRetryAfterAboveMaxDelayIsReturned(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "3600")
+		writeJSON(w, http.StatusTooManyRequests, APIError{Code: 429, Message: "Cota diária excedida"})
+	})
+
+	_, err := c.Quota(context.Background())
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests || apiErr.RetryAfter != time.Hour {
+		t.Fatalf("Quota() error = %v", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("server called %d times, want 1", got)
+	}
+}
+
+func TestErrorWithoutAPIErrorBody(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "upstream exploded", http.StatusBadGateway)
+	})
+
+	_, err := c.GetAction(context.Background(), "abc")
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *Error, got %v", err)
+	}
+	if apiErr.StatusCode != http.StatusBadGateway || apiErr.Message != "" || apiErr.Body != "upstream exploded\n" {
+		t.Errorf("unexpected error %+v", apiErr)
+	}
+	if StatusCode(err) != http.StatusBadGateway {
+		t.Errorf("StatusCode() = %d", StatusCode(err))
+	}
+}
+
+func TestCancelDuringBackoffReturnsContextError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusServiceUnavailable, APIError{Code: 503, Message: "Indisponível"})
+		served <- struct{}{}
+	}))
+	defer server.Close()
+	// Cancela durante a espera de um minuto antes da nova tentativa
+	go func() {
+		<-served
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	c := New(WithBaseURL(server.URL), WithRetry(3, time.Minute, time.Hour))
+
+	_, err := c.HealthCheck(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if StatusCode(err) != http.StatusServiceUnavailable {
+		t.Errorf("last response lost: %v", err)
+	}
+}
+
+// writeEvents responde com os eventos no formato do gin (sem espaço após ":")
+func writeEvents(w http.ResponseWriter, events ...string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	for _, event := range events {
+		fmt.Fprint(w, event)
+		w.(http.Flusher).Flush()
+	}
+}
+
+func TestSendErrorRequestStream(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/errors/kubernetes/stream" || r.Header.Get("Accept") != "text/event-stream" {
+			t.Errorf("unexpected request %s (Accept %q)", r.URL.Path, r.Header.Get("Accept"))
+		}
+		writeEvents(w,
+			"event:status\ndata:{\"stage\":\"analyzing\"}\n\n",
+			": keep-alive\n\n",
+			"event: result\ndata: {\"message\":\"ok\",\n",
+			"data: \"error\":{\"causa\":\"Falta de memória\",\"solucao\":\"kubectl top pod\"}}\n\n",
+		)
+	})
+
+	var events []StreamEvent
+	response, err := c.SendErrorRequestStream(context.Background(), "kubernetes", ErrorRequest{ErrorDetails: "OOMKilled"}, func(event StreamEvent) {
+		events = append(events, event)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Error == nil || response.Error.Causa != "Falta de memória" {
+		t.Errorf("unexpected response %+v", response)
+	}
+	if len(events) != 1 || events[0].Event != "status" || string(events[0].Data) != `{"stage":"analyzing"}` {
+		t.Errorf("unexpected events %+v", events)
+	}
+}
+
+func TestSendErrorRequestStreamErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []string
+		status int
+	}{
+		{
+			name:   "error event",
+			events: []string{"event:status\ndata:{}\n\n", "event:error\ndata:{\"code\":413,\"message\":\"Erro excede a janela de contexto do modelo\"}\n\n"},
+			status: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:   "closed without result",
+			events: []string{"event:status\ndata:{}\n\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				writeEvents(w, tt.events...)
+			})
+			response, err := c.SendErrorRequestStream(context.Background(), "kubernetes", ErrorRequest{ErrorDetails: "x"}, nil)
+			if err == nil {
+				t.Fatalf("expected error, got %+v", response)
+			}
+			if StatusCode(err) != tt.status {
+				t.Errorf("StatusCode(%v) = %d, want %d", err, StatusCode(err), tt.status)
+			}
+		})
+	}
+}
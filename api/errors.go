@@ -0,0 +1,44 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Error é retornado quando a API responde com status diferente de 200. Quando
+// o corpo é um models.APIError, seus campos ficam disponíveis em APIError.
+type Error struct {
+	StatusCode int
+	APIError
+	// RetryAfter é o valor do header Retry-After, quando presente.
+	RetryAfter time.Duration
+	// Body guarda o corpo da resposta quando ele não é um APIError.
+	Body string
+}
+
+func (e *Error) Error() string {
+	if e.Message != "" {
+		if e.Details != "" {
+			return fmt.Sprintf("status inesperado: %d, %s: %s", e.StatusCode, e.Message, e.Details)
+		}
+		return fmt.Sprintf("status inesperado: %d, %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("status inesperado: %d, resposta: %s", e.StatusCode, e.Body)
+}
+
+// Temporary indica respostas que podem ser repetidas mais tarde (429 e 503).
+func (e *Error) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode == http.StatusServiceUnavailable
+}
+
+// StatusCode retorna o status HTTP de um erro da API, ou 0 para falhas de
+// rede, serialização e cancelamento.
+func StatusCode(err error) int {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
@@ -0,0 +1,27 @@
+package client
+
+import "hefestus-api/internal/models"
+
+// Os tipos de requisição e resposta são os mesmos usados pelo servidor; os
+// aliases permitem usá-los fora do módulo, onde internal/models não é visível.
+type (
+	ErrorRequest     = models.ErrorRequest
+	ErrorResponse    = models.ErrorResponse
+	ErrorSolution    = models.ErrorSolution
+	NotificationSink = models.NotificationSink
+	KubernetesRef    = models.KubernetesRef
+	PreprocessReport = models.PreprocessReport
+	EnrichmentReport = models.EnrichmentReport
+	ActionResult     = models.ActionResult
+	APIError         = models.APIError
+
+	QuotaUsage          = models.QuotaUsage
+	DomainQuota         = models.DomainQuota
+	IntegrationResult   = models.IntegrationResult
+	IntegrationItem     = models.IntegrationItem
+	AlertmanagerWebhook = models.AlertmanagerWebhook
+	Alert               = models.Alert
+	ZabbixEvent         = models.ZabbixEvent
+	ArgoCDNotification  = models.ArgoCDNotification
+	ArgoCDApplication   = models.ArgoCDApplication
+)
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	client "hefestus-api/api"
+)
+
+// maxInputBytes limita o log lido; o servidor reduz o log ao trecho relevante
+const maxInputBytes = 16 << 20
+
+func runAnalyze(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("analyze", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	var domain, output, extraContext, file, configPath string
+	var offline bool
+	var offlineDirFlag string
+	var timeout time.Duration
+	flags.StringVar(&domain, "d", "", "domínio da análise (kubernetes, github, argocd...); vazio ou auto detecta pelo log")
+	flags.StringVar(&domain, "domain", "", "o mesmo que -d")
+	flags.StringVar(&output, "o", "", "formato da saída: text, json ou markdown")
+	flags.StringVar(&output, "output", "", "o mesmo que -o")
+	flags.StringVar(&extraContext, "c", "", "contexto adicional enviado com o log")
+	flags.StringVar(&extraContext, "context", "", "o mesmo que -c")
+	flags.StringVar(&file, "f", "", "arquivo com o log (padrão: entrada padrão)")
+	flags.StringVar(&configPath, "config", "", "arquivo de configuração (padrão: ~/.config/hefestus/config.json)")
+	flags.BoolVar(&offline, "offline", false, "analisa localmente com o Ollama, sem a API")
+	flags.StringVar(&offlineDirFlag, "dir", "", "diretório com config/domains.json e dicionários do modo offline")
+	flags.DurationVar(&timeout, "timeout", 2*time.Minute, "tempo máximo da análise")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Uso: hefestus analyze [opções] [texto do erro]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	config, err := loadConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "erro: %v\n", err)
+		return exitUsage
+	}
+	if output == "" {
+		output = config.Output
+	}
+	if !validOutput(output) {
+		fmt.Fprintf(stderr, "erro: formato de saída inválido: %s\n", output)
+		return exitUsage
+	}
+
+	details, err := readInput(flags.Args(), file, stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "erro: %v\n", err)
+		return exitUsage
+	}
+
+	if domain == "" || domain == "auto" {
+		if domain = detectDomain(details); domain == "" {
+			domain = config.Domain
+		}
+		if domain == "" {
+			fmt.Fprintln(stderr, "erro: não foi possível detectar o domínio; informe -d")
+			return exitUsage
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	config.Offline = config.Offline || offline
+	if offlineDirFlag != "" {
+		config.OfflineDir = offlineDirFlag
+	}
+	response, err := analyzeLog(ctx, config, domain, client.ErrorRequest{ErrorDetails: details, Context: extraContext}, timeout)
+	if errors.Is(err, errUnknownDomain) {
+		fmt.Fprintf(stderr, "erro: %v\n", err)
+		return exitUsage
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "erro: falha na análise: %v\n", err)
+		return exitAnalysisFailed
+	}
+
+	if err := render(stdout, output, domain, response); err != nil {
+		fmt.Fprintf(stderr, "erro: %v\n", err)
+		return exitAnalysisFailed
+	}
+	return exitOK
+}
+
+// analyzeLog analisa pela API ou, com Offline, no próprio processo; respostas
+// sem causa são tratadas como falha
+func analyzeLog(ctx context.Context, config *Config, domain string, request client.ErrorRequest, timeout time.Duration) (*client.ErrorResponse, error) {
+	var response *client.ErrorResponse
+	if config.Offline {
+		dir, err := offlineDir(config.OfflineDir)
+		if err != nil {
+			return nil, err
+		}
+		if response, err = analyzeOffline(ctx, dir, domain, request); err != nil {
+			return nil, err
+		}
+	} else {
+		sdk := client.New(client.WithBaseURL(config.BaseURL), client.WithAPIKey(config.APIKey),
+			client.WithTenant(config.Tenant), client.WithTimeout(timeout))
+		var err error
+		if response, err = sdk.SendErrorRequest(ctx, domain, request); err != nil {
+			return nil, err
+		}
+	}
+	if response.Error == nil || response.Error.Causa == "" {
+		return nil, errors.New("a análise não retornou uma causa")
+	}
+	return response, nil
+}
+
+// readInput usa os argumentos como texto do erro ou lê o arquivo ou a entrada
+// padrão; um terminal interativo sem pipe é tratado como entrada ausente
+func readInput(args []string, file string, stdin io.Reader) (string, error) {
+	if len(args) > 0 {
+		return strings.Join(args, " "), nil
+	}
+
+	reader := stdin
+	if file != "" && file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return "", fmt.Errorf("failed to open log: %w", err)
+		}
+		defer f.Close()
+		reader = f
+	} else if f, ok := stdin.(*os.File); ok {
+		if info, err := f.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+			return "", errors.New("nenhum log informado: use um pipe, -f ou passe o texto como argumento")
+		}
+	}
+
+	data, err := io.ReadAll(io.LimitReader(reader, maxInputBytes))
+	if err != nil {
+		return "", fmt.Errorf("failed to read log: %w", err)
+	}
+	if strings.TrimSpace(string(data)) == "" {
+		return "", errors.New("o log está vazio")
+	}
+	return string(data), nil
+}
+
+func runHealth(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("health", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	configPath := flags.String("config", "", "arquivo de configuração")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	config, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "erro: %v\n", err)
+		return exitUsage
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	status, err := client.New(client.WithBaseURL(config.BaseURL), client.WithAPIKey(config.APIKey)).HealthCheck(ctx)
+	if err != nil {
+		fmt.Fprintf(stderr, "erro: %v\n", err)
+		return exitAnalysisFailed
+	}
+	fmt.Fprintln(stdout, status)
+	return exitOK
+}
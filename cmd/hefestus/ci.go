@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	client "hefestus-api/api"
+)
+
+// Formatos das anotações do comando ci
+const (
+	ciAuto   = "auto"
+	ciGitHub = "github"
+	ciGitLab = "gitlab"
+	ciText   = "text"
+)
+
+// exitCommandNotFound é o código do shell para comandos que não puderam ser executados
+const exitCommandNotFound = 127
+
+// runCI executa o comando informado repassando sua saída e, se ele falhar,
+// analisa o final da saída e anota o job. O código de saída é sempre o do
+// comando: falhas da análise só geram avisos
+func runCI(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("ci", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	var domain, extraContext, configPath, format, summaryPath, offlineDirFlag string
+	var offline bool
+	var timeout time.Duration
+	flags.StringVar(&domain, "d", "", "domínio da análise; vazio ou auto detecta pela saída do comando")
+	flags.StringVar(&domain, "domain", "", "o mesmo que -d")
+	flags.StringVar(&extraContext, "c", "", "contexto adicional enviado com a saída")
+	flags.StringVar(&extraContext, "context", "", "o mesmo que -c")
+	flags.StringVar(&format, "format", ciAuto, "anotações: github, gitlab, text ou auto (detecta pelo ambiente do CI)")
+	flags.StringVar(&summaryPath, "summary", "", "arquivo markdown onde o diagnóstico é acrescentado (padrão: $GITHUB_STEP_SUMMARY)")
+	flags.StringVar(&configPath, "config", "", "arquivo de configuração (padrão: ~/.config/hefestus/config.json)")
+	flags.BoolVar(&offline, "offline", false, "analisa localmente com o Ollama, sem a API")
+	flags.StringVar(&offlineDirFlag, "dir", "", "diretório com config/domains.json e dicionários do modo offline")
+	flags.DurationVar(&timeout, "timeout", 2*time.Minute, "tempo máximo da análise")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Uso: hefestus ci [opções] -- comando [argumentos]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return exitUsage
+	}
+	if format == ciAuto {
+		format = detectCI()
+	}
+	if format != ciGitHub && format != ciGitLab && format != ciText {
+		fmt.Fprintf(stderr, "erro: formato de anotação inválido: %s\n", format)
+		return exitUsage
+	}
+	if summaryPath == "" && format == ciGitHub {
+		summaryPath = os.Getenv("GITHUB_STEP_SUMMARY")
+	}
+
+	config, err := loadConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "erro: %v\n", err)
+		return exitUsage
+	}
+	config.Offline = config.Offline || offline
+	if offlineDirFlag != "" {
+		config.OfflineDir = offlineDirFlag
+	}
+
+	output := &tailBuffer{max: maxInputBytes}
+	command := exec.Command(flags.Arg(0), flags.Args()[1:]...)
+	command.Stdin = stdin
+	command.Stdout = io.MultiWriter(stdout, output)
+	command.Stderr = io.MultiWriter(stderr, output)
+
+	code := exitOK
+	if err := command.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			fmt.Fprintf(stderr, "hefestus: falha ao executar %s: %v\n", flags.Arg(0), err)
+			return exitCommandNotFound
+		}
+		// Processos encerrados por sinal não têm código de saída
+		if code = exitErr.ExitCode(); code < 0 {
+			code = exitAnalysisFailed
+		}
+	}
+	if code == exitOK {
+		return exitOK
+	}
+
+	details := output.String()
+	if strings.TrimSpace(details) == "" {
+		fmt.Fprintf(stderr, "hefestus: %s falhou sem saída, nada a analisar\n", flags.Arg(0))
+		return code
+	}
+	if domain == "" || domain == "auto" {
+		if domain = detectDomain(details); domain == "" {
+			domain = config.Domain
+		}
+		if domain == "" {
+			fmt.Fprintln(stderr, "hefestus: não foi possível detectar o domínio; informe -d")
+			return code
+		}
+	}
+
+	commandLine := strings.Join(flags.Args(), " ")
+	if extraContext == "" {
+		extraContext = fmt.Sprintf("Saída do comando `%s`, que terminou com código %d.", commandLine, code)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	response, err := analyzeLog(ctx, config, domain, client.ErrorRequest{ErrorDetails: details, Context: extraContext}, timeout)
+	if err != nil {
+		fmt.Fprintf(stderr, "hefestus: falha na análise: %v\n", err)
+		return code
+	}
+
+	if err := annotate(stdout, format, domain, response); err != nil {
+		fmt.Fprintf(stderr, "hefestus: %v\n", err)
+	}
+	if summaryPath != "" {
+		if err := appendSummary(summaryPath, commandLine, code, domain, response); err != nil {
+			fmt.Fprintf(stderr, "hefestus: %v\n", err)
+		}
+	}
+	return code
+}
+
+// detectCI escolhe o formato pelas variáveis definidas pelos runners
+func detectCI() string {
+	switch {
+	case os.Getenv("GITHUB_ACTIONS") == "true":
+		return ciGitHub
+	case os.Getenv("GITLAB_CI") == "true":
+		return ciGitLab
+	}
+	return ciText
+}
+
+// annotate imprime o diagnóstico como anotação ::error:: do GitHub Actions,
+// seção recolhível do log do GitLab ou texto simples
+func annotate(w io.Writer, format string, domain string, response *client.ErrorResponse) error {
+	solution := response.Error
+	switch format {
+	case ciGitHub:
+		_, err := fmt.Fprintf(w, "::error title=%s::%s\n",
+			escapeProperty("Hefestus ("+domain+"): "+solution.Causa), escapeData(strings.TrimSpace(solution.Solucao)))
+		return err
+	case ciGitLab:
+		now := time.Now().Unix()
+		fmt.Fprintf(w, "\x1b[0Ksection_start:%d:hefestus[collapsed=false]\r\x1b[0KHefestus: %s\n", now, solution.Causa)
+		if err := render(w, outputText, domain, response); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintf(w, "\x1b[0Ksection_end:%d:hefestus\r\x1b[0K\n", now)
+		return err
+	}
+	return render(w, outputText, domain, response)
+}
+
+// appendSummary acrescenta o diagnóstico em markdown ao resumo do job
+func appendSummary(path string, commandLine string, code int, domain string, response *client.ErrorResponse) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open job summary: %w", err)
+	}
+	defer f.Close()
+
+	fmt.Fprintf(f, "`%s` terminou com código %d.\n\n", commandLine, code)
+	if err := render(f, outputMarkdown, domain, response); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(f)
+	return err
+}
+
+// escapeData codifica a mensagem de um workflow command do GitHub Actions
+func escapeData(value string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(value)
+}
+
+// escapeProperty codifica propriedades como title, que também não aceitam : e ,
+func escapeProperty(value string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(value)
+}
+
+// tailBuffer guarda os últimos max bytes escritos; o erro costuma estar no
+// final da saída
+type tailBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+	max int
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf.Write(p)
+	// Descarta o início só quando passa do dobro, para não copiar a cada escrita
+	if t.buf.Len() > 2*t.max {
+		t.buf.Next(t.buf.Len() - t.max)
+	}
+	return len(p), nil
+}
+
+func (t *tailBuffer) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	data := t.buf.Bytes()
+	if len(data) > t.max {
+		data = data[len(data)-t.max:]
+	}
+	return string(data)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const defaultBaseURL = "http://localhost:8080/api"
+
+// Config é o arquivo de configuração do CLI (padrão
+// ~/.config/hefestus/config.json); variáveis de ambiente e flags têm precedência
+type Config struct {
+	BaseURL string `json:"base_url"`
+	APIKey  string `json:"api_key"`
+	Tenant  string `json:"tenant"`
+	Domain  string `json:"domain"`
+	Output  string `json:"output"`
+	// Offline analisa no próprio processo com o Ollama local, sem a API
+	Offline bool `json:"offline"`
+	// OfflineDir contém config/domains.json e os dicionários do modo offline
+	OfflineDir string `json:"offline_dir"`
+}
+
+// loadConfig lê o arquivo informado, HEFESTUS_CONFIG ou o padrão e aplica
+// HEFESTUS_URL, HEFESTUS_API_KEY e HEFESTUS_TENANT; o arquivo padrão é opcional
+func loadConfig(path string) (*Config, error) {
+	explicit := path != ""
+	if path == "" {
+		path = os.Getenv("HEFESTUS_CONFIG")
+		explicit = path != ""
+	}
+	if path == "" {
+		if dir, err := os.UserConfigDir(); err == nil {
+			path = filepath.Join(dir, "hefestus", "config.json")
+		}
+	}
+
+	config := &Config{}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		switch {
+		case errors.Is(err, os.ErrNotExist) && !explicit:
+		case err != nil:
+			return nil, fmt.Errorf("failed to read config: %w", err)
+		default:
+			if err := json.Unmarshal(data, config); err != nil {
+				return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+			}
+		}
+	}
+
+	if value := os.Getenv("HEFESTUS_URL"); value != "" {
+		config.BaseURL = value
+	}
+	if value := os.Getenv("HEFESTUS_API_KEY"); value != "" {
+		config.APIKey = value
+	}
+	if value := os.Getenv("HEFESTUS_TENANT"); value != "" {
+		config.Tenant = value
+	}
+	if config.BaseURL == "" {
+		config.BaseURL = defaultBaseURL
+	}
+	if config.Output == "" {
+		config.Output = outputText
+	}
+	return config, nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import "strings"
+
+// domainHints são termos característicos dos logs de cada domínio; a
+// detecção escolhe o domínio com mais termos encontrados
+var domainHints = []struct {
+	domain string
+	hints  []string
+}{
+	{"argocd", []string{"argocd", "argo cd", "argoproj.io", "comparisonerror", "syncfailed", "outofsync", "sync operation", "app sync"}},
+	{"github", []string{"##[error]", "::error", "process completed with exit code", "actions/checkout", ".github/workflows", "github_token", "runner.", "gh run"}},
+	{"kubernetes", []string{"crashloopbackoff", "imagepullbackoff", "errimagepull", "oomkilled", "failedscheduling", "kubectl", "kubelet",
+		"back-off restarting", "readiness probe failed", "liveness probe failed", "insufficient cpu", "insufficient memory", "deployment.apps", "pod/"}},
+}
+
+// detectDomain retorna o domínio provável do log ou "" se nenhum termo for encontrado
+func detectDomain(text string) string {
+	text = strings.ToLower(text)
+
+	best, bestScore := "", 0
+	for _, candidate := range domainHints {
+		score := 0
+		for _, hint := range candidate.hints {
+			if strings.Contains(text, hint) {
+				score++
+			}
+		}
+		if score > bestScore {
+			best, bestScore = candidate.domain, score
+		}
+	}
+	return best
+}
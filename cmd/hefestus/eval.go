@@ -0,0 +1,484 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	client "hefestus-api/api"
+	"hefestus-api/internal/services"
+	"hefestus-api/internal/usage"
+	"hefestus-api/pkg/ollama"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Resultado de cada caso da avaliação
+const (
+	evalPassed  = "passed"
+	evalFailed  = "failed"
+	evalInvalid = "invalid"
+	evalError   = "error"
+)
+
+// evalCase é um erro do dataset com o que se espera da análise
+type evalCase struct {
+	ID           string `json:"id"`
+	Domain       string `json:"domain"`
+	ErrorDetails string `json:"error_details"`
+	Context      string `json:"context,omitempty"`
+	// ExpectedCategory é a categoria de um padrão do dicionário que deve ser encontrado
+	ExpectedCategory string `json:"expected_category,omitempty"`
+	// KeyPhrases devem aparecer na causa ou na solução, sem diferenciar maiúsculas
+	KeyPhrases []string `json:"key_phrases,omitempty"`
+	// ForbiddenCommands não podem aparecer na solução
+	ForbiddenCommands []string `json:"forbidden_commands,omitempty"`
+}
+
+// evalRun é o resultado do dataset com um modelo; o relatório gravado por
+// --out é uma lista de execuções, que pode ser usada depois como --baseline
+type evalRun struct {
+	Label     string       `json:"label"`
+	Model     string       `json:"model"`
+	Dataset   string       `json:"dataset"`
+	StartedAt time.Time    `json:"started_at"`
+	Summary   evalSummary  `json:"summary"`
+	Results   []evalResult `json:"results"`
+}
+
+type evalSummary struct {
+	Cases   int `json:"cases"`
+	Passed  int `json:"passed"`
+	Failed  int `json:"failed"`
+	Invalid int `json:"invalid"`
+	Errors  int `json:"errors"`
+	// CassetteMisses conta os prompts sem resposta gravada no modo replay
+	CassetteMisses int     `json:"cassette_misses,omitempty"`
+	Accuracy       float64 `json:"accuracy"`
+	// JSONValidity é a fração das respostas do modelo no formato JSON pedido
+	JSONValidity     float64 `json:"json_validity"`
+	LatencyP50Ms     int64   `json:"latency_p50_ms"`
+	LatencyP95Ms     int64   `json:"latency_p95_ms"`
+	LatencyMeanMs    int64   `json:"latency_mean_ms"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+}
+
+type evalResult struct {
+	ID               string   `json:"id"`
+	Domain           string   `json:"domain"`
+	Status           string   `json:"status"`
+	Failures         []string `json:"failures,omitempty"`
+	Causa            string   `json:"causa,omitempty"`
+	Solucao          string   `json:"solucao,omitempty"`
+	Categories       []string `json:"categories,omitempty"`
+	LatencyMs        int64    `json:"latency_ms"`
+	PromptTokens     int      `json:"prompt_tokens"`
+	CompletionTokens int      `json:"completion_tokens"`
+	Error            string   `json:"error,omitempty"`
+}
+
+// runEval roda um dataset pelo pipeline local (pré-processamento, dicionário,
+// prompt e parsing do servidor) com um ou mais modelos e compara as execuções
+// entre si e com um relatório anterior
+func runEval(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("eval", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	var configPath, offlineDirFlag, models, baselinePath, outPath, output, recordDir, replayDir string
+	var minAccuracy float64
+	var timeout time.Duration
+	flags.StringVar(&models, "model", "", "modelos do Ollama separados por vírgula (padrão: OLLAMA_MODEL)")
+	flags.StringVar(&baselinePath, "baseline", "", "relatório de uma execução anterior para comparação")
+	flags.StringVar(&outPath, "out", "", "arquivo onde gravar o relatório desta execução")
+	flags.StringVar(&output, "o", outputText, "formato da saída: text ou json")
+	flags.StringVar(&recordDir, "record", "", "grava as respostas do modelo neste diretório de cassetes")
+	flags.StringVar(&replayDir, "replay", "", "responde só com os cassetes deste diretório, sem chamar o Ollama")
+	flags.Float64Var(&minAccuracy, "min-accuracy", 0, "falha (código 1) se a acurácia de algum modelo ficar abaixo deste valor, entre 0 e 1")
+	flags.StringVar(&configPath, "config", "", "arquivo de configuração (padrão: ~/.config/hefestus/config.json)")
+	flags.StringVar(&offlineDirFlag, "dir", "", "diretório com config/domains.json e dicionários")
+	flags.DurationVar(&timeout, "timeout", 2*time.Minute, "tempo máximo de cada caso")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Uso: hefestus eval [opções] dataset.yaml|dataset.jsonl")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if flags.NArg() != 1 || (output != outputText && output != outputJSON) || (recordDir != "" && replayDir != "") {
+		flags.Usage()
+		return exitUsage
+	}
+
+	config, err := loadConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "erro: %v\n", err)
+		return exitUsage
+	}
+	if offlineDirFlag == "" {
+		offlineDirFlag = config.OfflineDir
+	}
+
+	datasetPath := flags.Arg(0)
+	cases, err := loadDataset(datasetPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "erro: %v\n", err)
+		return exitUsage
+	}
+	var baseline []evalRun
+	if baselinePath != "" {
+		if baseline, err = loadReport(baselinePath); err != nil {
+			fmt.Fprintf(stderr, "erro: %v\n", err)
+			return exitUsage
+		}
+	}
+	var cassette *ollama.Cassette
+	switch {
+	case recordDir != "":
+		cassette, err = ollama.NewCassette(ollama.CassetteRecord, recordDir)
+	case replayDir != "":
+		cassette, err = ollama.NewCassette(ollama.CassetteReplay, replayDir)
+	default:
+		cassette, err = ollama.CassetteFromEnv()
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "erro: %v\n", err)
+		return exitUsage
+	}
+
+	dir, err := offlineDir(offlineDirFlag)
+	if err != nil {
+		fmt.Fprintf(stderr, "erro: %v\n", err)
+		return exitUsage
+	}
+	p, err := newPipeline(dir, cassette)
+	if err != nil {
+		fmt.Fprintf(stderr, "erro: %v\n", err)
+		return exitUsage
+	}
+
+	modelList := []string{os.Getenv("OLLAMA_MODEL")}
+	if models != "" {
+		modelList = strings.Split(models, ",")
+	}
+
+	var runs []evalRun
+	for _, model := range modelList {
+		model = strings.TrimSpace(model)
+		p.useModel(model)
+		fmt.Fprintf(stderr, "avaliando %d casos com %s...\n", len(cases), valueOr(model, "o modelo padrão"))
+		runs = append(runs, p.evaluate(cases, datasetPath, model, timeout))
+	}
+
+	if outPath != "" {
+		data, err := json.MarshalIndent(runs, "", "  ")
+		if err == nil {
+			err = os.WriteFile(outPath, append(data, '\n'), 0o644)
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "erro: failed to write report: %v\n", err)
+		}
+	}
+
+	for i := range baseline {
+		baseline[i].Label = "baseline/" + baseline[i].Label
+	}
+	if output == outputJSON {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(runs); err != nil {
+			fmt.Fprintf(stderr, "erro: %v\n", err)
+		}
+	} else {
+		printComparison(stdout, baseline, runs)
+	}
+
+	for _, run := range runs {
+		// No replay, um prompt novo indica que o prompt ou o dataset mudou
+		// depois da gravação: o resultado não é comparável
+		if run.Summary.CassetteMisses > 0 {
+			fmt.Fprintf(stderr, "erro: %d prompts de %s sem resposta gravada no cassete; grave de novo com --record\n",
+				run.Summary.CassetteMisses, run.Label)
+			return exitAnalysisFailed
+		}
+		if run.Summary.Accuracy < minAccuracy {
+			fmt.Fprintf(stderr, "erro: acurácia de %s (%.1f%%) abaixo do mínimo de %.1f%%\n",
+				run.Label, 100*run.Summary.Accuracy, 100*minAccuracy)
+			return exitAnalysisFailed
+		}
+	}
+	return exitOK
+}
+
+// useModel troca o modelo do LLMService; vazio usa OLLAMA_MODEL
+func (p *pipeline) useModel(model string) {
+	options := []ollama.ClientOption{ollama.WithCassette(p.cassette)}
+	if model != "" {
+		options = append(options, ollama.WithModel(model))
+	}
+	p.llmService = services.NewLLMService(services.NewOllamaClient(p.dictService, options...), p.dictService)
+}
+
+// evaluate analisa os casos em sequência, para que a latência de um não afete a dos outros
+func (p *pipeline) evaluate(cases []evalCase, dataset string, model string, timeout time.Duration) evalRun {
+	run := evalRun{Label: valueOr(model, "default"), Model: model, Dataset: dataset, StartedAt: time.Now().UTC()}
+
+	var latencies []int64
+	for _, c := range cases {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		ctx, tracker := usage.WithTracker(ctx)
+		start := time.Now()
+		response, err := p.analyze(ctx, c.Domain, client.ErrorRequest{ErrorDetails: c.ErrorDetails, Context: c.Context})
+		result := evalResult{ID: c.ID, Domain: c.Domain, LatencyMs: time.Since(start).Milliseconds()}
+		result.PromptTokens, result.CompletionTokens = tracker.Tokens()
+
+		switch {
+		case errors.Is(err, ollama.ErrInvalidResponse):
+			result.Status = evalInvalid
+			result.Error = err.Error()
+		case err != nil:
+			result.Status = evalError
+			result.Error = err.Error()
+			if errors.Is(err, ollama.ErrCassetteMiss) {
+				run.Summary.CassetteMisses++
+			}
+		default:
+			p.score(ctx, c, response.Error, &result)
+		}
+		cancel()
+
+		run.Results = append(run.Results, result)
+		run.Summary.PromptTokens += result.PromptTokens
+		run.Summary.CompletionTokens += result.CompletionTokens
+		switch result.Status {
+		case evalPassed:
+			run.Summary.Passed++
+		case evalFailed:
+			run.Summary.Failed++
+		case evalInvalid:
+			run.Summary.Invalid++
+		case evalError:
+			run.Summary.Errors++
+			// Falhas antes da resposta do modelo não entram na latência
+			continue
+		}
+		latencies = append(latencies, result.LatencyMs)
+	}
+
+	summary := &run.Summary
+	summary.Cases = len(cases)
+	if summary.Cases > 0 {
+		summary.Accuracy = float64(summary.Passed) / float64(summary.Cases)
+	}
+	if answered := summary.Cases - summary.Errors; answered > 0 {
+		summary.JSONValidity = float64(answered-summary.Invalid) / float64(answered)
+	}
+	summary.LatencyP50Ms, summary.LatencyP95Ms, summary.LatencyMeanMs = latencyStats(latencies)
+	return run
+}
+
+// score compara a resposta com a categoria, as frases e os comandos esperados
+func (p *pipeline) score(ctx context.Context, c evalCase, solution *client.ErrorSolution, result *evalResult) {
+	result.Causa = solution.Causa
+	result.Solucao = solution.Solucao
+	for _, name := range solution.Patterns {
+		if pattern, ok := p.dictService.Pattern(ctx, c.Domain, name); ok && pattern.Category != "" {
+			result.Categories = append(result.Categories, pattern.Category)
+		}
+	}
+
+	if c.ExpectedCategory != "" && !containsFold(result.Categories, c.ExpectedCategory) {
+		result.Failures = append(result.Failures, fmt.Sprintf("categoria %s não encontrada (encontradas: %s)",
+			c.ExpectedCategory, valueOr(strings.Join(result.Categories, ", "), "nenhuma")))
+	}
+	answer := strings.ToLower(solution.Causa + "\n" + solution.Solucao)
+	for _, phrase := range c.KeyPhrases {
+		if !strings.Contains(answer, strings.ToLower(phrase)) {
+			result.Failures = append(result.Failures, "frase ausente: "+phrase)
+		}
+	}
+	steps := strings.ToLower(solution.Solucao)
+	for _, command := range c.ForbiddenCommands {
+		if strings.Contains(steps, strings.ToLower(command)) {
+			result.Failures = append(result.Failures, "comando proibido: "+command)
+		}
+	}
+
+	result.Status = evalPassed
+	if len(result.Failures) > 0 {
+		result.Status = evalFailed
+	}
+}
+
+func containsFold(values []string, target string) bool {
+	for _, value := range values {
+		if strings.EqualFold(value, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func valueOr(value string, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// latencyStats retorna p50, p95 e média, em milissegundos
+func latencyStats(latencies []int64) (int64, int64, int64) {
+	if len(latencies) == 0 {
+		return 0, 0, 0
+	}
+	sorted := append([]int64(nil), latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total int64
+	for _, latency := range sorted {
+		total += latency
+	}
+	percentile := func(p int) int64 {
+		return sorted[(len(sorted)-1)*p/100]
+	}
+	return percentile(50), percentile(95), total / int64(len(sorted))
+}
+
+// loadDataset lê os casos de um arquivo YAML (lista), JSONL (um caso por
+// linha) ou JSON (lista)
+func loadDataset(path string) ([]evalCase, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dataset: %w", err)
+	}
+
+	var cases []evalCase
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jsonl":
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		scanner.Buffer(make([]byte, 0, 64<<10), maxInputBytes)
+		for line := 1; scanner.Scan(); line++ {
+			if strings.TrimSpace(scanner.Text()) == "" {
+				continue
+			}
+			var c evalCase
+			if err := json.Unmarshal(scanner.Bytes(), &c); err != nil {
+				return nil, fmt.Errorf("failed to parse dataset line %d: %w", line, err)
+			}
+			cases = append(cases, c)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read dataset: %w", err)
+		}
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &cases); err != nil {
+			return nil, fmt.Errorf("failed to parse dataset: %w", err)
+		}
+	default:
+		if err := json.Unmarshal(data, &cases); err != nil {
+			return nil, fmt.Errorf("failed to parse dataset: %w", err)
+		}
+	}
+
+	if len(cases) == 0 {
+		return nil, errors.New("o dataset está vazio")
+	}
+	for i := range cases {
+		if cases[i].ID == "" {
+			cases[i].ID = fmt.Sprintf("case-%d", i+1)
+		}
+		if cases[i].Domain == "" || strings.TrimSpace(cases[i].ErrorDetails) == "" {
+			return nil, fmt.Errorf("caso %s: domain e error_details são obrigatórios", cases[i].ID)
+		}
+	}
+	return cases, nil
+}
+
+func loadReport(path string) ([]evalRun, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline: %w", err)
+	}
+	var runs []evalRun
+	if err := json.Unmarshal(data, &runs); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline: %w", err)
+	}
+	return runs, nil
+}
+
+// printComparison imprime as métricas de cada execução lado a lado, os casos
+// que pioraram em relação à primeira e as falhas das execuções atuais
+func printComparison(w io.Writer, baseline []evalRun, current []evalRun) {
+	runs := append(append([]evalRun(nil), baseline...), current...)
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	row := func(name string, value func(evalSummary) string) {
+		fmt.Fprint(table, name)
+		for _, run := range runs {
+			fmt.Fprint(table, "\t"+value(run.Summary))
+		}
+		fmt.Fprintln(table)
+	}
+	percent := func(v float64) string { return fmt.Sprintf("%.1f%%", 100*v) }
+
+	fmt.Fprint(table, "")
+	for _, run := range runs {
+		fmt.Fprint(table, "\t"+run.Label)
+	}
+	fmt.Fprintln(table)
+	row("casos", func(s evalSummary) string { return fmt.Sprint(s.Cases) })
+	row("acurácia", func(s evalSummary) string { return percent(s.Accuracy) })
+	row("JSON válido", func(s evalSummary) string { return percent(s.JSONValidity) })
+	row("erros", func(s evalSummary) string { return fmt.Sprint(s.Errors) })
+	row("latência p50", func(s evalSummary) string { return fmt.Sprintf("%dms", s.LatencyP50Ms) })
+	row("latência p95", func(s evalSummary) string { return fmt.Sprintf("%dms", s.LatencyP95Ms) })
+	row("latência média", func(s evalSummary) string { return fmt.Sprintf("%dms", s.LatencyMeanMs) })
+	row("tokens prompt/resposta", func(s evalSummary) string {
+		return fmt.Sprintf("%d/%d", s.PromptTokens, s.CompletionTokens)
+	})
+	table.Flush()
+
+	var problems []string
+	if len(runs) > 1 {
+		reference := make(map[string]string)
+		for _, result := range runs[0].Results {
+			reference[result.ID] = result.Status
+		}
+		for _, run := range runs[1:] {
+			for _, result := range run.Results {
+				if status, ok := reference[result.ID]; ok && status == evalPassed && result.Status != evalPassed {
+					problems = append(problems, fmt.Sprintf("regressão em %s: %s (%s)", run.Label, result.ID, resultProblem(result)))
+				}
+			}
+		}
+	}
+	for _, run := range current {
+		for _, result := range run.Results {
+			if result.Status != evalPassed {
+				problems = append(problems, fmt.Sprintf("%s %s [%s]: %s", run.Label, result.ID, result.Status, resultProblem(result)))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		fmt.Fprintf(w, "\n%s\n", strings.Join(problems, "\n"))
+	}
+}
+
+func resultProblem(result evalResult) string {
+	if result.Error != "" {
+		return result.Error
+	}
+	return strings.Join(result.Failures, "; ")
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"hefestus-api/internal/services"
+)
+
+// TestDatasetCategoriesMatchDictionaries garante que os casos do dataset de
+// exemplo casam com a categoria esperada nos dicionários de config/domains.json
+func TestDatasetCategoriesMatchDictionaries(t *testing.T) {
+	root := filepath.Join("..", "..")
+	cases, err := loadDataset(filepath.Join(root, "contrib", "eval", "dataset.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dictService, err := services.NewDictionaryService(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range cases {
+		if c.ExpectedCategory == "" {
+			continue
+		}
+		t.Run(c.ID, func(t *testing.T) {
+			var categories []string
+			for _, match := range dictService.FindMatches(context.Background(), c.Domain, c.ErrorDetails) {
+				categories = append(categories, match.Category)
+			}
+			if !containsFold(categories, c.ExpectedCategory) {
+				t.Errorf("expected category %s, dictionary matched %v", c.ExpectedCategory, categories)
+			}
+		})
+	}
+}
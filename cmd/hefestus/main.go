@@ -0,0 +1,72 @@
+// Command hefestus envia logs para a API Hefestus e imprime o diagnóstico:
+//
+//	kubectl logs pod/api | hefestus analyze -d kubernetes
+//	hefestus ci -d github -- make test
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"hefestus-api/internal/logging"
+)
+
+// Códigos de saída
+const (
+	exitOK = 0
+	// exitAnalysisFailed indica que a análise falhou ou não retornou causa
+	exitAnalysisFailed = 1
+	// exitUsage indica argumentos, entrada ou configuração inválidos
+	exitUsage = 2
+)
+
+const usageText = `Uso: hefestus <comando> [opções]
+
+Comandos:
+  analyze   analisa um log lido da entrada padrão, de um arquivo ou dos argumentos
+            (--offline usa o Ollama local, sem a API)
+  ci        executa um comando e, se ele falhar, analisa a saída e anota o job
+            (GitHub Actions, GitLab CI ou texto)
+  eval      avalia prompts e modelos com um dataset de erros e respostas esperadas
+  health    verifica se a API está no ar
+
+Use "hefestus <comando> -h" para as opções de cada comando.
+`
+
+func main() {
+	// Os logs dos serviços do modo offline vão para stderr, só a partir de
+	// warn por padrão, para não se misturar ao diagnóstico em stdout
+	level := os.Getenv("LOG_LEVEL")
+	if level == "" {
+		level = "warn"
+	}
+	slog.SetDefault(logging.New(os.Stderr, level, "text"))
+
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usageText)
+		return exitUsage
+	}
+
+	switch args[0] {
+	case "analyze":
+		return runAnalyze(args[1:], stdin, stdout, stderr)
+	case "ci":
+		return runCI(args[1:], stdin, stdout, stderr)
+	case "eval":
+		return runEval(args[1:], stdout, stderr)
+	case "health":
+		return runHealth(args[1:], stdout, stderr)
+	case "-h", "--help", "help":
+		fmt.Fprint(stdout, usageText)
+		return exitOK
+	default:
+		fmt.Fprintf(stderr, "comando desconhecido: %s\n\n%s", args[0], usageText)
+		return exitUsage
+	}
+}
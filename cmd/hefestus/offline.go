@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	client "hefestus-api/api"
+	"hefestus-api/internal/services"
+	"hefestus-api/pkg/ollama"
+)
+
+// errUnknownDomain indica um domínio ausente do config/domains.json local
+var errUnknownDomain = errors.New("unknown domain")
+
+// offlineDir retorna o diretório com config/domains.json e os dicionários:
+// o informado, o atual se tiver config/domains.json ou ~/.config/hefestus
+func offlineDir(dir string) (string, error) {
+	if dir != "" {
+		return dir, nil
+	}
+	if _, err := os.Stat(filepath.Join("config", "domains.json")); err == nil {
+		return ".", nil
+	}
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find config dir: %w", err)
+	}
+	return filepath.Join(configDir, "hefestus"), nil
+}
+
+// pipeline reúne os serviços do servidor usados no próprio processo
+type pipeline struct {
+	dictService       *services.DictionaryService
+	preprocessService *services.PreprocessService
+	llmService        *services.LLMService
+	cassette          *ollama.Cassette
+}
+
+// newPipeline carrega config/domains.json e os dicionários de dir com os
+// mesmos DictionaryService, PreprocessService e LLMService do servidor, sem
+// mudar o diretório de trabalho; cassette, se não for nil, grava ou reproduz
+// as chamadas ao modelo
+func newPipeline(dir string, cassette *ollama.Cassette) (*pipeline, error) {
+	dictService, err := services.NewDictionaryService(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &pipeline{
+		dictService:       dictService,
+		preprocessService: services.NewPreprocessService(dictService),
+		llmService:        services.NewLLMService(services.NewOllamaClient(dictService, ollama.WithCassette(cassette)), dictService),
+		cassette:          cassette,
+	}, nil
+}
+
+// analyze pré-processa o log e consulta o Ollama local, sem notificações nem ações
+func (p *pipeline) analyze(ctx context.Context, domain string, request client.ErrorRequest) (*client.ErrorResponse, error) {
+	if _, ok := p.dictService.GetDomainConfig(ctx, domain); !ok {
+		return nil, fmt.Errorf("%w: %s (disponíveis: %s)", errUnknownDomain, domain, strings.Join(p.dictService.Domains(), ", "))
+	}
+
+	errorDetails, report := p.preprocessService.Process(ctx, domain, request.ErrorDetails)
+	solution, err := p.llmService.GetResolution(ctx, domain, errorDetails, request.Context)
+	if err != nil {
+		return nil, err
+	}
+
+	return &client.ErrorResponse{
+		Error:         solution,
+		Message:       "Análise concluída com sucesso",
+		Preprocessing: report,
+	}, nil
+}
+
+// analyzeOffline roda a análise no próprio processo com o Ollama local,
+// respeitando OLLAMA_CASSETTE_MODE como o servidor
+func analyzeOffline(ctx context.Context, dir string, domain string, request client.ErrorRequest) (*client.ErrorResponse, error) {
+	cassette, err := ollama.CassetteFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	p, err := newPipeline(dir, cassette)
+	if err != nil {
+		return nil, err
+	}
+	return p.analyze(ctx, domain, request)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	client "hefestus-api/api"
+)
+
+// Formatos de saída
+const (
+	outputText     = "text"
+	outputJSON     = "json"
+	outputMarkdown = "markdown"
+)
+
+func validOutput(format string) bool {
+	return format == outputText || format == outputJSON || format == outputMarkdown
+}
+
+// render imprime o diagnóstico no formato escolhido
+func render(w io.Writer, format string, domain string, response *client.ErrorResponse) error {
+	if format == outputJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(response)
+	}
+
+	solution := response.Error
+	steps := strings.Split(strings.TrimSpace(solution.Solucao), "\n")
+
+	if format == outputMarkdown {
+		fmt.Fprintf(w, "### Hefestus (%s): %s\n\n", domain, solution.Causa)
+		fmt.Fprintf(w, "```sh\n%s\n```\n", strings.Join(steps, "\n"))
+		if len(solution.References) > 0 {
+			fmt.Fprintln(w, "\n**Referências**")
+			for _, reference := range solution.References {
+				fmt.Fprintf(w, "- %s\n", reference)
+			}
+		}
+		if len(response.Actions) > 0 {
+			fmt.Fprintln(w, "\n**Ações**")
+			for _, action := range response.Actions {
+				fmt.Fprintf(w, "- `%s` (%s): %s\n", action.Pattern, action.Type, action.Status)
+			}
+		}
+		return nil
+	}
+
+	fmt.Fprintf(w, "Domínio: %s\nCausa:   %s\nSolução:\n", domain, solution.Causa)
+	for _, step := range steps {
+		fmt.Fprintf(w, "  %s\n", step)
+	}
+	if len(solution.References) > 0 {
+		fmt.Fprintln(w, "Referências:")
+		for _, reference := range solution.References {
+			fmt.Fprintf(w, "  - %s\n", reference)
+		}
+	}
+	if len(response.Actions) > 0 {
+		fmt.Fprintln(w, "Ações:")
+		for _, action := range response.Actions {
+			fmt.Fprintf(w, "  - %s (%s): %s\n", action.Pattern, action.Type, action.Status)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"hefestus-api/internal/actions"
+	"hefestus-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	apiKeyHeader        = "X-API-Key"
+	hmacKeyIDHeader     = "X-Hefestus-Key-Id"
+	hmacTimestampHeader = actions.TimestampHeader
+	hmacSignatureHeader = actions.SignatureHeader
+
+	// principalKey guarda no gin.Context o cliente autenticado
+	principalKey = "auth.principal"
+
+	scopeAdmin = "admin"
+	// scopeActionsExecute permite action_mode=execute na análise
+	scopeActionsExecute = "actions:execute"
+	// scopeActionsApprove permite aprovar ações pendentes
+	scopeActionsApprove = "actions:approve"
+
+	// maxSignedBodyBytes limita o corpo lido para conferir a assinatura HMAC,
+	// já que o key id não é segredo
+	maxSignedBodyBytes = 1 << 20
+)
+
+// errBodyTooLarge indica um corpo assinado acima de maxSignedBodyBytes
+var errBodyTooLarge = errors.New("request body too large")
+
+// AuthConfig descreve as credenciais aceitas pelo servidor (config/auth.json)
+type AuthConfig struct {
+	Enabled bool `json:"enabled"`
+	// MaxSkewSeconds limita a diferença entre o timestamp assinado e o relógio do servidor
+	MaxSkewSeconds int          `json:"max_skew_seconds"`
+	APIKeys        []APIKey     `json:"api_keys"`
+	HMACClients    []HMACClient `json:"hmac_clients"`
+	Actions        ActionsAuth  `json:"actions"`
+}
+
+// ActionsAuth controla quem pode disparar e aprovar ações de autocorreção
+type ActionsAuth struct {
+	// AllowUnauthenticated libera execução e aprovação de ações quando a
+	// autenticação está desativada; o padrão é recusar
+	AllowUnauthenticated bool `json:"allow_unauthenticated"`
+}
+
+// APIKey é uma chave estática; apenas o SHA-256 da chave fica na configuração
+type APIKey struct {
+	ID        string                  `json:"id"`
+	Hash      string                  `json:"sha256"`
+	Scopes    []string                `json:"scopes"`
+	Tenant    string                  `json:"tenant,omitempty"`
+	RateLimit *models.RateLimitConfig `json:"rate_limit,omitempty"`
+}
+
+// HMACClient assina as requisições com um segredo compartilhado, lido da
+// variável de ambiente indicada em SecretEnv
+type HMACClient struct {
+	ID        string                  `json:"id"`
+	SecretEnv string                  `json:"secret_env"`
+	Scopes    []string                `json:"scopes"`
+	Tenant    string                  `json:"tenant,omitempty"`
+	RateLimit *models.RateLimitConfig `json:"rate_limit,omitempty"`
+
+	secret []byte
+}
+
+// Principal identifica o cliente autenticado e o que ele pode fazer
+type Principal struct {
+	ID     string
+	Method string
+	Scopes []string
+	// Tenant fixa o escopo de domínios e dicionários da chave
+	Tenant string
+	// RateLimit sobrescreve os limites do domínio para este cliente
+	RateLimit *models.RateLimitConfig
+}
+
+// HasScope verifica se o cliente possui o escopo, considerando admin e
+// curingas do tipo analyze:*
+func (p *Principal) HasScope(scope string) bool {
+	for _, granted := range p.Scopes {
+		if granted == scopeAdmin || granted == scope {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(granted, "*"); ok && strings.HasPrefix(scope, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticator valida API keys e assinaturas HMAC das requisições
+type Authenticator struct {
+	config  AuthConfig
+	keys    map[string]APIKey
+	clients map[string]HMACClient
+}
+
+// loadAuthConfig lê a configuração de autenticação de AUTH_CONFIG
+// (padrão config/auth.json). Sem arquivo, a autenticação fica desativada.
+func loadAuthConfig() (*AuthConfig, error) {
+	path := os.Getenv("AUTH_CONFIG")
+	if path == "" {
+		path = "config/auth.json"
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &AuthConfig{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read auth config: %w", err)
+	}
+
+	var config AuthConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse auth config: %w", err)
+	}
+	return &config, nil
+}
+
+// NewAuthenticator indexa as credenciais e carrega os segredos HMAC do ambiente
+func NewAuthenticator(config AuthConfig) (*Authenticator, error) {
+	if config.MaxSkewSeconds <= 0 {
+		config.MaxSkewSeconds = 300
+	}
+
+	a := &Authenticator{
+		config:  config,
+		keys:    make(map[string]APIKey, len(config.APIKeys)),
+		clients: make(map[string]HMACClient, len(config.HMACClients)),
+	}
+
+	for _, key := range config.APIKeys {
+		hash := strings.ToLower(strings.TrimPrefix(key.Hash, "sha256:"))
+		if decoded, err := hex.DecodeString(hash); err != nil || len(decoded) != sha256.Size {
+			return nil, fmt.Errorf("api key %s: sha256 must be a hex-encoded SHA-256 digest", key.ID)
+		}
+		key.Hash = hash
+		a.keys[hash] = key
+	}
+
+	for _, client := range config.HMACClients {
+		secret := os.Getenv(client.SecretEnv)
+		if secret == "" {
+			return nil, fmt.Errorf("hmac client %s: environment variable %s is empty", client.ID, client.SecretEnv)
+		}
+		client.secret = []byte(secret)
+		a.clients[client.ID] = client
+	}
+
+	return a, nil
+}
+
+// Authenticate identifica o cliente pelo header de API key ou pela assinatura HMAC
+func (a *Authenticator) Authenticate(c *gin.Context) (*Principal, error) {
+	if keyID := c.GetHeader(hmacKeyIDHeader); keyID != "" {
+		return a.authenticateHMAC(c, keyID)
+	}
+
+	key := c.GetHeader(apiKeyHeader)
+	if key == "" {
+		if token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok {
+			key = strings.TrimSpace(token)
+		}
+	}
+	if key == "" {
+		return nil, errors.New("missing API key or HMAC signature")
+	}
+
+	digest := sha256.Sum256([]byte(key))
+	hash := hex.EncodeToString(digest[:])
+	for stored, apiKey := range a.keys {
+		if subtle.ConstantTimeCompare([]byte(stored), []byte(hash)) == 1 {
+			return &Principal{ID: apiKey.ID, Method: "api_key", Scopes: apiKey.Scopes, Tenant: apiKey.Tenant, RateLimit: apiKey.RateLimit}, nil
+		}
+	}
+	return nil, errors.New("invalid API key")
+}
+
+// authenticateHMAC valida a assinatura de timestamp, método, caminho e corpo
+func (a *Authenticator) authenticateHMAC(c *gin.Context, keyID string) (*Principal, error) {
+	client, ok := a.clients[keyID]
+	if !ok {
+		return nil, errors.New("unknown HMAC key id")
+	}
+
+	timestamp := c.GetHeader(hmacTimestampHeader)
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid signature timestamp")
+	}
+	if math.Abs(time.Since(time.Unix(unix, 0)).Seconds()) > float64(a.config.MaxSkewSeconds) {
+		return nil, errors.New("signature timestamp outside allowed window")
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxSignedBodyBytes))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return nil, fmt.Errorf("%w: limit is %d bytes", errBodyTooLarge, tooLarge.Limit)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	signature, _ := strings.CutPrefix(c.GetHeader(hmacSignatureHeader), "sha256=")
+	expected := actions.Sign(client.secret, timestamp, c.Request.Method, c.Request.URL.RequestURI(), body)
+	if !hmac.Equal([]byte(strings.ToLower(signature)), []byte(expected)) {
+		return nil, errors.New("invalid HMAC signature")
+	}
+
+	return &Principal{ID: client.ID, Method: "hmac", Scopes: client.Scopes, Tenant: client.Tenant, RateLimit: client.RateLimit}, nil
+}
+
+// RequireScope autentica a requisição e exige o escopo calculado por scopeFor.
+// Com scopeFor nil basta estar autenticado.
+func (a *Authenticator) RequireScope(scopeFor func(c *gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !a.config.Enabled {
+			c.Next()
+			return
+		}
+
+		principal, err := a.Authenticate(c)
+		if errors.Is(err, errBodyTooLarge) {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, models.APIError{
+				Code:    http.StatusRequestEntityTooLarge,
+				Message: "Corpo da requisição muito grande",
+				Details: err.Error(),
+			})
+			return
+		}
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer realm="hefestus"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, models.APIError{
+				Code:    http.StatusUnauthorized,
+				Message: "Não autenticado",
+				Details: err.Error(),
+			})
+			return
+		}
+
+		if scopeFor == nil {
+			c.Set(principalKey, principal)
+			c.Next()
+			return
+		}
+
+		scope := scopeFor(c)
+		if !principal.HasScope(scope) {
+			slog.Warn("acesso negado", "principal", principal.ID, "scope", scope, "path", c.Request.URL.Path)
+			c.AbortWithStatusJSON(http.StatusForbidden, models.APIError{
+				Code:    http.StatusForbidden,
+				Message: "Acesso negado",
+				Details: fmt.Sprintf("O escopo %s é necessário", scope),
+			})
+			return
+		}
+
+		c.Set(principalKey, principal)
+		c.Next()
+	}
+}
+
+// principalFrom retorna o cliente autenticado pelo middleware, se houver
+func principalFrom(c *gin.Context) *Principal {
+	if value, ok := c.Get(principalKey); ok {
+		return value.(*Principal)
+	}
+	return nil
+}
+
+// analyzeScope exige analyze:<domínio> conforme o parâmetro da rota
+func analyzeScope(c *gin.Context) string {
+	return "analyze:" + c.Param("domain")
+}
+
+// allowActions libera action_mode=execute para clientes com o escopo
+// actions:execute. Com a autenticação desativada, só se
+// actions.allow_unauthenticated estiver habilitado
+func (a *Authenticator) allowActions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if a.anonymousActions() {
+			c.Request = c.Request.WithContext(actions.AllowExecution(c.Request.Context()))
+		} else if principal := principalFrom(c); a.config.Enabled && principal != nil && principal.HasScope(scopeActionsExecute) {
+			c.Request = c.Request.WithContext(actions.AllowExecution(c.Request.Context()))
+		}
+		c.Next()
+	}
+}
+
+// requireActionsAccess recusa aprovações de ações quando a autenticação está
+// desativada, pois aprovar dispara a ação, a menos que
+// actions.allow_unauthenticated esteja habilitado
+func (a *Authenticator) requireActionsAccess() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !a.config.Enabled && !a.anonymousActions() {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.APIError{
+				Code:    http.StatusForbidden,
+				Message: "Acesso negado",
+				Details: "Ações exigem autenticação ou actions.allow_unauthenticated em auth.json",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// anonymousActions informa se clientes sem autenticação podem disparar ações
+func (a *Authenticator) anonymousActions() bool {
+	return !a.config.Enabled && a.config.Actions.AllowUnauthenticated
+}
+
+// actionsApproveScope exige actions:approve
+func actionsApproveScope(*gin.Context) string {
+	return scopeActionsApprove
+}
+
+// integrationScope exige integrations:<nome> para os receptores de eventos
+func integrationScope(name string) func(*gin.Context) string {
+	return func(*gin.Context) string {
+		return "integrations:" + name
+	}
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"hefestus-api/internal/actions"
+	"hefestus-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	testAPIKey     = "hf_test_key"
+	testHMACSecret = "hmac-secret"
+)
+
+// newTestAuthenticator aceita uma API key com analyze:kubernetes e um cliente
+// HMAC com analyze:*
+func newTestAuthenticator(t *testing.T) *Authenticator {
+	t.Helper()
+	t.Setenv("TEST_HMAC_SECRET", testHMACSecret)
+	digest := sha256.Sum256([]byte(testAPIKey))
+	auth, err := NewAuthenticator(AuthConfig{
+		Enabled:        true,
+		MaxSkewSeconds: 60,
+		APIKeys: []APIKey{
+			{ID: "pipeline", Hash: "sha256:" + strings.ToUpper(hex.EncodeToString(digest[:])), Scopes: []string{"analyze:kubernetes"}},
+		},
+		HMACClients: []HMACClient{
+			{ID: "alerts", SecretEnv: "TEST_HMAC_SECRET", Scopes: []string{"analyze:*"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return auth
+}
+
+// newAuthRouter devolve o corpo recebido para conferir que a leitura da
+// assinatura não o consome
+func newAuthRouter(auth *Authenticator) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/api/errors/:domain", auth.RequireScope(analyzeScope), func(c *gin.Context) {
+		body, _ := c.GetRawData()
+		c.JSON(http.StatusOK, gin.H{"principal": principalFrom(c).ID, "body": string(body)})
+	})
+	return r
+}
+
+// signedRequest assina a requisição como actions.Sign, com o timestamp informado
+func signedRequest(path string, body []byte, timestamp time.Time, secret string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+	unix := strconv.FormatInt(timestamp.Unix(), 10)
+	req.Header.Set(hmacKeyIDHeader, "alerts")
+	req.Header.Set(hmacTimestampHeader, unix)
+	req.Header.Set(hmacSignatureHeader, "sha256="+actions.Sign([]byte(secret), unix, http.MethodPost, path, body))
+	return req
+}
+
+func TestNewAuthenticatorRejectsInvalidHash(t *testing.T) {
+	for _, hash := range []string{"", "plaintext-key", "sha256:abcd"} {
+		_, err := NewAuthenticator(AuthConfig{Enabled: true, APIKeys: []APIKey{{ID: "bad", Hash: hash}}})
+		if err == nil {
+			t.Errorf("hash %q accepted", hash)
+		}
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	r := newAuthRouter(newTestAuthenticator(t))
+	body := []byte(`{"error_details":"OOMKilled"}`)
+
+	tests := []struct {
+		name      string
+		request   func() *http.Request
+		status    int
+		principal string
+		message   string
+	}{
+		{
+			name: "api key header",
+			request: func() *http.Request {
+				req := httptest.NewRequest(http.MethodPost, "/api/errors/kubernetes", nil)
+				req.Header.Set(apiKeyHeader, testAPIKey)
+				return req
+			},
+			status:    http.StatusOK,
+			principal: "pipeline",
+		},
+		{
+			name: "bearer token",
+			request: func() *http.Request {
+				req := httptest.NewRequest(http.MethodPost, "/api/errors/kubernetes", nil)
+				req.Header.Set("Authorization", "Bearer "+testAPIKey)
+				return req
+			},
+			status:    http.StatusOK,
+			principal: "pipeline",
+		},
+		{
+			name: "missing credentials",
+			request: func() *http.Request {
+				return httptest.NewRequest(http.MethodPost, "/api/errors/kubernetes", nil)
+			},
+			status:  http.StatusUnauthorized,
+			message: "Não autenticado",
+		},
+		{
+			name: "wrong api key",
+			request: func() *http.Request {
+				req := httptest.NewRequest(http.MethodPost, "/api/errors/kubernetes", nil)
+				req.Header.Set(apiKeyHeader, "hf_other_key")
+				return req
+			},
+			status:  http.StatusUnauthorized,
+			message: "Não autenticado",
+		},
+		{
+			name: "api key without the domain scope",
+			request: func() *http.Request {
+				req := httptest.NewRequest(http.MethodPost, "/api/errors/github", nil)
+				req.Header.Set(apiKeyHeader, testAPIKey)
+				return req
+			},
+			status:  http.StatusForbidden,
+			message: "Acesso negado",
+		},
+		{
+			name: "hmac signature",
+			request: func() *http.Request {
+				return signedRequest("/api/errors/github", body, time.Now(), testHMACSecret)
+			},
+			status:    http.StatusOK,
+			principal: "alerts",
+		},
+		{
+			name: "hmac with wrong secret",
+			request: func() *http.Request {
+				return signedRequest("/api/errors/github", body, time.Now(), "other-secret")
+			},
+			status:  http.StatusUnauthorized,
+			message: "Não autenticado",
+		},
+		{
+			name: "hmac signed for another path",
+			request: func() *http.Request {
+				req := signedRequest("/api/errors/github", body, time.Now(), testHMACSecret)
+				req.URL.Path = "/api/errors/kubernetes"
+				req.RequestURI = ""
+				return req
+			},
+			status:  http.StatusUnauthorized,
+			message: "Não autenticado",
+		},
+		{
+			name: "hmac timestamp outside the window",
+			request: func() *http.Request {
+				return signedRequest("/api/errors/github", body, time.Now().Add(-2*time.Minute), testHMACSecret)
+			},
+			status:  http.StatusUnauthorized,
+			message: "Não autenticado",
+		},
+		{
+			name: "hmac timestamp in the future",
+			request: func() *http.Request {
+				return signedRequest("/api/errors/github", body, time.Now().Add(2*time.Minute), testHMACSecret)
+			},
+			status:  http.StatusUnauthorized,
+			message: "Não autenticado",
+		},
+		{
+			name: "hmac timestamp within the window",
+			request: func() *http.Request {
+				return signedRequest("/api/errors/github", body, time.Now().Add(-30*time.Second), testHMACSecret)
+			},
+			status:    http.StatusOK,
+			principal: "alerts",
+		},
+		{
+			name: "hmac body above the limit",
+			request: func() *http.Request {
+				return signedRequest("/api/errors/github", bytes.Repeat([]byte("a"), maxSignedBodyBytes+1), time.Now(), testHMACSecret)
+			},
+			status:  http.StatusRequestEntityTooLarge,
+			message: "Corpo da requisição muito grande",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, tt.request())
+			if w.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+
+			if tt.status == http.StatusOK {
+				var got struct{ Principal, Body string }
+				if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+					t.Fatal(err)
+				}
+				if got.Principal != tt.principal {
+					t.Errorf("principal %q, want %q", got.Principal, tt.principal)
+				}
+				if got.Principal == "alerts" && got.Body != string(body) {
+					t.Errorf("handler received body %q after the signature check", got.Body)
+				}
+				return
+			}
+
+			var apiErr models.APIError
+			if err := json.Unmarshal(w.Body.Bytes(), &apiErr); err != nil {
+				t.Fatalf("body is not an APIError: %s", w.Body.String())
+			}
+			if apiErr.Code != tt.status || apiErr.Message != tt.message || apiErr.Details == "" {
+				t.Errorf("unexpected APIError %+v", apiErr)
+			}
+			if challenge := w.Header().Get("WWW-Authenticate"); (tt.status == http.StatusUnauthorized) != (challenge != "") {
+				t.Errorf("WWW-Authenticate = %q with status %d", challenge, tt.status)
+			}
+		})
+	}
+}
+
+func TestRequireScopeDisabled(t *testing.T) {
+	auth, err := NewAuthenticator(AuthConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/api/errors/:domain", auth.RequireScope(analyzeScope), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/errors/kubernetes", nil))
+	if w.Code != http.StatusNoContent {
+		t.Errorf("status %d with authentication disabled", w.Code)
+	}
+}
+
+func TestHasScope(t *testing.T) {
+	tests := []struct {
+		scopes []string
+		scope  string
+		want   bool
+	}{
+		{[]string{"analyze:kubernetes"}, "analyze:kubernetes", true},
+		{[]string{"analyze:kubernetes"}, "analyze:github", false},
+		{[]string{"analyze:*"}, "analyze:github", true},
+		{[]string{"analyze:*"}, "actions:approve", false},
+		{[]string{"admin"}, "actions:approve", true},
+		{nil, "analyze:kubernetes", false},
+	}
+	for _, tt := range tests {
+		p := &Principal{Scopes: tt.scopes}
+		if got := p.HasScope(tt.scope); got != tt.want {
+			t.Errorf("%v.HasScope(%q) = %v, want %v", tt.scopes, tt.scope, got, tt.want)
+		}
+	}
+}
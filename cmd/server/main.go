@@ -1,16 +1,30 @@
 package main
 
 import (
-	"log"
+	"context"
+	"log/slog"
 	"os"
 
 	_ "hefestus-api/docs"
+	"hefestus-api/internal/actions"
+	"hefestus-api/internal/controller"
+	"hefestus-api/internal/enrichment"
 	"hefestus-api/internal/handlers"
+	"hefestus-api/internal/integrations"
+	"hefestus-api/internal/kubewatch"
+	"hefestus-api/internal/logging"
+	"hefestus-api/internal/notifier"
 	"hefestus-api/internal/services"
+	"hefestus-api/internal/tracing"
+	"hefestus-api/pkg/github"
+	"hefestus-api/pkg/kube"
 	"hefestus-api/pkg/ollama"
+	"hefestus-api/pkg/rundeck"
+	"hefestus-api/pkg/zabbix"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 // @title Hefestus API
@@ -20,10 +34,18 @@ import (
 // @BasePath /api
 // @schemes http
 
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+
 func init() {
 	// Carrega variáveis de ambiente do arquivo .env
-	if err := godotenv.Load(); err != nil {
-		log.Println("Arquivo .env não encontrado, usando variáveis de ambiente")
+	err := godotenv.Load()
+
+	// O nível de log depende do .env, então o logger só é configurado depois dele
+	logging.Setup()
+	if err != nil {
+		slog.Info("Arquivo .env não encontrado, usando variáveis de ambiente")
 	}
 }
 
@@ -31,42 +53,145 @@ func main() {
 	// Configura o modo do Gin baseado no ambiente
 	setGinMode()
 
+	// Configura tracing (no-op a menos que OTEL_TRACES_EXPORTER=otlp)
+	shutdownTracing, err := tracing.Setup(context.Background())
+	if err != nil {
+		fatal("Falha ao configurar tracing", err)
+	}
+	defer shutdownTracing(context.Background())
+
 	// Inicializa o router
-	r := gin.Default()
+	r := gin.New()
+	// O Gin confia em qualquer proxy por padrão; o X-Forwarded-For só é
+	// aceito dos proxies listados em TRUSTED_PROXIES
+	if err := r.SetTrustedProxies(trustedProxies()); err != nil {
+		fatal("TRUSTED_PROXIES inválido", err)
+	}
+	r.Use(gin.Recovery(), logging.Middleware(), tracing.Middleware())
 
 	// Inicializa serviços
-	dictService, err := services.NewDictionaryService()
+	dictService, err := services.NewDictionaryService("")
 	if err != nil {
-		log.Fatal("Falha ao inicializar serviço de dicionário:", err)
+		fatal("Falha ao inicializar serviço de dicionário", err)
 	}
 
-	// Inicializa cliente Ollama
-	ollamaClient := ollama.NewClient()
+	// Inicializa cliente Ollama, gravando ou reproduzindo as chamadas se
+	// OLLAMA_CASSETTE_MODE estiver definido
+	cassette, err := ollama.CassetteFromEnv()
+	if err != nil {
+		fatal("Falha ao configurar cassete do Ollama", err)
+	}
+	if cassette != nil {
+		slog.Warn("Cassete do Ollama ativo", "mode", cassette.Mode(), "dir", cassette.Dir())
+	}
+	ollamaClient := services.NewOllamaClient(dictService, ollama.WithCassette(cassette))
 
 	// Inicializa serviços
 	llmService := services.NewLLMService(ollamaClient, dictService)
+	preprocessService := services.NewPreprocessService(dictService)
+	actionRunner := actions.NewRunner(rundeck.NewClient())
+	kubeEnricher := enrichment.NewKubernetes(kube.NewClientset)
+	errorService := services.NewErrorService(llmService, preprocessService, notifier.NewNotifier(), actionRunner, kubeEnricher)
 
 	// Inicializa handlers
-	errorHandler := handlers.NewErrorHandler(llmService)
+	errorHandler := handlers.NewErrorHandler(errorService)
+	actionHandler := handlers.NewActionHandler(actionRunner)
+
+	// Inicializa integrações (Alertmanager, Zabbix, GitHub, Argo CD)
+	integrationsConfig, err := integrations.LoadConfig()
+	if err != nil {
+		fatal("Falha ao carregar configuração de integrações", err)
+	}
+	// Inicializa rate limiting e cotas; as integrações aplicam o limite do
+	// domínio resolvido a partir do evento
+	limiter := NewRateLimiter(dictService)
+	integrationHandler := handlers.NewIntegrationHandler(errorService, dictService, integrationsConfig, zabbix.NewClient(), github.NewClient(), limiter)
+
+	// Inicia o modo watch, que analisa falhas de pods sem esperar requisições
+	watchConfig, err := kubewatch.LoadConfig()
+	if err != nil {
+		fatal("Falha ao carregar configuração do modo watch", err)
+	}
+	if watchConfig.Enabled {
+		clientset, err := kube.NewClientset()
+		if err != nil {
+			fatal("Falha ao conectar ao cluster Kubernetes", err)
+		}
+		watcher := kubewatch.NewWatcher(clientset, errorService, *watchConfig)
+		go func() {
+			if err := watcher.Run(context.Background()); err != nil {
+				slog.Error("Watcher do Kubernetes encerrado", "error", err)
+			}
+		}()
+	}
+
+	// Inicia o controller do recurso Diagnosis (CRD em contrib/kubernetes/crd)
+	if os.Getenv("DIAGNOSIS_CONTROLLER_ENABLED") == "true" {
+		mgr, err := controller.NewManager(errorService, kubeEnricher)
+		if err != nil {
+			fatal("Falha ao inicializar controller de Diagnosis", err)
+		}
+		go func() {
+			if err := mgr.Start(context.Background()); err != nil {
+				slog.Error("Controller de Diagnosis encerrado", "error", err)
+			}
+		}()
+	}
+
+	// Inicializa autenticação
+	authConfig, err := loadAuthConfig()
+	if err != nil {
+		fatal("Falha ao carregar configuração de autenticação", err)
+	}
+	auth, err := NewAuthenticator(*authConfig)
+	if err != nil {
+		fatal("Falha ao inicializar autenticação", err)
+	}
+	if !authConfig.Enabled {
+		slog.Warn("Autenticação desativada; todas as rotas estão abertas")
+		if authConfig.Actions.AllowUnauthenticated {
+			slog.Warn("actions.allow_unauthenticated habilitado; qualquer cliente pode disparar e aprovar ações")
+		}
+	}
 
 	// Configura documentação Swagger
 	ConfigureSwagger(r)
 
+	// Expõe métricas no formato Prometheus
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
 	// Configura rotas da API
 	api := r.Group("/api")
 	{
 		api.GET("/health", errorHandler.HealthCheck)
-		api.POST("/errors/:domain", errorHandler.AnalyzeError)
+		resolveTenant := tenantMiddleware(dictService)
+		api.GET("/quota", auth.RequireScope(nil), resolveTenant, limiter.QuotaUsage)
+		api.POST("/errors/:domain", auth.RequireScope(analyzeScope), auth.allowActions(), resolveTenant, limiter.Limit(), errorHandler.AnalyzeError)
+		api.POST("/errors/:domain/stream", auth.RequireScope(analyzeScope), auth.allowActions(), resolveTenant, limiter.Limit(), errorHandler.AnalyzeErrorStream)
+		api.POST("/integrations/alertmanager", auth.RequireScope(integrationScope("alertmanager")), resolveTenant, integrationHandler.Alertmanager)
+		api.POST("/integrations/zabbix", auth.RequireScope(integrationScope("zabbix")), resolveTenant, integrationHandler.Zabbix)
+		api.POST("/integrations/argocd", auth.RequireScope(integrationScope("argocd")), resolveTenant, integrationHandler.ArgoCD)
+		// O GitHub não envia API key: o handler exige a assinatura
+		// X-Hub-Signature-256 com GITHUB_WEBHOOK_SECRET no lugar de RequireScope
+		api.POST("/integrations/github", resolveTenant, integrationHandler.GitHub)
+		api.GET("/actions/:id", auth.RequireScope(nil), resolveTenant, actionHandler.GetAction)
+		api.POST("/actions/:id/approve", auth.RequireScope(actionsApproveScope), auth.requireActionsAccess(), resolveTenant, actionHandler.ApproveAction)
 	}
 
 	// Inicia o servidor
 	port := getPort()
-	log.Printf("Servidor iniciado na porta %s", port)
+	slog.Info("Servidor iniciado", "port", port)
 	if err := r.Run(":" + port); err != nil {
-		log.Fatal("Falha ao iniciar servidor: ", err)
+		fatal("Falha ao iniciar servidor", err)
 	}
 }
 
+// fatal registra o erro e encerra o processo
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
 // setGinMode configura o modo do Gin baseado no ambiente
 func setGinMode() {
 	env := os.Getenv("ENV")
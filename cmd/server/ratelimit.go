@@ -0,0 +1,313 @@
+package main
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"hefestus-api/internal/models"
+	"hefestus-api/internal/services"
+	"hefestus-api/internal/usage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bucketSweepInterval é o intervalo mínimo entre varreduras de buckets ociosos
+const bucketSweepInterval = time.Minute
+
+// tokenBucket reabastece rate tokens por segundo até a capacidade burst
+type tokenBucket struct {
+	tokens   float64
+	updated  time.Time
+	rate     float64
+	capacity float64
+}
+
+// take consome um token e retorna quantos restam e o tempo até que haja um
+// token disponível
+func (b *tokenBucket) take(now time.Time) (bool, float64, time.Duration) {
+	b.tokens = math.Min(b.capacity, b.tokens+now.Sub(b.updated).Seconds()*b.rate)
+	b.updated = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		return false, b.tokens, wait
+	}
+	b.tokens--
+
+	var wait time.Duration
+	if b.tokens < 1 {
+		wait = time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	}
+	return true, b.tokens, wait
+}
+
+// idle informa se o bucket já estaria cheio em now; descartá-lo equivale a
+// recriá-lo na próxima requisição
+func (b *tokenBucket) idle(now time.Time) bool {
+	return b.tokens+now.Sub(b.updated).Seconds()*b.rate >= b.capacity
+}
+
+// RateLimiter aplica token bucket por cliente e domínio e a cota diária de
+// chamadas ao LLM
+type RateLimiter struct {
+	dictService *services.DictionaryService
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	swept   time.Time
+	day     string
+	quota   map[string]int
+	now     func() time.Time
+}
+
+// NewRateLimiter cria o limitador usando os limites configurados por domínio
+func NewRateLimiter(dictService *services.DictionaryService) *RateLimiter {
+	return &RateLimiter{
+		dictService: dictService,
+		buckets:     make(map[string]*tokenBucket),
+		quota:       make(map[string]int),
+		now:         time.Now,
+	}
+}
+
+// trustedProxies lê TRUSTED_PROXIES, IPs ou CIDRs separados por vírgula dos
+// proxies cujo X-Forwarded-For é aceito; vazio não confia em nenhum
+func trustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
+// clientKey identifica o cliente pela API key autenticada ou pelo IP de
+// origem, que só vem do X-Forwarded-For quando a conexão é de um proxy de
+// TRUSTED_PROXIES; sem isso qualquer cliente trocaria de bucket e de cota
+// trocando o header. Só o tenant fixado na chave entra na identificação: o escolhido pelo
+// header X-Tenant-ID não, para que trocar o header não multiplique a cota
+func clientKey(c *gin.Context) string {
+	principal := principalFrom(c)
+	if principal == nil {
+		return "ip:" + c.ClientIP()
+	}
+	if principal.Tenant != "" {
+		return "tenant:" + principal.Tenant + "/key:" + principal.ID
+	}
+	return "key:" + principal.ID
+}
+
+// limitsFor combina os limites do domínio com os sobrescritos pela chave
+func (l *RateLimiter) limitsFor(c *gin.Context, domain string) models.RateLimitConfig {
+	domainConfig, _ := l.dictService.GetDomainConfig(c.Request.Context(), domain)
+	limits := domainConfig.RateLimit
+
+	if principal := principalFrom(c); principal != nil && principal.RateLimit != nil {
+		override := principal.RateLimit
+		if override.RequestsPerMinute > 0 {
+			limits.RequestsPerMinute = override.RequestsPerMinute
+		}
+		if override.Burst > 0 {
+			limits.Burst = override.Burst
+		}
+		if override.DailyLLMQuota > 0 {
+			limits.DailyLLMQuota = override.DailyLLMQuota
+		}
+	}
+	return limits
+}
+
+// Limit aplica o rate limit e reserva cota antes do handler, para o domínio do
+// parâmetro da rota; a reserva é devolvida quando a resposta não precisou do LLM
+func (l *RateLimiter) Limit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, release, apiErr := l.Admit(c, c.Request.Context(), c.Param("domain"))
+		if apiErr != nil {
+			c.AbortWithStatusJSON(apiErr.Code, apiErr)
+			return
+		}
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+		release()
+	}
+}
+
+// Admit aplica o rate limit e reserva cota do domínio para o cliente da
+// requisição, preenchendo os headers X-RateLimit-* e X-Quota-*. Serve às rotas
+// em que o domínio só é conhecido depois de ler o evento, como as integrações.
+// release ajusta a cota às chamadas ao LLM feitas com o ctx retornado
+func (l *RateLimiter) Admit(c *gin.Context, ctx context.Context, domain string) (context.Context, func(), *models.APIError) {
+	client := clientKey(c)
+	limits := l.limitsFor(c, domain)
+	key := client + "|" + domain
+
+	if limits.RequestsPerMinute > 0 {
+		allowed, remaining, wait := l.takeToken(key, limits)
+		c.Header("X-RateLimit-Limit", strconv.Itoa(int(limits.RequestsPerMinute)))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(int(remaining)))
+		c.Header("X-RateLimit-Reset", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		if !allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			return ctx, nil, &models.APIError{
+				Code:    http.StatusTooManyRequests,
+				Message: "Limite de requisições excedido",
+				Details: "Aguarde " + wait.Round(time.Second).String() + " antes de tentar novamente",
+			}
+		}
+	}
+
+	if limits.DailyLLMQuota <= 0 {
+		return ctx, func() {}, nil
+	}
+
+	used, ok := l.reserve(key, limits.DailyLLMQuota)
+	reset := l.untilReset()
+	c.Header("X-Quota-Limit", strconv.Itoa(limits.DailyLLMQuota))
+	c.Header("X-Quota-Remaining", strconv.Itoa(max(0, limits.DailyLLMQuota-used)))
+	c.Header("X-Quota-Reset", strconv.Itoa(int(reset.Seconds())))
+	if !ok {
+		c.Header("Retry-After", strconv.Itoa(int(reset.Seconds())))
+		return ctx, nil, &models.APIError{
+			Code:    http.StatusTooManyRequests,
+			Message: "Cota diária de chamadas ao LLM esgotada",
+			Details: "A cota é renovada à meia-noite UTC",
+		}
+	}
+
+	ctx, tracker := usage.WithTracker(ctx)
+	return ctx, func() {
+		// A reserva cobre uma chamada; ajusta para o consumo real
+		l.adjust(key, tracker.LLMCalls()-1)
+	}, nil
+}
+
+func (l *RateLimiter) takeToken(key string, limits models.RateLimitConfig) (bool, float64, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+	bucket, ok := l.buckets[key]
+	if !ok {
+		capacity := float64(limits.Burst)
+		if capacity < 1 {
+			capacity = math.Max(1, limits.RequestsPerMinute)
+		}
+		bucket = &tokenBucket{tokens: capacity, updated: now}
+		l.buckets[key] = bucket
+	}
+
+	// Limites podem mudar entre requisições quando a chave sobrescreve o domínio
+	bucket.rate = limits.RequestsPerMinute / 60
+	bucket.capacity = float64(limits.Burst)
+	if bucket.capacity < 1 {
+		bucket.capacity = math.Max(1, limits.RequestsPerMinute)
+	}
+
+	return bucket.take(now)
+}
+
+// sweep descarta os buckets ociosos, que já estariam cheios, para que clientes
+// e IPs que deixaram de chamar não fiquem na memória; deve ser chamado com mu
+// travado
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < bucketSweepInterval {
+		return
+	}
+	l.swept = now
+	for key, bucket := range l.buckets {
+		if bucket.idle(now) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// reserve consome uma unidade da cota do dia, se houver saldo
+func (l *RateLimiter) reserve(key string, limit int) (int, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.rollDay()
+	if l.quota[key] >= limit {
+		return l.quota[key], false
+	}
+	l.quota[key]++
+	return l.quota[key], true
+}
+
+func (l *RateLimiter) adjust(key string, delta int) {
+	if delta == 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.rollDay()
+	// Reservas devolvidas por inteiro não deixam entrada no mapa
+	if used := l.quota[key] + delta; used > 0 {
+		l.quota[key] = used
+	} else {
+		delete(l.quota, key)
+	}
+}
+
+// rollDay zera as cotas na virada do dia UTC; deve ser chamado com mu travado
+func (l *RateLimiter) rollDay() {
+	today := l.now().UTC().Format(time.DateOnly)
+	if l.day != today {
+		l.day = today
+		l.quota = make(map[string]int)
+	}
+}
+
+func (l *RateLimiter) untilReset() time.Duration {
+	now := l.now().UTC()
+	midnight := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	return midnight.Sub(now)
+}
+
+// QuotaUsage retorna o consumo de cota do cliente autenticado
+// @Summary      Consultar uso da cota
+// @Description  Retorna, para o cliente autenticado (ou IP de origem), as chamadas ao LLM feitas hoje em cada domínio
+// @Tags         system
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {object}  models.QuotaUsage
+// @Failure      401  {object}  models.APIError  "Credenciais ausentes ou inválidas"
+// @Router       /quota [get]
+func (l *RateLimiter) QuotaUsage(c *gin.Context) {
+	client := clientKey(c)
+	reset := int(l.untilReset().Seconds())
+
+	response := models.QuotaUsage{Client: client}
+	for _, domain := range l.dictService.Domains() {
+		limits := l.limitsFor(c, domain)
+
+		l.mu.Lock()
+		l.rollDay()
+		used := l.quota[client+"|"+domain]
+		l.mu.Unlock()
+
+		quota := models.DomainQuota{
+			Domain:       domain,
+			Used:         used,
+			Limit:        limits.DailyLLMQuota,
+			ResetSeconds: reset,
+		}
+		if limits.DailyLLMQuota > 0 {
+			quota.Remaining = max(0, limits.DailyLLMQuota-used)
+		}
+		response.Domains = append(response.Domains, quota)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
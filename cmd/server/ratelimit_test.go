@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"hefestus-api/internal/services"
+	"hefestus-api/internal/usage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestDictService cria um domínio kubernetes com os limites informados
+func newTestDictService(t *testing.T, rateLimit string) *services.DictionaryService {
+	t.Helper()
+	dir := t.TempDir()
+	config := fmt.Sprintf(`{"domains": {"kubernetes": {"prompt_template": "Analise o erro.", "rate_limit": %s}}}`, rateLimit)
+	if err := os.MkdirAll(filepath.Join(dir, "config"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config", "domains.json"), []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TENANTS_CONFIG", filepath.Join(dir, "tenants.json"))
+	dictService, err := services.NewDictionaryService(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dictService
+}
+
+// newLimitedRouter monta a rota de análise com o limitador; o handler só
+// consome cota do LLM quando a requisição traz X-Test-LLM, como uma resposta
+// vinda do modelo (e não do cache ou só do dicionário)
+func newLimitedRouter(t *testing.T, limiter *RateLimiter) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	if err := r.SetTrustedProxies(trustedProxies()); err != nil {
+		t.Fatal(err)
+	}
+	r.POST("/api/errors/:domain", limiter.Limit(), func(c *gin.Context) {
+		if c.GetHeader("X-Test-LLM") != "" {
+			usage.RecordLLMCall(c.Request.Context())
+		}
+		c.Status(http.StatusOK)
+	})
+	return r
+}
+
+func analyze(r http.Handler, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/errors/kubernetes", nil)
+	req.RemoteAddr = "192.0.2.10:40000"
+	for name, values := range header {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimitIgnoresSpoofedForwardedFor(t *testing.T) {
+	limiter := NewRateLimiter(newTestDictService(t, `{"requests_per_minute": 1, "burst": 1}`))
+
+	t.Run("untrusted", func(t *testing.T) {
+		t.Setenv("TRUSTED_PROXIES", "")
+		r := newLimitedRouter(t, limiter)
+		if w := analyze(r, http.Header{"X-Forwarded-For": {"203.0.113.1"}}); w.Code != http.StatusOK {
+			t.Fatalf("first request: status %d", w.Code)
+		}
+		// Trocar o X-Forwarded-For não cria outro bucket
+		if w := analyze(r, http.Header{"X-Forwarded-For": {"203.0.113.2"}}); w.Code != http.StatusTooManyRequests {
+			t.Fatalf("spoofed X-Forwarded-For: status %d, want 429", w.Code)
+		}
+	})
+
+	t.Run("trusted proxy", func(t *testing.T) {
+		t.Setenv("TRUSTED_PROXIES", "192.0.2.0/24")
+		r := newLimitedRouter(t, limiter)
+		for _, ip := range []string{"198.51.100.1", "198.51.100.2"} {
+			if w := analyze(r, http.Header{"X-Forwarded-For": {ip}}); w.Code != http.StatusOK {
+				t.Fatalf("client %s behind the proxy: status %d", ip, w.Code)
+			}
+		}
+	})
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	limiter := NewRateLimiter(newTestDictService(t, `{"requests_per_minute": 30, "burst": 2}`))
+	now := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+	r := newLimitedRouter(t, limiter)
+
+	for remaining := 1; remaining >= 0; remaining-- {
+		w := analyze(r, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status %d within the burst", w.Code)
+		}
+		if got := w.Header().Get("X-RateLimit-Limit"); got != "30" {
+			t.Errorf("X-RateLimit-Limit = %q", got)
+		}
+		if got := w.Header().Get("X-RateLimit-Remaining"); got != fmt.Sprint(remaining) {
+			t.Errorf("X-RateLimit-Remaining = %q, want %d", got, remaining)
+		}
+	}
+
+	w := analyze(r, nil)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status %d after the burst, want 429", w.Code)
+	}
+	// 30 por minuto: um token a cada 2s
+	if w.Header().Get("Retry-After") != "2" || w.Header().Get("X-RateLimit-Reset") != "2" || w.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Errorf("unexpected headers %v", w.Header())
+	}
+
+	now = now.Add(2 * time.Second)
+	if w := analyze(r, nil); w.Code != http.StatusOK {
+		t.Fatalf("status %d after the bucket refilled", w.Code)
+	}
+}
+
+func TestDailyQuota(t *testing.T) {
+	limiter := NewRateLimiter(newTestDictService(t, `{"daily_llm_quota": 2}`))
+	now := time.Date(2026, 1, 2, 23, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+	r := newLimitedRouter(t, limiter)
+	llm := http.Header{"X-Test-LLM": {"1"}}
+
+	// Respostas do cache ou só do dicionário devolvem a reserva
+	for i := 0; i < 5; i++ {
+		if w := analyze(r, nil); w.Code != http.StatusOK || w.Header().Get("X-Quota-Remaining") != "1" {
+			t.Fatalf("answer without LLM call: status %d, remaining %q", w.Code, w.Header().Get("X-Quota-Remaining"))
+		}
+	}
+	if used := limiter.quota["ip:192.0.2.10|kubernetes"]; used != 0 {
+		t.Fatalf("answers without LLM calls used %d of the quota", used)
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := analyze(r, llm); w.Code != http.StatusOK {
+			t.Fatalf("LLM call %d: status %d", i+1, w.Code)
+		}
+	}
+	w := analyze(r, llm)
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("X-Quota-Remaining") != "0" {
+		t.Fatalf("status %d, remaining %q after the quota, want 429", w.Code, w.Header().Get("X-Quota-Remaining"))
+	}
+	if got := w.Header().Get("Retry-After"); got != "3600" {
+		t.Errorf("Retry-After = %q, want seconds until midnight UTC", got)
+	}
+
+	// A cota é renovada na virada do dia UTC
+	now = now.Add(time.Hour)
+	if w := analyze(r, llm); w.Code != http.StatusOK || w.Header().Get("X-Quota-Remaining") != "1" {
+		t.Fatalf("after midnight: status %d, remaining %q", w.Code, w.Header().Get("X-Quota-Remaining"))
+	}
+}
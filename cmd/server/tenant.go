@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"hefestus-api/internal/models"
+	"hefestus-api/internal/services"
+	"hefestus-api/internal/tenant"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tenantMiddleware resolve o tenant pela API key ou, se ela não fixar um,
+// pelo header X-Tenant-ID, e o propaga no context da requisição
+func tenantMiddleware(dictService *services.DictionaryService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(tenant.Header)
+
+		if principal := principalFrom(c); principal != nil && principal.Tenant != "" {
+			if id != "" && id != principal.Tenant {
+				c.AbortWithStatusJSON(http.StatusForbidden, models.APIError{
+					Code:    http.StatusForbidden,
+					Message: "Acesso negado",
+					Details: fmt.Sprintf("A chave %s pertence ao tenant %s", principal.ID, principal.Tenant),
+				})
+				return
+			}
+			id = principal.Tenant
+		}
+
+		if id == "" {
+			c.Next()
+			return
+		}
+
+		if !dictService.HasTenant(id) {
+			c.AbortWithStatusJSON(http.StatusNotFound, models.APIError{
+				Code:    http.StatusNotFound,
+				Message: "Tenant não encontrado",
+				Details: id,
+			})
+			return
+		}
+
+		c.Request = c.Request.WithContext(tenant.WithTenant(c.Request.Context(), id))
+		c.Next()
+	}
+}
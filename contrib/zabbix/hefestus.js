@@ -0,0 +1,45 @@
+// Script do media type webhook do Zabbix que envia problemas ao Hefestus.
+//
+// Parâmetros do media type:
+//   hefestus_url          http://hefestus:8080/api/integrations/zabbix
+//   hefestus_api_key      {$HEFESTUS.API.KEY}
+//   event_id              {EVENT.ID}
+//   event_name            {EVENT.NAME}
+//   event_value           {EVENT.VALUE}
+//   event_update_status   {EVENT.UPDATE.STATUS}
+//   severity              {EVENT.SEVERITY}
+//   host                  {HOST.NAME}
+//   host_groups           {TRIGGER.HOSTGROUP.NAME}
+//   tags                  {EVENT.TAGS}
+//   trigger_name          {TRIGGER.NAME}
+//   trigger_description   {TRIGGER.DESCRIPTION}
+//   item_name             {ITEM.NAME}
+//   item_value            {ITEM.VALUE}
+var params = JSON.parse(value);
+
+var payload = {};
+[
+    'event_id', 'event_name', 'event_value', 'event_update_status', 'severity', 'host',
+    'host_groups', 'tags', 'trigger_name', 'trigger_description', 'item_name', 'item_value'
+].forEach(function (field) {
+    var v = params[field] || '';
+    // Macros não resolvidas chegam como o próprio texto da macro
+    payload[field] = /^\{[A-Z.]+\}$/.test(v) ? '' : v;
+});
+
+var request = new HttpRequest();
+request.addHeader('Content-Type: application/json');
+request.addHeader('X-API-Key: ' + params.hefestus_api_key);
+
+var response = request.post(params.hefestus_url, JSON.stringify(payload));
+// 202: o problema foi aceito e será analisado em segundo plano
+if (request.getStatus() !== 200 && request.getStatus() !== 202) {
+    throw 'Hefestus respondeu com status ' + request.getStatus() + ': ' + response;
+}
+
+var result = JSON.parse(response);
+var item = result.items && result.items[0];
+if (item && item.status === 'failed') {
+    throw 'Hefestus não analisou o problema: ' + item.reason;
+}
+return JSON.stringify({ status: item ? item.status : 'unknown' });
@@ -15,8 +15,129 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/actions/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retorna uma ação disparada ou pendente, atualizando o estado da execução no Rundeck",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "actions"
+                ],
+                "summary": "Consultar ação de autocorreção",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tenant da requisição que originou a ação",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID da ação",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ActionResult"
+                        }
+                    },
+                    "401": {
+                        "description": "Credenciais ausentes ou inválidas",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Ação não encontrada ou expirada",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/actions/{id}/approve": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Dispara no Rundeck uma ação com require_approval que está em pending_approval",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "actions"
+                ],
+                "summary": "Aprovar ação de autocorreção",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tenant da requisição que originou a ação",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID da ação",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ActionResult"
+                        }
+                    },
+                    "401": {
+                        "description": "Credenciais ausentes ou inválidas",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "Escopo actions:approve ausente",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Ação não encontrada ou expirada",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "409": {
+                        "description": "Ação não aguarda aprovação",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
         "/errors/{domain}": {
             "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
                 "description": "Recebe detalhes de um erro e seu contexto, retornando possíveis soluções baseadas em LLM",
                 "consumes": [
                     "application/json"
@@ -29,6 +150,12 @@ const docTemplate = `{
                 ],
                 "summary": "Analisar e resolver erros por domínio",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tenant cujas configurações e dicionários serão usados",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
                     {
                         "enum": [
                             "kubernetes",
@@ -64,103 +191,1414 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.APIError"
                         }
                     },
+                    "401": {
+                        "description": "Credenciais ausentes ou inválidas",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "Escopo analyze:{domain} ausente, ou actions:execute ausente com action_mode=execute",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
                     "404": {
                         "description": "Domínio não encontrado",
                         "schema": {
                             "$ref": "#/definitions/models.APIError"
                         }
                     },
+                    "413": {
+                        "description": "Erro não cabe na janela de contexto do modelo",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "429": {
+                        "description": "Limite de requisições ou cota diária excedidos",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
                     "500": {
                         "description": "Erro interno do servidor",
                         "schema": {
                             "$ref": "#/definitions/models.APIError"
                         }
+                    },
+                    "503": {
+                        "description": "Modelo indisponível; tente novamente após Retry-After",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "models.APIError": {
-            "description": "Estrutura de erro padrão retornada pela API",
-            "type": "object",
-            "required": [
-                "code",
-                "message"
-            ],
-            "properties": {
-                "code": {
-                    "type": "integer",
-                    "example": 400
-                },
-                "details": {
-                    "type": "string",
-                    "example": "O campo error_details é obrigatório"
-                },
-                "message": {
-                    "type": "string",
-                    "example": "Parâmetros inválidos"
-                }
-            }
         },
-        "models.ErrorRequest": {
-            "description": "Requisição contendo os detalhes do erro a ser analisado",
-            "type": "object",
-            "required": [
-                "error_details"
-            ],
-            "properties": {
-                "context": {
-                    "type": "string",
-                    "example": "Deployment em cluster Kubernetes 1.26 com imagem Docker personalizada"
-                },
-                "error_details": {
-                    "type": "string",
-                    "example": "CrashLoopBackOff: container failed to start"
+        "/errors/{domain}/stream": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Igual a POST /errors/{domain}, mas responde em text/event-stream: um evento status ao iniciar, comentários de keep-alive enquanto o modelo responde e, ao final, um evento result com o ErrorResponse ou error com o APIError. Erros de validação, autenticação e limite são respondidos antes do stream, como JSON.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "errors"
+                ],
+                "summary": "Analisar erros com progresso em Server-Sent Events",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tenant cujas configurações e dicionários serão usados",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "enum": [
+                            "kubernetes",
+                            "github",
+                            "argocd"
+                        ],
+                        "type": "string",
+                        "description": "Domínio técnico (kubernetes, github, argocd)",
+                        "name": "domain",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Detalhes do erro e contexto",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Stream com os eventos status e result (ou error)",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Erro de validação ou requisição inválida",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Credenciais ausentes ou inválidas",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "Escopo analyze:{domain} ausente",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "404": {
+                        "description": "Domínio não encontrado",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "429": {
+                        "description": "Limite de requisições ou cota diária excedidos",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
                 }
             }
         },
-        "models.ErrorResponse": {
-            "description": "Resposta contendo análise e solução para o erro reportado",
-            "type": "object",
-            "required": [
-                "error"
-            ],
-            "properties": {
-                "error": {
-                    "$ref": "#/definitions/models.ErrorSolution"
-                },
-                "message": {
-                    "type": "string",
-                    "example": "Análise concluída com sucesso"
+        "/health": {
+            "get": {
+                "description": "Verifica se o serviço está em funcionamento",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "system"
+                ],
+                "summary": "Verificar saúde do serviço",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
                 }
             }
         },
-        "models.ErrorSolution": {
-            "description": "Estrutura contendo a causa identificada e soluções propostas para o erro",
-            "type": "object",
-            "required": [
-                "causa",
-                "solucao"
-            ],
-            "properties": {
-                "causa": {
-                    "type": "string",
-                    "example": "Imagem Docker inválida"
-                },
-                "solucao": {
-                    "type": "string",
-                    "example": "kubectl describe pod meu-pod\nkubectl logs meu-pod --previous"
+        "/integrations/alertmanager": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Recebe o webhook do Prometheus Alertmanager e enfileira a análise de cada alerta disparado; a descrição vira error_details e os labels, o contexto. A resposta sai antes da análise, para não estourar o timeout do Alertmanager (10s por padrão) e provocar reenvios; o diagnóstico segue para os destinos de notify. Alertas resolvidos são ignorados.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "integrations"
+                ],
+                "summary": "Receber alertas do Alertmanager",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tenant cujas configurações e dicionários serão usados",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Payload do webhook do Alertmanager",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.AlertmanagerWebhook"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Nenhum alerta enfileirado",
+                        "schema": {
+                            "$ref": "#/definitions/models.IntegrationResult"
+                        }
+                    },
+                    "202": {
+                        "description": "Alertas enfileirados para análise",
+                        "schema": {
+                            "$ref": "#/definitions/models.IntegrationResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Payload inválido",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Credenciais ausentes ou inválidas",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "Escopo integrations:alertmanager ausente",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "429": {
+                        "description": "Limite de requisições ou cota diária excedidos",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/integrations/argocd": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Recebe o webhook do notifications-controller (triggers on-sync-failed e on-health-degraded) com a Application serializada e analisa as mensagens da operação, das condições e dos recursos degradados",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "integrations"
+                ],
+                "summary": "Receber notificações do Argo CD",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tenant cujas configurações e dicionários serão usados",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Notificação do Argo CD",
+                        "name": "notification",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ArgoCDNotification"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.IntegrationResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Payload inválido",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Credenciais ausentes ou inválidas",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "Escopo integrations:argocd ausente",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "429": {
+                        "description": "Limite de requisições ou cota diária excedidos",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/integrations/github": {
+            "post": {
+                "description": "Verifica a assinatura X-Hub-Signature-256 e, para execuções ou jobs concluídos com falha, baixa os logs dos jobs e os analisa em segundo plano, comentando o diagnóstico no commit ou pull request quando configurado. Dispensa API key.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "integrations"
+                ],
+                "summary": "Receber webhooks do GitHub Actions",
+                "parameters": [
+                    {
+                        "enum": [
+                            "workflow_run",
+                            "workflow_job",
+                            "ping"
+                        ],
+                        "type": "string",
+                        "description": "Tipo do evento",
+                        "name": "X-GitHub-Event",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Assinatura HMAC-SHA256 do corpo",
+                        "name": "X-Hub-Signature-256",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Payload do webhook",
+                        "name": "event",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.GitHubWorkflowEvent"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Evento ignorado",
+                        "schema": {
+                            "$ref": "#/definitions/models.IntegrationResult"
+                        }
+                    },
+                    "202": {
+                        "description": "Falha enfileirada para análise",
+                        "schema": {
+                            "$ref": "#/definitions/models.IntegrationResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Payload inválido",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Assinatura inválida",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "429": {
+                        "description": "Limite de requisições ou cota diária excedidos",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "503": {
+                        "description": "Integração não configurada",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/integrations/zabbix": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Recebe o payload do script do media type webhook do Zabbix e enfileira a análise do problema; o domínio vem das tags ou dos grupos de hosts. A resposta sai antes da análise, para caber no timeout do media type. Com acknowledge habilitado, o diagnóstico é registrado como comentário do problema. Recuperações e atualizações são ignoradas.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "integrations"
+                ],
+                "summary": "Receber eventos do Zabbix",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tenant cujas configurações e dicionários serão usados",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Evento do Zabbix",
+                        "name": "event",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ZabbixEvent"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Evento ignorado",
+                        "schema": {
+                            "$ref": "#/definitions/models.IntegrationResult"
+                        }
+                    },
+                    "202": {
+                        "description": "Problema enfileirado para análise",
+                        "schema": {
+                            "$ref": "#/definitions/models.IntegrationResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Payload inválido",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "401": {
+                        "description": "Credenciais ausentes ou inválidas",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "403": {
+                        "description": "Escopo integrations:zabbix ausente",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    },
+                    "429": {
+                        "description": "Limite de requisições ou cota diária excedidos",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        },
+        "/quota": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retorna, para o cliente autenticado (ou IP de origem), as chamadas ao LLM feitas hoje em cada domínio",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "system"
+                ],
+                "summary": "Consultar uso da cota",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.QuotaUsage"
+                        }
+                    },
+                    "401": {
+                        "description": "Credenciais ausentes ou inválidas",
+                        "schema": {
+                            "$ref": "#/definitions/models.APIError"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "models.APIError": {
+            "description": "Estrutura de erro padrão retornada pela API",
+            "type": "object",
+            "required": [
+                "code",
+                "message"
+            ],
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 400
+                },
+                "details": {
+                    "type": "string",
+                    "example": "O campo error_details é obrigatório"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Parâmetros inválidos"
+                }
+            }
+        },
+        "models.ActionResult": {
+            "description": "Ação do dicionário planejada ou disparada; status segue o Rundeck (running, succeeded, failed...) ou vale dry_run, pending_approval, approved, disabled, delivering, delivered ou error",
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DeliveryAttempt"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                },
+                "execution_id": {
+                    "type": "integer",
+                    "example": 1234
+                },
+                "id": {
+                    "type": "string",
+                    "example": "5f2b8c1e9a7d4e3f"
+                },
+                "job_id": {
+                    "type": "string",
+                    "example": "a1b2c3d4-0000-0000-0000-000000000000"
+                },
+                "options": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "pattern": {
+                    "type": "string",
+                    "example": "image_pull_backoff"
+                },
+                "permalink": {
+                    "type": "string",
+                    "example": "https://rundeck.example.com/project/ops/execution/show/1234"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "pending_approval"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "rundeck"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://awx.example.com/api/v2/job_templates/42/launch/"
+                }
+            }
+        },
+        "models.Alert": {
+            "type": "object",
+            "properties": {
+                "annotations": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "endsAt": {
+                    "type": "string"
+                },
+                "fingerprint": {
+                    "type": "string"
+                },
+                "generatorURL": {
+                    "type": "string"
+                },
+                "labels": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "startsAt": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "firing",
+                        "resolved"
+                    ],
+                    "example": "firing"
+                }
+            }
+        },
+        "models.AlertmanagerWebhook": {
+            "description": "Payload do webhook do Prometheus Alertmanager (version 4)",
+            "type": "object",
+            "properties": {
+                "alerts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Alert"
+                    }
+                },
+                "commonAnnotations": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "commonLabels": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "externalURL": {
+                    "type": "string"
+                },
+                "groupKey": {
+                    "type": "string"
+                },
+                "groupLabels": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "receiver": {
+                    "type": "string",
+                    "example": "hefestus"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "firing",
+                        "resolved"
+                    ],
+                    "example": "firing"
+                },
+                "version": {
+                    "type": "string",
+                    "example": "4"
+                }
+            }
+        },
+        "models.ArgoCDApplication": {
+            "type": "object",
+            "properties": {
+                "metadata": {
+                    "type": "object",
+                    "properties": {
+                        "name": {
+                            "type": "string",
+                            "example": "guestbook"
+                        },
+                        "namespace": {
+                            "type": "string",
+                            "example": "argocd"
+                        }
+                    }
+                },
+                "spec": {
+                    "type": "object",
+                    "properties": {
+                        "destination": {
+                            "type": "object",
+                            "properties": {
+                                "namespace": {
+                                    "type": "string"
+                                },
+                                "server": {
+                                    "type": "string"
+                                }
+                            }
+                        },
+                        "project": {
+                            "type": "string",
+                            "example": "default"
+                        },
+                        "source": {
+                            "type": "object",
+                            "properties": {
+                                "path": {
+                                    "type": "string"
+                                },
+                                "repoURL": {
+                                    "type": "string"
+                                },
+                                "targetRevision": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                },
+                "status": {
+                    "$ref": "#/definitions/models.ArgoCDApplicationStatus"
+                }
+            }
+        },
+        "models.ArgoCDApplicationStatus": {
+            "type": "object",
+            "properties": {
+                "conditions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ArgoCDCondition"
+                    }
+                },
+                "health": {
+                    "$ref": "#/definitions/models.ArgoCDHealth"
+                },
+                "operationState": {
+                    "$ref": "#/definitions/models.ArgoCDOperationState"
+                },
+                "resources": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ArgoCDResourceStatus"
+                    }
+                },
+                "sync": {
+                    "type": "object",
+                    "properties": {
+                        "revision": {
+                            "type": "string"
+                        },
+                        "status": {
+                            "type": "string",
+                            "example": "OutOfSync"
+                        }
+                    }
+                }
+            }
+        },
+        "models.ArgoCDCondition": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "ComparisonError"
+                }
+            }
+        },
+        "models.ArgoCDHealth": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "Degraded"
+                }
+            }
+        },
+        "models.ArgoCDNotification": {
+            "description": "Notificação do Argo CD com a Application serializada",
+            "type": "object",
+            "properties": {
+                "app": {
+                    "$ref": "#/definitions/models.ArgoCDApplication"
+                },
+                "trigger": {
+                    "type": "string",
+                    "example": "on-sync-failed"
+                }
+            }
+        },
+        "models.ArgoCDOperationState": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "phase": {
+                    "type": "string",
+                    "example": "Failed"
+                },
+                "syncResult": {
+                    "type": "object",
+                    "properties": {
+                        "resources": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ArgoCDSyncResult"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "models.ArgoCDResourceStatus": {
+            "type": "object",
+            "properties": {
+                "health": {
+                    "$ref": "#/definitions/models.ArgoCDHealth"
+                },
+                "kind": {
+                    "type": "string",
+                    "example": "Deployment"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "namespace": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "Synced"
+                }
+            }
+        },
+        "models.ArgoCDSyncResult": {
+            "type": "object",
+            "properties": {
+                "hookPhase": {
+                    "type": "string"
+                },
+                "kind": {
+                    "type": "string",
+                    "example": "Deployment"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "namespace": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "SyncFailed"
+                }
+            }
+        },
+        "models.DeliveryAttempt": {
+            "description": "Tentativa de entrega de um webhook, com o status HTTP ou o erro de rede",
+            "type": "object",
+            "properties": {
+                "attempt": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "duration_ms": {
+                    "type": "integer",
+                    "example": 120
+                },
+                "error": {
+                    "type": "string"
+                },
+                "status_code": {
+                    "type": "integer",
+                    "example": 503
+                },
+                "time": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DomainQuota": {
+            "description": "Consumo da cota diária de um domínio; limit 0 indica sem limite",
+            "type": "object",
+            "properties": {
+                "domain": {
+                    "type": "string",
+                    "example": "kubernetes"
+                },
+                "limit": {
+                    "type": "integer",
+                    "example": 500
+                },
+                "remaining": {
+                    "type": "integer",
+                    "example": 458
+                },
+                "reset_seconds": {
+                    "type": "integer",
+                    "example": 3600
+                },
+                "used": {
+                    "type": "integer",
+                    "example": 42
+                }
+            }
+        },
+        "models.EnrichmentReport": {
+            "description": "Objetos consultados no enriquecimento de contexto; error indica falha parcial, sem impedir a análise",
+            "type": "object",
+            "properties": {
+                "bytes": {
+                    "type": "integer",
+                    "example": 4096
+                },
+                "error": {
+                    "type": "string"
+                },
+                "objects": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "Deployment/shop/api",
+                        "Pod/shop/api-7d9f8c6b5-x2x7q"
+                    ]
+                },
+                "source": {
+                    "type": "string",
+                    "example": "kubernetes"
+                },
+                "truncated": {
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "models.ErrorRequest": {
+            "description": "Requisição contendo os detalhes do erro a ser analisado",
+            "type": "object",
+            "required": [
+                "error_details"
+            ],
+            "properties": {
+                "action_mode": {
+                    "type": "string",
+                    "enum": [
+                        "none",
+                        "dry_run",
+                        "execute"
+                    ],
+                    "example": "dry_run"
+                },
+                "context": {
+                    "type": "string",
+                    "example": "Deployment em cluster Kubernetes 1.26 com imagem Docker personalizada"
+                },
+                "error_details": {
+                    "type": "string",
+                    "example": "CrashLoopBackOff: container failed to start"
+                },
+                "kubernetes": {
+                    "$ref": "#/definitions/models.KubernetesRef"
+                },
+                "notify": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.NotificationSink"
+                    }
+                }
+            }
+        },
+        "models.ErrorResponse": {
+            "description": "Resposta contendo análise e solução para o erro reportado",
+            "type": "object",
+            "required": [
+                "error"
+            ],
+            "properties": {
+                "actions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ActionResult"
+                    }
+                },
+                "enrichment": {
+                    "$ref": "#/definitions/models.EnrichmentReport"
+                },
+                "error": {
+                    "$ref": "#/definitions/models.ErrorSolution"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Análise concluída com sucesso"
+                },
+                "preprocessing": {
+                    "$ref": "#/definitions/models.PreprocessReport"
+                }
+            }
+        },
+        "models.ErrorSolution": {
+            "description": "Estrutura contendo a causa identificada e soluções propostas para o erro",
+            "type": "object",
+            "required": [
+                "causa",
+                "solucao"
+            ],
+            "properties": {
+                "causa": {
+                    "type": "string",
+                    "example": "Imagem Docker inválida"
+                },
+                "patterns": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "image_pull_backoff"
+                    ]
+                },
+                "references": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "https://kubernetes.io/docs/tasks/debug/debug-application/debug-pods/"
+                    ]
+                },
+                "solucao": {
+                    "type": "string",
+                    "example": "kubectl describe pod meu-pod\nkubectl logs meu-pod --previous"
+                }
+            }
+        },
+        "models.GitHubPullRequest": {
+            "type": "object",
+            "properties": {
+                "number": {
+                    "type": "integer",
+                    "example": 42
+                }
+            }
+        },
+        "models.GitHubRepository": {
+            "type": "object",
+            "properties": {
+                "full_name": {
+                    "type": "string",
+                    "example": "octo-org/octo-repo"
+                }
+            }
+        },
+        "models.GitHubStep": {
+            "type": "object",
+            "properties": {
+                "conclusion": {
+                    "type": "string",
+                    "example": "failure"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Run tests"
+                },
+                "number": {
+                    "type": "integer",
+                    "example": 4
+                }
+            }
+        },
+        "models.GitHubWorkflowEvent": {
+            "description": "Payload dos webhooks workflow_run e workflow_job do GitHub; o tipo vem do header X-GitHub-Event",
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "completed"
+                },
+                "repository": {
+                    "$ref": "#/definitions/models.GitHubRepository"
+                },
+                "workflow_job": {
+                    "$ref": "#/definitions/models.GitHubWorkflowJob"
+                },
+                "workflow_run": {
+                    "$ref": "#/definitions/models.GitHubWorkflowRun"
+                }
+            }
+        },
+        "models.GitHubWorkflowJob": {
+            "type": "object",
+            "properties": {
+                "conclusion": {
+                    "type": "string",
+                    "example": "failure"
+                },
+                "head_branch": {
+                    "type": "string",
+                    "example": "main"
+                },
+                "head_sha": {
+                    "type": "string"
+                },
+                "html_url": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 399444496
+                },
+                "name": {
+                    "type": "string",
+                    "example": "build"
+                },
+                "run_id": {
+                    "type": "integer",
+                    "example": 30433642
+                },
+                "steps": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.GitHubStep"
+                    }
+                },
+                "workflow_name": {
+                    "type": "string",
+                    "example": "CI"
+                }
+            }
+        },
+        "models.GitHubWorkflowRun": {
+            "type": "object",
+            "properties": {
+                "conclusion": {
+                    "type": "string",
+                    "example": "failure"
+                },
+                "head_branch": {
+                    "type": "string",
+                    "example": "main"
+                },
+                "head_sha": {
+                    "type": "string"
+                },
+                "html_url": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 30433642
+                },
+                "name": {
+                    "type": "string",
+                    "example": "CI"
+                },
+                "pull_requests": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.GitHubPullRequest"
+                    }
+                }
+            }
+        },
+        "models.IntegrationItem": {
+            "description": "Item analisado, ignorado (com o motivo), que falhou ou enfileirado para análise em segundo plano",
+            "type": "object",
+            "properties": {
+                "domain": {
+                    "type": "string",
+                    "example": "kubernetes"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "3b5f0f0c2a6b7e4d"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "KubePodCrashLooping"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "alerta resolvido"
+                },
+                "response": {
+                    "$ref": "#/definitions/models.ErrorResponse"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "analyzed",
+                        "ignored",
+                        "failed",
+                        "queued"
+                    ],
+                    "example": "analyzed"
+                }
+            }
+        },
+        "models.IntegrationResult": {
+            "description": "Resultado da análise de cada item (alerta, trigger, workflow...) de um evento de integração",
+            "type": "object",
+            "properties": {
+                "analyzed": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "failed": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "ignored": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.IntegrationItem"
+                    }
+                },
+                "queued": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "received": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "models.KubernetesRef": {
+            "description": "Recurso cujos detalhes, eventos e logs são anexados ao contexto quando o domínio tem kubernetes_enrichment; informe pod ou deployment",
+            "type": "object",
+            "properties": {
+                "container": {
+                    "type": "string",
+                    "example": "app"
+                },
+                "deployment": {
+                    "type": "string",
+                    "example": "api"
+                },
+                "namespace": {
+                    "type": "string",
+                    "example": "shop"
+                },
+                "pod": {
+                    "type": "string",
+                    "example": "api-7d9f8c6b5-x2x7q"
+                }
+            }
+        },
+        "models.NotificationSink": {
+            "description": "Webhook do Slack ou Microsoft Teams; template é um text/template opcional para o texto da mensagem",
+            "type": "object",
+            "properties": {
+                "template": {
+                    "type": "string",
+                    "example": "{{.Domain}}: {{.Causa}}"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "slack",
+                        "teams"
+                    ],
+                    "example": "slack"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://hooks.slack.com/services/T000/B000/XXXX"
+                }
+            }
+        },
+        "models.PreprocessReport": {
+            "description": "Resumo do pré-processamento aplicado ao log recebido",
+            "type": "object",
+            "properties": {
+                "ansi_codes_removed": {
+                    "type": "integer",
+                    "example": 812
+                },
+                "duplicate_lines_collapsed": {
+                    "type": "integer",
+                    "example": 97
+                },
+                "error_regions": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "lines_dropped": {
+                    "type": "integer",
+                    "example": 5086
+                },
+                "original_lines": {
+                    "type": "integer",
+                    "example": 5231
+                },
+                "original_tokens": {
+                    "type": "integer",
+                    "example": 180344
+                },
+                "result_lines": {
+                    "type": "integer",
+                    "example": 48
+                },
+                "result_tokens": {
+                    "type": "integer",
+                    "example": 1910
+                },
+                "timestamps_removed": {
+                    "type": "integer",
+                    "example": 5231
+                },
+                "token_budget": {
+                    "type": "integer",
+                    "example": 2048
+                },
+                "truncated": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "models.QuotaUsage": {
+            "description": "Uso da cota diária de chamadas ao LLM por domínio",
+            "type": "object",
+            "properties": {
+                "client": {
+                    "type": "string",
+                    "example": "key:zabbix"
+                },
+                "domains": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DomainQuota"
+                    }
+                }
+            }
+        },
+        "models.ZabbixEvent": {
+            "description": "Evento do Zabbix; os campos correspondem às macros {EVENT.*}, {HOST.NAME}, {TRIGGER.*} e {ITEM.*}. host_groups e tags são listas separadas por vírgula, como nas macros {TRIGGER.HOSTGROUP.NAME} e {EVENT.TAGS}.",
+            "type": "object",
+            "required": [
+                "event_id"
+            ],
+            "properties": {
+                "event_id": {
+                    "type": "string",
+                    "example": "4211"
+                },
+                "event_name": {
+                    "type": "string",
+                    "example": "Pod api-7f in CrashLoopBackOff"
+                },
+                "event_update_status": {
+                    "type": "string",
+                    "enum": [
+                        "0",
+                        "1"
+                    ],
+                    "example": "0"
+                },
+                "event_value": {
+                    "type": "string",
+                    "enum": [
+                        "0",
+                        "1"
+                    ],
+                    "example": "1"
+                },
+                "host": {
+                    "type": "string",
+                    "example": "k8s-node-01"
+                },
+                "host_groups": {
+                    "type": "string",
+                    "example": "Kubernetes nodes,Linux servers"
+                },
+                "item_name": {
+                    "type": "string",
+                    "example": "Pod api-7f status"
+                },
+                "item_value": {
+                    "type": "string",
+                    "example": "Back-off restarting failed container"
+                },
+                "severity": {
+                    "type": "string",
+                    "example": "High"
+                },
+                "tags": {
+                    "type": "string",
+                    "example": "service:api,hefestus_domain:kubernetes"
+                },
+                "trigger_description": {
+                    "type": "string"
+                },
+                "trigger_name": {
+                    "type": "string",
+                    "example": "Pod is crash looping"
                 }
             }
         }
     },
-    "tags": [
-        {
-            "description": "Error resolution endpoints",
-            "name": "errors"
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
         }
-    ]
+    }
 }`
 
 // SwaggerInfo holds exported Swagger Info so clients can modify it
@@ -170,7 +1608,7 @@ var SwaggerInfo = &swag.Spec{
 	BasePath:         "/api",
 	Schemes:          []string{"http"},
 	Title:            "Hefestus API",
-	Description:      "Error resolution API using local LLM",
+	Description:      "API para resolução de erros técnicos utilizando LLMs locais",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
 	LeftDelim:        "{{",
@@ -0,0 +1,451 @@
+// Package actions dispara as ações de autocorreção associadas aos padrões do
+// dicionário e aos domínios (jobs do Rundeck e webhooks genéricos), com modo de
+// simulação e aprovação manual.
+package actions
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"hefestus-api/internal/logging"
+	"hefestus-api/internal/metrics"
+	"hefestus-api/internal/models"
+	"hefestus-api/internal/tenant"
+	"hefestus-api/pkg/rundeck"
+)
+
+const (
+	// TypeRundeck dispara um job do Rundeck
+	TypeRundeck = "rundeck"
+	// TypeWebhook envia uma requisição HTTP com corpo renderizado por template
+	TypeWebhook = "webhook"
+
+	// ModeNone ignora as ações (padrão)
+	ModeNone = "none"
+	// ModeDryRun retorna as ações que seriam disparadas, sem executá-las
+	ModeDryRun = "dry_run"
+	// ModeExecute dispara as ações, exceto as que exigem aprovação
+	ModeExecute = "execute"
+
+	// Estados próprios do Hefestus; os demais vêm do Rundeck (running, succeeded...)
+	StatusDryRun          = "dry_run"
+	StatusPendingApproval = "pending_approval"
+	StatusApproved        = "approved"
+	StatusDisabled        = "disabled"
+	StatusDelivering      = "delivering"
+	StatusDelivered       = "delivered"
+	StatusError           = "error"
+
+	// resultTTL é por quanto tempo ações pendentes e executadas podem ser consultadas
+	resultTTL = 24 * time.Hour
+)
+
+var (
+	// ErrInvalidMode indica um action_mode desconhecido
+	ErrInvalidMode = errors.New("invalid action mode")
+	// ErrExecutionNotAllowed indica um cliente sem permissão para disparar ações
+	ErrExecutionNotAllowed = errors.New("action execution not allowed")
+	// ErrNotFound indica uma ação inexistente, expirada ou de outro tenant
+	ErrNotFound = errors.New("action not found")
+	// ErrNotPending indica uma aprovação de ação que não aguarda aprovação
+	ErrNotPending = errors.New("action is not pending approval")
+)
+
+type executeKey struct{}
+
+// AllowExecution marca o context como autorizado a disparar ações
+func AllowExecution(ctx context.Context) context.Context {
+	return context.WithValue(ctx, executeKey{}, true)
+}
+
+// CanExecute informa se o context foi autorizado a disparar ações
+func CanExecute(ctx context.Context) bool {
+	allowed, _ := ctx.Value(executeKey{}).(bool)
+	return allowed
+}
+
+// ValidateMode confere o action_mode da requisição e a permissão de execução
+func ValidateMode(ctx context.Context, mode string) error {
+	switch mode {
+	case "", ModeNone, ModeDryRun:
+		return nil
+	case ModeExecute:
+		if !CanExecute(ctx) {
+			return ErrExecutionNotAllowed
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidMode, mode)
+	}
+}
+
+// ValidateAction verifica tipo, destino e templates de uma ação
+func ValidateAction(action models.ActionConfig) error {
+	switch action.Type {
+	case TypeRundeck:
+		if action.JobID == "" {
+			return errors.New("action job_id cannot be empty")
+		}
+	case TypeWebhook:
+		if err := validateWebhook(action); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported action type: %q", action.Type)
+	}
+
+	for name, value := range action.Options {
+		if _, err := parseTemplate(name, value); err != nil {
+			return fmt.Errorf("invalid template for option %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Analysis reúne o resultado da análise usado para planejar as ações
+type Analysis struct {
+	Domain string
+	// ErrorDetails é o log pré-processado, no qual os padrões casaram
+	ErrorDetails string
+	// OriginalError é o error_details recebido, já com segredos mascarados
+	OriginalError string
+	Context       string
+	Solution      *models.ErrorSolution
+}
+
+// Input reúne os dados disponíveis para os templates das ações
+type Input struct {
+	Domain       string
+	Tenant       string
+	Pattern      string
+	Category     string
+	Match        string
+	Groups       map[string]string
+	Causa        string
+	Steps        []string
+	References   []string
+	ErrorDetails string
+	Context      string
+}
+
+// Runner planeja, dispara e acompanha as ações
+type Runner struct {
+	client      *rundeck.Client
+	httpClient  *http.Client
+	maxAttempts int
+	backoff     time.Duration
+	mu          sync.Mutex
+	results     map[string]*entry
+}
+
+type entry struct {
+	result    models.ActionResult
+	webhook   *webhookRequest
+	tenant    string
+	expiresAt time.Time
+}
+
+// NewRunner cria o executor de ações
+func NewRunner(client *rundeck.Client) *Runner {
+	return &Runner{
+		client:      client,
+		httpClient:  &http.Client{Timeout: 15 * time.Second},
+		maxAttempts: 3,
+		backoff:     time.Second,
+		results:     make(map[string]*entry),
+	}
+}
+
+// Run processa, conforme o modo da requisição, as ações dos padrões
+// encontrados e as ações do domínio
+func (r *Runner) Run(ctx context.Context, mode string, analysis Analysis, patterns []models.ErrorPattern, domainActions []models.ActionConfig) []models.ActionResult {
+	if mode == "" || mode == ModeNone {
+		return nil
+	}
+
+	base := Input{
+		Domain:       analysis.Domain,
+		Tenant:       tenant.FromContext(ctx),
+		Causa:        analysis.Solution.Causa,
+		Steps:        strings.Split(analysis.Solution.Solucao, "\n"),
+		References:   analysis.Solution.References,
+		ErrorDetails: analysis.OriginalError,
+		Context:      analysis.Context,
+	}
+
+	var results []models.ActionResult
+	for _, pattern := range patterns {
+		input := base
+		input.Pattern = pattern.Name
+		input.Category = pattern.Category
+		input.Match, input.Groups = match(pattern.Pattern, analysis.ErrorDetails)
+
+		for _, action := range pattern.Actions {
+			results = append(results, r.plan(ctx, mode, action, input))
+		}
+	}
+	for _, action := range domainActions {
+		results = append(results, r.plan(ctx, mode, action, base))
+	}
+
+	return results
+}
+
+// plan renderiza a ação e decide se ela é simulada, aguarda aprovação ou é
+// disparada imediatamente
+func (r *Runner) plan(ctx context.Context, mode string, action models.ActionConfig, input Input) models.ActionResult {
+	result := models.ActionResult{
+		ID:      newID(),
+		Pattern: input.Pattern,
+		Type:    action.Type,
+		JobID:   action.JobID,
+		URL:     action.URL,
+	}
+
+	var hook *webhookRequest
+	var err error
+	switch action.Type {
+	case TypeWebhook:
+		hook, err = renderWebhook(action, input)
+	default:
+		result.Options, err = renderOptions(action, input)
+	}
+
+	dispatch := false
+	switch {
+	case err != nil:
+		result.Status = StatusError
+		result.Error = err.Error()
+	case mode == ModeDryRun:
+		result.Status = StatusDryRun
+	case action.Type == TypeRundeck && !r.client.Enabled():
+		result.Status = StatusDisabled
+	case action.RequireApproval:
+		result.Status = StatusPendingApproval
+	default:
+		dispatch = true
+	}
+
+	if result.Status == StatusDryRun {
+		metrics.Actions.WithLabelValues(action.Type, result.Status).Inc()
+		return result
+	}
+
+	e := r.store(ctx, result, hook)
+	if dispatch {
+		return r.dispatch(ctx, e)
+	}
+	metrics.Actions.WithLabelValues(action.Type, result.Status).Inc()
+	return result
+}
+
+// Get retorna uma ação do tenant da requisição, atualizando o estado da
+// execução no Rundeck
+func (r *Runner) Get(ctx context.Context, id string) (models.ActionResult, error) {
+	e, err := r.lookup(ctx, id)
+	if err != nil {
+		return models.ActionResult{}, err
+	}
+
+	result := r.snapshot(e)
+	if result.ExecutionID == 0 {
+		return result, nil
+	}
+
+	execution, err := r.client.GetExecution(ctx, result.ExecutionID)
+	if err != nil {
+		logging.FromContext(ctx).Warn("falha ao consultar execução no Rundeck", "action", id, "error", err)
+		return result, nil
+	}
+
+	r.mu.Lock()
+	e.result.Status = execution.Status
+	r.mu.Unlock()
+	return r.snapshot(e), nil
+}
+
+// Approve dispara uma ação que aguardava aprovação
+func (r *Runner) Approve(ctx context.Context, id string) (models.ActionResult, error) {
+	e, err := r.lookup(ctx, id)
+	if err != nil {
+		return models.ActionResult{}, err
+	}
+
+	r.mu.Lock()
+	if e.result.Status != StatusPendingApproval {
+		status := e.result.Status
+		r.mu.Unlock()
+		return models.ActionResult{}, fmt.Errorf("%w: status %s", ErrNotPending, status)
+	}
+	// Marca antes de liberar o lock para que aprovações concorrentes não disparem a ação duas vezes
+	e.result.Status = StatusApproved
+	r.mu.Unlock()
+
+	logging.FromContext(ctx).Info("ação aprovada", "action", id, "type", e.result.Type)
+	return r.dispatch(ctx, e), nil
+}
+
+// dispatch executa uma ação armazenada: jobs do Rundeck são disparados na
+// hora e webhooks são entregues em segundo plano
+func (r *Runner) dispatch(ctx context.Context, e *entry) models.ActionResult {
+	if e.webhook != nil {
+		r.mu.Lock()
+		e.result.Status = StatusDelivering
+		r.mu.Unlock()
+
+		go r.deliver(context.WithoutCancel(ctx), e)
+		return r.snapshot(e)
+	}
+
+	result := r.snapshot(e)
+	execution, err := r.client.RunJob(ctx, result.JobID, result.Options)
+
+	r.mu.Lock()
+	if err != nil {
+		logging.FromContext(ctx).Error("falha ao disparar job do Rundeck", "job_id", result.JobID, "error", err)
+		e.result.Status = StatusError
+		e.result.Error = err.Error()
+	} else {
+		logging.FromContext(ctx).Info("job do Rundeck disparado",
+			"job_id", result.JobID, "execution_id", execution.ID, "pattern", result.Pattern)
+		e.result.Status = execution.Status
+		e.result.ExecutionID = execution.ID
+		e.result.Permalink = execution.Permalink
+	}
+	r.mu.Unlock()
+
+	result = r.snapshot(e)
+	metrics.Actions.WithLabelValues(result.Type, result.Status).Inc()
+	return result
+}
+
+func (r *Runner) store(ctx context.Context, result models.ActionResult, hook *webhookRequest) *entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for id, e := range r.results {
+		if now.After(e.expiresAt) {
+			delete(r.results, id)
+		}
+	}
+
+	e := &entry{
+		result:    result,
+		webhook:   hook,
+		tenant:    tenant.FromContext(ctx),
+		expiresAt: now.Add(resultTTL),
+	}
+	r.results[result.ID] = e
+	return e
+}
+
+func (r *Runner) lookup(ctx context.Context, id string) (*entry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, ok := r.results[id]
+	if !ok || time.Now().After(e.expiresAt) || e.tenant != tenant.FromContext(ctx) {
+		return nil, ErrNotFound
+	}
+	return e, nil
+}
+
+// snapshot copia o resultado de uma ação para ser devolvido fora do lock
+func (r *Runner) snapshot(e *entry) models.ActionResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := e.result
+	result.Attempts = append([]models.DeliveryAttempt(nil), e.result.Attempts...)
+	return result
+}
+
+// renderOptions aplica os templates das opções de uma ação
+func renderOptions(action models.ActionConfig, input Input) (map[string]string, error) {
+	if len(action.Options) == 0 {
+		return nil, nil
+	}
+
+	names := make([]string, 0, len(action.Options))
+	for name := range action.Options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	options := make(map[string]string, len(names))
+	for _, name := range names {
+		value, err := render(name, action.Options[name], input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render option %s: %w", name, err)
+		}
+		options[name] = value
+	}
+	return options, nil
+}
+
+// templateFuncs permite montar corpos JSON com valores escapados, como {{json .Causa}}
+var templateFuncs = template.FuncMap{
+	"json": func(value interface{}) (string, error) {
+		data, err := json.Marshal(value)
+		return string(data), err
+	},
+}
+
+func parseTemplate(name string, source string) (*template.Template, error) {
+	return template.New(name).Funcs(templateFuncs).Option("missingkey=zero").Parse(source)
+}
+
+func render(name string, source string, input Input) (string, error) {
+	tmpl, err := parseTemplate(name, source)
+	if err != nil {
+		return "", err
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, input); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// match retorna o primeiro trecho casado e os grupos nomeados do padrão
+func match(pattern string, text string) (string, map[string]string) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", nil
+	}
+
+	submatches := re.FindStringSubmatch(text)
+	if submatches == nil {
+		return "", nil
+	}
+
+	groups := make(map[string]string)
+	for i, name := range re.SubexpNames() {
+		if name != "" && i < len(submatches) {
+			groups[name] = submatches[i]
+		}
+	}
+	return submatches[0], groups
+}
+
+func newID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
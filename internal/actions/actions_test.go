@@ -0,0 +1,368 @@
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"hefestus-api/internal/models"
+	"hefestus-api/internal/tenant"
+	"hefestus-api/pkg/rundeck"
+)
+
+var imagePullPattern = models.ErrorPattern{
+	Name:     "image_pull_backoff",
+	Pattern:  `Failed to pull image "(?P<image>[^"]+)"`,
+	Category: "IMAGE",
+}
+
+var analysis = Analysis{
+	Domain:        "kubernetes",
+	ErrorDetails:  `Warning Failed: Failed to pull image "registry.example.com/api:1.2"`,
+	OriginalError: `Warning Failed: Failed to pull image "registry.example.com/api:1.2"`,
+	Context:       "Deployment api",
+	Solution:      &models.ErrorSolution{Causa: "Imagem inexistente", Solucao: "verificar a tag\nkubectl describe pod api"},
+}
+
+// rundeckStub simula o Rundeck, guardando as opções de cada job disparado
+type rundeckStub struct {
+	mu   sync.Mutex
+	runs []map[string]string
+}
+
+func (s *rundeckStub) calls() []map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]map[string]string(nil), s.runs...)
+}
+
+// newTestRunner aponta o Runner para um Rundeck falso
+func newTestRunner(t *testing.T) (*Runner, *rundeckStub) {
+	t.Helper()
+	stub := &rundeckStub{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/41/job/restart-api/run":
+			var body struct {
+				Options map[string]string `json:"options"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			stub.mu.Lock()
+			stub.runs = append(stub.runs, body.Options)
+			stub.mu.Unlock()
+			_, _ = io.WriteString(w, `{"id": 1234, "status": "running", "permalink": "https://rundeck.example.com/execution/show/1234"}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/api/41/execution/1234":
+			_, _ = io.WriteString(w, `{"id": 1234, "status": "succeeded"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("RUNDECK_URL", server.URL)
+	t.Setenv("RUNDECK_TOKEN", "token")
+	t.Setenv("RUNDECK_API_VERSION", "")
+
+	runner := NewRunner(rundeck.NewClient())
+	runner.backoff = time.Millisecond
+	return runner, stub
+}
+
+func rundeckAction(requireApproval bool) models.ActionConfig {
+	return models.ActionConfig{
+		Type:  TypeRundeck,
+		JobID: "restart-api",
+		Options: map[string]string{
+			"match":   "{{.Match}}",
+			"image":   "{{.Groups.image}}",
+			"pattern": "{{.Pattern}}",
+			"causa":   "{{.Causa}}",
+		},
+		RequireApproval: requireApproval,
+	}
+}
+
+func TestValidateMode(t *testing.T) {
+	allowed := AllowExecution(context.Background())
+	tests := []struct {
+		ctx  context.Context
+		mode string
+		want error
+	}{
+		{context.Background(), "", nil},
+		{context.Background(), ModeNone, nil},
+		{context.Background(), ModeDryRun, nil},
+		{context.Background(), ModeExecute, ErrExecutionNotAllowed},
+		{allowed, ModeExecute, nil},
+		{allowed, "run", ErrInvalidMode},
+	}
+	for _, tt := range tests {
+		if err := ValidateMode(tt.ctx, tt.mode); !errors.Is(err, tt.want) {
+			t.Errorf("ValidateMode(%q) = %v, want %v", tt.mode, err, tt.want)
+		}
+	}
+}
+
+func TestRunDryRun(t *testing.T) {
+	runner, stub := newTestRunner(t)
+	pattern := imagePullPattern
+	pattern.Actions = []models.ActionConfig{rundeckAction(false)}
+	domainAction := models.ActionConfig{Type: TypeWebhook, URL: "https://hooks.example.com/heal", Body: `{"causa": {{json .Causa}}}`}
+
+	results := runner.Run(context.Background(), ModeDryRun, analysis, []models.ErrorPattern{pattern}, []models.ActionConfig{domainAction})
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want the pattern and the domain action", len(results))
+	}
+
+	// Os grupos nomeados do padrão ficam disponíveis nos templates
+	pull := results[0]
+	want := map[string]string{
+		"match":   `Failed to pull image "registry.example.com/api:1.2"`,
+		"image":   "registry.example.com/api:1.2",
+		"pattern": "image_pull_backoff",
+		"causa":   "Imagem inexistente",
+	}
+	if pull.Status != StatusDryRun || pull.Pattern != "image_pull_backoff" || len(pull.Options) != len(want) {
+		t.Fatalf("unexpected result %+v", pull)
+	}
+	for name, value := range want {
+		if pull.Options[name] != value {
+			t.Errorf("option %s = %q, want %q", name, pull.Options[name], value)
+		}
+	}
+	if hook := results[1]; hook.Status != StatusDryRun || hook.Pattern != "" || hook.URL != domainAction.URL {
+		t.Errorf("unexpected domain action %+v", hook)
+	}
+
+	if len(stub.calls()) != 0 {
+		t.Errorf("dry_run triggered %d jobs", len(stub.calls()))
+	}
+	// Simulações não ficam guardadas para aprovação
+	if _, err := runner.Approve(context.Background(), pull.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Approve() on a dry_run = %v, want ErrNotFound", err)
+	}
+}
+
+func TestRunModeNone(t *testing.T) {
+	runner, _ := newTestRunner(t)
+	pattern := imagePullPattern
+	pattern.Actions = []models.ActionConfig{rundeckAction(false)}
+
+	for _, mode := range []string{"", ModeNone} {
+		if results := runner.Run(context.Background(), mode, analysis, []models.ErrorPattern{pattern}, nil); results != nil {
+			t.Errorf("mode %q returned %+v", mode, results)
+		}
+	}
+}
+
+func TestRunExecute(t *testing.T) {
+	runner, stub := newTestRunner(t)
+	pattern := imagePullPattern
+	pattern.Actions = []models.ActionConfig{rundeckAction(false)}
+
+	results := runner.Run(context.Background(), ModeExecute, analysis, []models.ErrorPattern{pattern}, nil)
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+	result := results[0]
+	if result.Status != "running" || result.ExecutionID != 1234 || result.Permalink == "" {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if calls := stub.calls(); len(calls) != 1 || calls[0]["image"] != "registry.example.com/api:1.2" {
+		t.Errorf("unexpected job runs %v", calls)
+	}
+
+	// Get consulta o estado atual da execução no Rundeck
+	current, err := runner.Get(context.Background(), result.ID)
+	if err != nil || current.Status != "succeeded" {
+		t.Errorf("Get() = %+v, %v", current, err)
+	}
+}
+
+func TestRunStatuses(t *testing.T) {
+	tests := []struct {
+		name    string
+		action  models.ActionConfig
+		rundeck bool
+		status  string
+	}{
+		{name: "rundeck not configured", action: rundeckAction(false), status: StatusDisabled},
+		{name: "approval required", action: rundeckAction(true), rundeck: true, status: StatusPendingApproval},
+		{
+			name:    "template error",
+			action:  models.ActionConfig{Type: TypeRundeck, JobID: "restart-api", Options: map[string]string{"x": "{{.Groups.image.Missing}}"}},
+			rundeck: true,
+			status:  StatusError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner, stub := newTestRunner(t)
+			if !tt.rundeck {
+				runner.client = &rundeck.Client{}
+			}
+			pattern := imagePullPattern
+			pattern.Actions = []models.ActionConfig{tt.action}
+
+			results := runner.Run(context.Background(), ModeExecute, analysis, []models.ErrorPattern{pattern}, nil)
+			if len(results) != 1 || results[0].Status != tt.status {
+				t.Fatalf("unexpected results %+v, want status %s", results, tt.status)
+			}
+			if len(stub.calls()) != 0 {
+				t.Errorf("job triggered with status %s", tt.status)
+			}
+		})
+	}
+}
+
+func TestApprove(t *testing.T) {
+	runner, stub := newTestRunner(t)
+	pattern := imagePullPattern
+	pattern.Actions = []models.ActionConfig{rundeckAction(true)}
+	ctx := tenant.WithTenant(context.Background(), "team-a")
+
+	pending := runner.Run(ctx, ModeExecute, analysis, []models.ErrorPattern{pattern}, nil)[0]
+	if pending.Status != StatusPendingApproval {
+		t.Fatalf("status %s, want %s", pending.Status, StatusPendingApproval)
+	}
+
+	// Ações de outro tenant não são visíveis
+	if _, err := runner.Approve(tenant.WithTenant(context.Background(), "team-b"), pending.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Approve() from another tenant = %v, want ErrNotFound", err)
+	}
+	if _, err := runner.Approve(ctx, "unknown"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Approve() of an unknown id = %v, want ErrNotFound", err)
+	}
+	if len(stub.calls()) != 0 {
+		t.Fatal("job triggered before the approval")
+	}
+
+	approved, err := runner.Approve(ctx, pending.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if approved.Status != "running" || approved.ExecutionID != 1234 || len(stub.calls()) != 1 {
+		t.Errorf("unexpected approved action %+v", approved)
+	}
+
+	// Aprovar de novo não dispara o job outra vez
+	if _, err := runner.Approve(ctx, pending.ID); !errors.Is(err, ErrNotPending) {
+		t.Errorf("second Approve() = %v, want ErrNotPending", err)
+	}
+	if len(stub.calls()) != 1 {
+		t.Errorf("job triggered %d times", len(stub.calls()))
+	}
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	runner, _ := newTestRunner(t)
+	t.Setenv("HEAL_SECRET", "webhook-secret")
+
+	var calls int
+	var mu sync.Mutex
+	received := make(chan *http.Request, 1)
+	bodies := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		first := calls == 1
+		mu.Unlock()
+		// A primeira tentativa falha e é repetida
+		if first {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		received <- r
+		bodies <- string(body)
+	}))
+	t.Cleanup(server.Close)
+
+	action := models.ActionConfig{
+		Type:      TypeWebhook,
+		URL:       server.URL + "/heal?source=hefestus",
+		Body:      `{"image": {{json .Groups.image}}, "causa": {{json .Causa}}}`,
+		SecretEnv: "HEAL_SECRET",
+	}
+	if err := ValidateAction(action); err != nil {
+		t.Fatal(err)
+	}
+	pattern := imagePullPattern
+	pattern.Actions = []models.ActionConfig{action}
+
+	result := runner.Run(context.Background(), ModeExecute, analysis, []models.ErrorPattern{pattern}, nil)[0]
+	if result.Status != StatusDelivering {
+		t.Fatalf("status %s, want %s", result.Status, StatusDelivering)
+	}
+
+	var req *http.Request
+	var body string
+	select {
+	case req = <-received:
+		body = <-bodies
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook not delivered")
+	}
+	if body != `{"image": "registry.example.com/api:1.2", "causa": "Imagem inexistente"}` {
+		t.Errorf("unexpected body %s", body)
+	}
+	if req.Header.Get(ActionIDHeader) != result.ID {
+		t.Errorf("%s = %q, want %q", ActionIDHeader, req.Header.Get(ActionIDHeader), result.ID)
+	}
+	timestamp := req.Header.Get(TimestampHeader)
+	if want := "sha256=" + Sign([]byte("webhook-secret"), timestamp, http.MethodPost, "/heal?source=hefestus", []byte(body)); req.Header.Get(SignatureHeader) != want {
+		t.Errorf("%s = %q, want %q", SignatureHeader, req.Header.Get(SignatureHeader), want)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		current, err := runner.Get(context.Background(), result.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if current.Status == StatusDelivered {
+			if len(current.Attempts) != 2 || current.Attempts[0].StatusCode != http.StatusServiceUnavailable || current.Attempts[1].StatusCode != http.StatusOK {
+				t.Errorf("unexpected attempts %+v", current.Attempts)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("status %s after the delivery", current.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestValidateAction(t *testing.T) {
+	tests := []struct {
+		name   string
+		action models.ActionConfig
+		valid  bool
+	}{
+		{name: "rundeck", action: rundeckAction(false), valid: true},
+		{name: "rundeck without job", action: models.ActionConfig{Type: TypeRundeck}},
+		{name: "webhook", action: models.ActionConfig{Type: TypeWebhook, URL: "https://hooks.example.com/heal"}, valid: true},
+		{name: "webhook without scheme", action: models.ActionConfig{Type: TypeWebhook, URL: "hooks.example.com/heal"}},
+		{name: "webhook method", action: models.ActionConfig{Type: TypeWebhook, URL: "https://hooks.example.com/heal", Method: "TRACE"}},
+		{name: "webhook body", action: models.ActionConfig{Type: TypeWebhook, URL: "https://hooks.example.com/heal", Body: "{{.Causa"}},
+		{name: "webhook secret", action: models.ActionConfig{Type: TypeWebhook, URL: "https://hooks.example.com/heal", SecretEnv: "HEFESTUS_TEST_UNSET_SECRET"}},
+		{name: "option template", action: models.ActionConfig{Type: TypeRundeck, JobID: "x", Options: map[string]string{"a": "{{"}}},
+		{name: "unknown type", action: models.ActionConfig{Type: "ansible"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAction(tt.action)
+			if tt.valid && err != nil {
+				t.Errorf("rejected: %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Error("accepted")
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package actions
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"hefestus-api/internal/logging"
+	"hefestus-api/internal/metrics"
+	"hefestus-api/internal/models"
+	"hefestus-api/internal/retry"
+	"hefestus-api/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// TimestampHeader e SignatureHeader seguem o mesmo esquema HMAC aceito pela
+	// API, para que os receptores possam reaproveitar a verificação
+	TimestampHeader = "X-Hefestus-Timestamp"
+	SignatureHeader = "X-Hefestus-Signature"
+	// ActionIDHeader identifica a ação, permitindo deduplicar retentativas
+	ActionIDHeader = "X-Hefestus-Action-Id"
+)
+
+// webhookRequest é a requisição já renderizada de uma ação webhook
+type webhookRequest struct {
+	method  string
+	url     string
+	headers map[string]string
+	body    []byte
+	secret  []byte
+}
+
+// validateWebhook verifica URL, método, template do corpo e segredo
+func validateWebhook(action models.ActionConfig) error {
+	u, err := url.Parse(action.URL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("invalid webhook URL: %q", action.URL)
+	}
+
+	switch webhookMethod(action) {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodGet, http.MethodDelete:
+	default:
+		return fmt.Errorf("unsupported webhook method: %q", action.Method)
+	}
+
+	if _, err := parseTemplate("body", action.Body); err != nil {
+		return fmt.Errorf("invalid body template: %w", err)
+	}
+
+	if action.SecretEnv != "" && os.Getenv(action.SecretEnv) == "" {
+		return fmt.Errorf("webhook secret: environment variable %s is empty", action.SecretEnv)
+	}
+	return nil
+}
+
+func webhookMethod(action models.ActionConfig) string {
+	if action.Method == "" {
+		return http.MethodPost
+	}
+	return strings.ToUpper(action.Method)
+}
+
+// renderWebhook monta a requisição: o corpo é renderizado pelo template e os
+// valores dos headers aceitam variáveis de ambiente (${JENKINS_TOKEN})
+func renderWebhook(action models.ActionConfig, input Input) (*webhookRequest, error) {
+	body, err := render("body", action.Body, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render webhook body: %w", err)
+	}
+
+	headers := make(map[string]string, len(action.Headers))
+	for name, value := range action.Headers {
+		headers[name] = os.ExpandEnv(value)
+	}
+
+	var secret []byte
+	if action.SecretEnv != "" {
+		secret = []byte(os.Getenv(action.SecretEnv))
+	}
+
+	return &webhookRequest{
+		method:  webhookMethod(action),
+		url:     action.URL,
+		headers: headers,
+		body:    []byte(body),
+		secret:  secret,
+	}, nil
+}
+
+// Sign calcula a assinatura usada em X-Hefestus-Signature, tanto nos webhooks
+// de ações quanto na autenticação HMAC da API: HMAC-SHA256 de
+// "timestamp\nMÉTODO\ncaminho?query\ncorpo", em hexadecimal
+func Sign(secret []byte, timestamp string, method string, requestURI string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp + "\n" + method + "\n" + requestURI + "\n"))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// deliver entrega o webhook com retentativas em erros de rede, 429 e 5xx,
+// registrando cada tentativa no resultado da ação; a espera pedida em
+// Retry-After é limitada a retry.DefaultMaxWait
+func (r *Runner) deliver(ctx context.Context, e *entry) {
+	result := r.snapshot(e)
+	ctx, span := tracing.StartClient(ctx, "actions.deliverWebhook",
+		attribute.String("hefestus.action.id", result.ID),
+		attribute.String("http.request.method", e.webhook.method))
+	defer span.End()
+
+	logger := logging.FromContext(ctx).With("action", result.ID, "url", result.URL)
+
+	policy := retry.Policy{MaxAttempts: r.maxAttempts, Backoff: r.backoff}
+	attempts, err := policy.Do(ctx, func(attempt int) error {
+		return r.send(ctx, e, attempt)
+	}, func(attempt int, wait time.Duration, err error) {
+		logger.Warn("webhook falhou, tentando novamente", "attempt", attempt, "wait", wait, "error", err)
+	})
+	if err != nil {
+		logger.Error("falha ao entregar webhook", "attempt", attempts, "error", err)
+	} else {
+		logger.Info("webhook entregue", "attempt", attempts)
+	}
+
+	r.mu.Lock()
+	if err != nil {
+		e.result.Status = StatusError
+		e.result.Error = err.Error()
+	} else {
+		e.result.Status = StatusDelivered
+	}
+	status := e.result.Status
+	r.mu.Unlock()
+
+	tracing.RecordError(span, err)
+	metrics.Actions.WithLabelValues(TypeWebhook, status).Inc()
+}
+
+// send faz uma tentativa de entrega e a registra no log de entregas; erros
+// que não adianta repetir vêm marcados com retry.Permanent
+func (r *Runner) send(ctx context.Context, e *entry, attempt int) (err error) {
+	hook := e.webhook
+	record := models.DeliveryAttempt{Attempt: attempt, Time: time.Now().UTC()}
+	defer func() {
+		record.DurationMs = time.Since(record.Time).Milliseconds()
+		if err != nil {
+			record.Error = err.Error()
+		}
+		r.mu.Lock()
+		e.result.Attempts = append(e.result.Attempts, record)
+		r.mu.Unlock()
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, hook.method, hook.url, bytes.NewReader(hook.body))
+	if err != nil {
+		return retry.Permanent(fmt.Errorf("failed to create request: %w", err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range hook.headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set(ActionIDHeader, e.result.ID)
+	if id := logging.RequestID(ctx); id != "" {
+		req.Header.Set(logging.RequestIDHeader, id)
+	}
+	if len(hook.secret) > 0 {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(TimestampHeader, timestamp)
+		req.Header.Set(SignatureHeader, "sha256="+Sign(hook.secret, timestamp, hook.method, req.URL.RequestURI(), hook.body))
+	}
+	tracing.Inject(ctx, req.Header)
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	record.StatusCode = resp.StatusCode
+
+	switch {
+	case resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return retry.After(fmt.Errorf("webhook answered with status %d", resp.StatusCode),
+			retry.ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now()))
+	default:
+		return retry.Permanent(fmt.Errorf("webhook rejected request with status %d", resp.StatusCode))
+	}
+}
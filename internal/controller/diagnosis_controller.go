@@ -0,0 +1,221 @@
+// Package controller implementa o controller do recurso Diagnosis: cada
+// Diagnosis criado ou alterado é analisado e o resultado vai para o status.
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"hefestus-api/internal/enrichment"
+	"hefestus-api/internal/logging"
+	"hefestus-api/internal/models"
+	"hefestus-api/internal/tracing"
+	"hefestus-api/pkg/apis/hefestus/v1alpha1"
+	"hefestus-api/pkg/ollama"
+
+	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/attribute"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlcontroller "sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const defaultDomain = "kubernetes"
+
+// Analyzer é a parte do ErrorService usada pelo controller
+type Analyzer interface {
+	ProcessError(ctx context.Context, domain string, req models.ErrorRequest) (*models.ErrorResponse, error)
+}
+
+// DiagnosisReconciler analisa cada geração de um Diagnosis uma única vez
+type DiagnosisReconciler struct {
+	client.Client
+	Analyzer Analyzer
+	Enricher *enrichment.Kubernetes
+}
+
+// NewManager cria o manager do controller-runtime com o DiagnosisReconciler
+// registrado. Sem servidor de métricas próprio (as métricas ficam no /metrics
+// da API); DIAGNOSIS_LEADER_ELECTION=true ativa eleição de líder para rodar
+// várias réplicas
+func NewManager(analyzer Analyzer, enricher *enrichment.Kubernetes) (manager.Manager, error) {
+	ctrl.SetLogger(logr.FromSlogHandler(slog.Default().Handler()))
+
+	scheme, err := newScheme()
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := ctrl.GetConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubernetes config: %w", err)
+	}
+
+	mgr, err := ctrl.NewManager(config, ctrl.Options{
+		Scheme:                 scheme,
+		Metrics:                metricsserver.Options{BindAddress: "0"},
+		HealthProbeBindAddress: "0",
+		LeaderElection:         os.Getenv("DIAGNOSIS_LEADER_ELECTION") == "true",
+		LeaderElectionID:       "hefestus-diagnosis.hefestus.io",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create controller manager: %w", err)
+	}
+
+	reconciler := &DiagnosisReconciler{Client: mgr.GetClient(), Analyzer: analyzer, Enricher: enricher}
+	if err := reconciler.SetupWithManager(mgr); err != nil {
+		return nil, fmt.Errorf("failed to set up diagnosis controller: %w", err)
+	}
+	return mgr, nil
+}
+
+// newScheme registra os tipos do Kubernetes e o Diagnosis
+func newScheme() (*runtime.Scheme, error) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		return nil, fmt.Errorf("failed to register kubernetes types: %w", err)
+	}
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		return nil, fmt.Errorf("failed to register diagnosis types: %w", err)
+	}
+	return scheme, nil
+}
+
+// SetupWithManager registra o controller; mudanças só de status não disparam
+// uma nova análise
+func (r *DiagnosisReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.Diagnosis{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		WithOptions(ctrlcontroller.Options{MaxConcurrentReconciles: 2}).
+		Complete(r)
+}
+
+// Reconcile analisa o Diagnosis se a geração atual ainda não foi analisada.
+// Falhas passageiras do Ollama deixam o Diagnosis em Pending e voltam para a
+// fila com backoff; só erros definitivos terminam em Failed
+func (r *DiagnosisReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var diagnosis v1alpha1.Diagnosis
+	if err := r.Get(ctx, req.NamespacedName, &diagnosis); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	status := &diagnosis.Status
+	if status.ObservedGeneration == diagnosis.Generation && (status.Phase == v1alpha1.PhaseCompleted || status.Phase == v1alpha1.PhaseFailed) {
+		return ctrl.Result{}, nil
+	}
+
+	ctx = logging.WithRequestID(ctx, logging.NewRequestID())
+	ctx, span := tracing.Start(ctx, "controller.Diagnosis",
+		attribute.String("k8s.namespace.name", diagnosis.Namespace),
+		attribute.String("hefestus.diagnosis.name", diagnosis.Name))
+	defer span.End()
+	logger := logging.FromContext(ctx).With("diagnosis", req.NamespacedName.String())
+
+	status.Phase = v1alpha1.PhaseAnalyzing
+	status.Message = ""
+	if err := r.Status().Update(ctx, &diagnosis); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	domain := diagnosis.Spec.Domain
+	if domain == "" {
+		domain = defaultDomain
+	}
+
+	response, err := r.Analyzer.ProcessError(ctx, domain, r.buildRequest(ctx, &diagnosis))
+	if retryable(err) {
+		// A geração continua sem análise: o erro devolvido faz a fila repetir
+		// o Diagnosis com backoff exponencial
+		logger.Warn("Ollama indisponível, análise adiada", "error", err)
+		status.Phase = v1alpha1.PhasePending
+		status.Message = "Análise adiada: " + err.Error()
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type: v1alpha1.ConditionAnalyzed, Status: metav1.ConditionUnknown, Reason: "AnalysisRetrying",
+			Message: err.Error(), ObservedGeneration: diagnosis.Generation,
+		})
+		if updateErr := r.Status().Update(ctx, &diagnosis); updateErr != nil {
+			return ctrl.Result{}, updateErr
+		}
+		return ctrl.Result{}, fmt.Errorf("analysis deferred: %w", err)
+	}
+
+	now := metav1.Now()
+	status.ObservedGeneration = diagnosis.Generation
+	status.CompletedAt = &now
+	if err != nil {
+		tracing.RecordError(span, err)
+		logger.Error("falha ao analisar Diagnosis", "error", err)
+		status.Phase = v1alpha1.PhaseFailed
+		status.Message = err.Error()
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type: v1alpha1.ConditionAnalyzed, Status: metav1.ConditionFalse, Reason: "AnalysisFailed",
+			Message: err.Error(), ObservedGeneration: diagnosis.Generation,
+		})
+	} else {
+		solution := response.Error
+		status.Phase = v1alpha1.PhaseCompleted
+		status.Message = response.Message
+		status.Cause = solution.Causa
+		status.Steps = strings.Split(solution.Solucao, "\n")
+		status.References = solution.References
+		status.Patterns = solution.Patterns
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type: v1alpha1.ConditionAnalyzed, Status: metav1.ConditionTrue, Reason: "Analyzed",
+			Message: solution.Causa, ObservedGeneration: diagnosis.Generation,
+		})
+		logger.Info("Diagnosis analisado", "causa", solution.Causa)
+	}
+
+	return ctrl.Result{}, r.Status().Update(ctx, &diagnosis)
+}
+
+// retryable separa falhas passageiras do Ollama, que não devem encerrar o
+// Diagnosis como Failed, de erros da própria requisição
+func retryable(err error) bool {
+	return errors.Is(err, ollama.ErrUnavailable) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled)
+}
+
+// buildRequest usa o estado, os eventos e os logs do pod referenciado: como
+// evidência do erro quando errorDetails está vazio, ou como contexto adicional
+func (r *DiagnosisReconciler) buildRequest(ctx context.Context, diagnosis *v1alpha1.Diagnosis) models.ErrorRequest {
+	spec := diagnosis.Spec
+	request := models.ErrorRequest{ErrorDetails: spec.ErrorDetails, Context: spec.Context}
+	if spec.PodRef == nil || r.Enricher == nil {
+		return request
+	}
+
+	// O podRef só aponta para o namespace do próprio Diagnosis
+	config := models.KubernetesEnrichmentConfig{Enabled: true, Namespaces: []string{diagnosis.Namespace}}
+	details, report := r.Enricher.Enrich(ctx, config, models.KubernetesRef{
+		Namespace: diagnosis.Namespace,
+		Pod:       spec.PodRef.Name,
+		Container: spec.PodRef.Container,
+	})
+	if report.Error != "" {
+		logging.FromContext(ctx).Warn("falha ao buscar estado do pod", "pod", spec.PodRef.Name, "error", report.Error)
+	}
+
+	switch {
+	case details == "":
+	case request.ErrorDetails == "":
+		request.ErrorDetails = details
+	default:
+		request.Context = strings.TrimSpace(request.Context + "\n\nEstado atual no cluster:\n" + details)
+	}
+	if request.ErrorDetails == "" {
+		request.ErrorDetails = fmt.Sprintf("Pod %s/%s: %s", diagnosis.Namespace, spec.PodRef.Name, report.Error)
+	}
+	return request
+}
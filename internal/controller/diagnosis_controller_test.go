@@ -0,0 +1,214 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"hefestus-api/internal/models"
+	"hefestus-api/pkg/apis/hefestus/v1alpha1"
+	"hefestus-api/pkg/ollama"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+)
+
+// stubAnalyzer responde conforme o texto do erro: "indisponível" falha com
+// ErrUnavailable nas duas primeiras chamadas e "inválido" falha sempre
+type stubAnalyzer struct {
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (a *stubAnalyzer) ProcessError(_ context.Context, _ string, req models.ErrorRequest) (*models.ErrorResponse, error) {
+	a.mu.Lock()
+	a.calls[req.ErrorDetails]++
+	calls := a.calls[req.ErrorDetails]
+	a.mu.Unlock()
+
+	switch {
+	case strings.Contains(req.ErrorDetails, "inválido"):
+		return nil, errors.New("error details rejected")
+	case strings.Contains(req.ErrorDetails, "indisponível") && calls <= 2:
+		return nil, fmt.Errorf("%w: status 503: model is loading", ollama.ErrUnavailable)
+	}
+	return &models.ErrorResponse{
+		Message: "Análise concluída com sucesso",
+		Error: &models.ErrorSolution{
+			Causa:   "Falta de memória",
+			Solucao: "kubectl top pod\nkubectl describe pod api",
+		},
+	}, nil
+}
+
+func (a *stubAnalyzer) count(details string) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.calls[details]
+}
+
+// startControlPlane sobe kube-apiserver e etcd com o CRD do Diagnosis e o
+// controller rodando; precisa dos binários do setup-envtest em KUBEBUILDER_ASSETS
+func startControlPlane(t *testing.T, analyzer Analyzer) client.Client {
+	t.Helper()
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		t.Skip("KUBEBUILDER_ASSETS não definido; rode setup-envtest para testar o controller")
+	}
+
+	env := &envtest.Environment{
+		CRDDirectoryPaths:     []string{filepath.Join("..", "..", "contrib", "kubernetes", "crd")},
+		ErrorIfCRDPathMissing: true,
+	}
+	config, err := env.Start()
+	if err != nil {
+		t.Fatalf("failed to start envtest: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := env.Stop(); err != nil {
+			t.Errorf("failed to stop envtest: %v", err)
+		}
+	})
+
+	scheme, err := newScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mgr, err := ctrl.NewManager(config, ctrl.Options{
+		Scheme:                 scheme,
+		Metrics:                metricsserver.Options{BindAddress: "0"},
+		HealthProbeBindAddress: "0",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := (&DiagnosisReconciler{Client: mgr.GetClient(), Analyzer: analyzer}).SetupWithManager(mgr); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- mgr.Start(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("manager stopped with error: %v", err)
+		}
+	})
+
+	c, err := client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// waitForPhase espera o Diagnosis chegar a Completed ou Failed
+func waitForPhase(t *testing.T, c client.Client, key client.ObjectKey) *v1alpha1.Diagnosis {
+	t.Helper()
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		var diagnosis v1alpha1.Diagnosis
+		if err := c.Get(context.Background(), key, &diagnosis); err != nil {
+			t.Fatal(err)
+		}
+		status := diagnosis.Status
+		if status.ObservedGeneration == diagnosis.Generation && (status.Phase == v1alpha1.PhaseCompleted || status.Phase == v1alpha1.PhaseFailed) {
+			return &diagnosis
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s stuck in phase %q: %s", key.Name, status.Phase, status.Message)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func TestDiagnosisReconciler(t *testing.T) {
+	analyzer := &stubAnalyzer{calls: make(map[string]int)}
+	c := startControlPlane(t, analyzer)
+
+	tests := []struct {
+		name    string
+		details string
+		phase   string
+		calls   int
+	}{
+		{name: "oom", details: "OOMKilled: container api", phase: v1alpha1.PhaseCompleted, calls: 1},
+		{name: "rejected", details: "pedido inválido", phase: v1alpha1.PhaseFailed, calls: 1},
+		// Falhas passageiras do Ollama voltam para a fila em vez de encerrar em Failed
+		{name: "ollama-down", details: "Ollama indisponível", phase: v1alpha1.PhaseCompleted, calls: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagnosis := &v1alpha1.Diagnosis{
+				ObjectMeta: metav1.ObjectMeta{Name: tt.name, Namespace: "default"},
+				Spec:       v1alpha1.DiagnosisSpec{ErrorDetails: tt.details},
+			}
+			if err := c.Create(context.Background(), diagnosis); err != nil {
+				t.Fatal(err)
+			}
+
+			got := waitForPhase(t, c, client.ObjectKeyFromObject(diagnosis))
+			if got.Status.Phase != tt.phase {
+				t.Fatalf("phase = %q (%s), want %q", got.Status.Phase, got.Status.Message, tt.phase)
+			}
+			if calls := analyzer.count(tt.details); calls != tt.calls {
+				t.Errorf("analyzer called %d times, want %d", calls, tt.calls)
+			}
+			if tt.phase == v1alpha1.PhaseCompleted {
+				if got.Status.Cause != "Falta de memória" || len(got.Status.Steps) != 2 || got.Status.CompletedAt == nil {
+					t.Errorf("unexpected status: %+v", got.Status)
+				}
+			}
+		})
+	}
+}
+
+// TestReconcileRetriesUnavailableOllama roda sem control plane, com o client
+// fake do controller-runtime
+func TestReconcileRetriesUnavailableOllama(t *testing.T) {
+	scheme, err := newScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+	diagnosis := &v1alpha1.Diagnosis{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default", Generation: 1},
+		Spec:       v1alpha1.DiagnosisSpec{ErrorDetails: "Ollama indisponível"},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(diagnosis).WithStatusSubresource(diagnosis).Build()
+	r := &DiagnosisReconciler{Client: c, Analyzer: &stubAnalyzer{calls: make(map[string]int)}}
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(diagnosis)}
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		if _, err := r.Reconcile(context.Background(), req); !errors.Is(err, ollama.ErrUnavailable) {
+			t.Fatalf("attempt %d: expected ErrUnavailable to requeue, got %v", attempt, err)
+		}
+		var got v1alpha1.Diagnosis
+		if err := c.Get(context.Background(), req.NamespacedName, &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Status.Phase != v1alpha1.PhasePending || got.Status.ObservedGeneration != 0 {
+			t.Fatalf("attempt %d: phase %q, observed generation %d", attempt, got.Status.Phase, got.Status.ObservedGeneration)
+		}
+	}
+
+	if _, err := r.Reconcile(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	var got v1alpha1.Diagnosis
+	if err := c.Get(context.Background(), req.NamespacedName, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Status.Phase != v1alpha1.PhaseCompleted || got.Status.ObservedGeneration != 1 {
+		t.Fatalf("phase %q, observed generation %d after Ollama recovered", got.Status.Phase, got.Status.ObservedGeneration)
+	}
+}
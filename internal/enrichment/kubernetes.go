@@ -0,0 +1,289 @@
+// Package enrichment acrescenta ao prompt detalhes buscados no ambiente de
+// origem do erro, que o cliente raramente envia no context.
+package enrichment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"hefestus-api/internal/logging"
+	"hefestus-api/internal/models"
+	"hefestus-api/internal/tracing"
+	"hefestus-api/pkg/kube"
+
+	"go.opentelemetry.io/otel/attribute"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+)
+
+// SourceKubernetes identifica o enriquecimento via API do Kubernetes no relatório
+const SourceKubernetes = "kubernetes"
+
+const (
+	defaultLogLines  = 50
+	defaultMaxEvents = 10
+	defaultMaxBytes  = 8192
+)
+
+// ErrInvalidReference indica uma referência sem namespace, sem pod ou
+// deployment, ou com nomes inválidos
+var ErrInvalidReference = errors.New("invalid kubernetes reference")
+
+// Kubernetes busca describe, eventos e logs do recurso citado na requisição.
+// O clientset só é criado no primeiro uso, para que a API suba fora do
+// cluster quando nenhum domínio usa o enriquecimento
+type Kubernetes struct {
+	newClient func() (kubernetes.Interface, error)
+	once      sync.Once
+	client    kubernetes.Interface
+	err       error
+}
+
+// NewKubernetes cria o enriquecedor; newClient normalmente é kube.NewClientset
+func NewKubernetes(newClient func() (kubernetes.Interface, error)) *Kubernetes {
+	return &Kubernetes{newClient: newClient}
+}
+
+// ValidateRef verifica o namespace e os nomes informados
+func ValidateRef(ref *models.KubernetesRef) error {
+	if ref == nil {
+		return nil
+	}
+	if len(validation.IsDNS1123Label(ref.Namespace)) > 0 {
+		return fmt.Errorf("%w: invalid namespace %q", ErrInvalidReference, ref.Namespace)
+	}
+	if ref.Pod == "" && ref.Deployment == "" {
+		return fmt.Errorf("%w: pod or deployment is required", ErrInvalidReference)
+	}
+	for _, name := range []string{ref.Pod, ref.Deployment} {
+		if name != "" && len(validation.IsDNS1123Subdomain(name)) > 0 {
+			return fmt.Errorf("%w: invalid name %q", ErrInvalidReference, name)
+		}
+	}
+	if ref.Container != "" && len(validation.IsDNS1123Label(ref.Container)) > 0 {
+		return fmt.Errorf("%w: invalid container %q", ErrInvalidReference, ref.Container)
+	}
+	return nil
+}
+
+func (k *Kubernetes) clientset() (kubernetes.Interface, error) {
+	k.once.Do(func() {
+		k.client, k.err = k.newClient()
+	})
+	return k.client, k.err
+}
+
+// Enrich retorna o texto a anexar ao context, já mascarado e limitado a
+// MaxBytes (os logs são cortados primeiro, mantendo o final). Falhas parciais
+// ficam no relatório e não impedem a análise
+func (k *Kubernetes) Enrich(ctx context.Context, config models.KubernetesEnrichmentConfig, ref models.KubernetesRef) (string, *models.EnrichmentReport) {
+	ctx, span := tracing.Start(ctx, "enrichment.Kubernetes",
+		attribute.String("k8s.namespace.name", ref.Namespace),
+		attribute.String("k8s.pod.name", ref.Pod),
+		attribute.String("k8s.deployment.name", ref.Deployment))
+	defer span.End()
+
+	applyDefaults(&config)
+	report := &models.EnrichmentReport{Source: SourceKubernetes}
+
+	if !namespaceAllowed(config.Namespaces, ref.Namespace) {
+		report.Error = "namespace não permitido para enriquecimento: " + ref.Namespace
+		return "", report
+	}
+
+	client, err := k.clientset()
+	if err != nil {
+		tracing.RecordError(span, err)
+		report.Error = err.Error()
+		return "", report
+	}
+
+	var sections []string
+	var errs []string
+	collect := func(kind string, name string) {
+		report.Objects = append(report.Objects, kind+"/"+ref.Namespace+"/"+name)
+		events, err := kube.ObjectEvents(ctx, client, ref.Namespace, kind, name, config.MaxEvents)
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+		if len(events) > 0 {
+			sections = append(sections, "Eventos de "+kind+" "+name+":\n"+strings.Join(events, "\n"))
+		}
+	}
+
+	var pod *corev1.Pod
+	if ref.Deployment != "" {
+		deployment, err := client.AppsV1().Deployments(ref.Namespace).Get(ctx, ref.Deployment, metav1.GetOptions{})
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("failed to get deployment: %v", err))
+		} else {
+			sections = append(sections, kube.DescribeDeployment(deployment))
+			collect("Deployment", deployment.Name)
+			if ref.Pod == "" {
+				if pod, err = worstPod(ctx, client, deployment.Namespace, deployment.Spec.Selector); err != nil {
+					errs = append(errs, err.Error())
+				}
+			}
+		}
+	}
+	if ref.Pod != "" {
+		if pod, err = client.CoreV1().Pods(ref.Namespace).Get(ctx, ref.Pod, metav1.GetOptions{}); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to get pod: %v", err))
+			pod = nil
+		}
+	}
+
+	var logs string
+	if pod != nil {
+		sections = append(sections, kube.DescribePod(pod))
+		collect("Pod", pod.Name)
+		logs, err = containerLogs(ctx, client, pod, ref.Container, config.LogLines)
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	text := boundText(logging.Redact(strings.Join(sections, "\n\n")), logging.Redact(logs), config.MaxBytes, report)
+	if len(errs) > 0 {
+		report.Error = strings.Join(errs, "; ")
+		logging.FromContext(ctx).Warn("enriquecimento parcial do contexto", "namespace", ref.Namespace, "error", report.Error)
+	}
+	report.Bytes = len(text)
+	return text, report
+}
+
+func applyDefaults(config *models.KubernetesEnrichmentConfig) {
+	if config.LogLines <= 0 {
+		config.LogLines = defaultLogLines
+	}
+	if config.MaxEvents <= 0 {
+		config.MaxEvents = defaultMaxEvents
+	}
+	if config.MaxBytes <= 0 {
+		config.MaxBytes = defaultMaxBytes
+	}
+}
+
+// namespaceAllowed só libera namespaces listados; a lista vazia não libera
+// nenhum e "*" libera todos, para que ler o cluster inteiro seja explícito
+func namespaceAllowed(allowed []string, namespace string) bool {
+	for _, ns := range allowed {
+		if ns == "*" || ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// worstPod escolhe, entre os pods do Deployment, o que não está pronto e tem
+// mais reinícios
+func worstPod(ctx context.Context, client kubernetes.Interface, namespace string, selector *metav1.LabelSelector) (*corev1.Pod, error) {
+	labels, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid deployment selector: %w", err)
+	}
+	pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: labels.String()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	var worst *corev1.Pod
+	worstScore := -1
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		score := 0
+		if !podReady(pod) {
+			score += 1 << 20
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			score += int(status.RestartCount)
+		}
+		if score > worstScore {
+			worst, worstScore = pod, score
+		}
+	}
+	return worst, nil
+}
+
+func podReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// containerLogs lê os logs do container informado ou do que mais reiniciou;
+// para containers reiniciados, a execução anterior é a que contém o erro
+func containerLogs(ctx context.Context, client kubernetes.Interface, pod *corev1.Pod, container string, lines int64) (string, error) {
+	var status *corev1.ContainerStatus
+	for i := range pod.Status.ContainerStatuses {
+		current := &pod.Status.ContainerStatuses[i]
+		if current.Name == container || (container == "" && (status == nil || current.RestartCount > status.RestartCount)) {
+			status = current
+		}
+	}
+	if status == nil {
+		if container == "" {
+			return "", nil
+		}
+		return "", fmt.Errorf("container %s not found in pod %s", container, pod.Name)
+	}
+	if status.State.Waiting != nil && status.LastTerminationState.Terminated == nil {
+		// O container ainda não rodou (imagem, configuração): não há logs
+		return "", nil
+	}
+
+	previous := status.LastTerminationState.Terminated != nil && status.State.Terminated == nil
+	logs, err := kube.PodLogs(ctx, client, pod, status.Name, previous, lines)
+	if err != nil {
+		return "", err
+	}
+	if logs == "" {
+		return "", nil
+	}
+	header := "Logs do container " + status.Name
+	if previous {
+		header += " (execução anterior)"
+	}
+	return header + ":\n" + logs, nil
+}
+
+// boundText junta detalhes e logs em até maxBytes, cortando primeiro o início
+// dos logs e depois o fim dos detalhes
+func boundText(details string, logs string, maxBytes int, report *models.EnrichmentReport) string {
+	if len(details) > maxBytes {
+		report.Truncated = true
+		return truncateBytes(details, maxBytes)
+	}
+
+	if logs == "" {
+		return details
+	}
+	remaining := maxBytes - len(details) - len("\n\n")
+	if len(logs) > remaining {
+		report.Truncated = true
+		if remaining <= 0 {
+			return details
+		}
+		logs = logs[len(logs)-remaining:]
+		for len(logs) > 0 && !utf8.RuneStart(logs[0]) {
+			logs = logs[1:]
+		}
+	}
+	return strings.TrimSpace(details + "\n\n" + logs)
+}
+
+func truncateBytes(text string, max int) string {
+	for max > 0 && !utf8.RuneStart(text[max]) {
+		max--
+	}
+	return text[:max]
+}
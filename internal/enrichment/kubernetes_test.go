@@ -0,0 +1,176 @@
+package enrichment
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"hefestus-api/internal/models"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// restartedPod cria um pod do Deployment api cujo container app já reiniciou
+func restartedPod(name string, restarts int32) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "shop", Labels: map[string]string{"app": "api"}},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "shop/api:1.2"}}},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+			Name:                 "app",
+			RestartCount:         restarts,
+			State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+			LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Error", ExitCode: 1}},
+		}}},
+	}
+}
+
+// newTestKubernetes usa um clientset falso e conta quantas vezes ele foi criado
+func newTestKubernetes() (*Kubernetes, *int) {
+	created := 0
+	client := fake.NewClientset(
+		restartedPod("api-1", 1),
+		restartedPod("api-2", 7),
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "shop"},
+			Spec:       appsv1.DeploymentSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "api"}}},
+		},
+	)
+	return NewKubernetes(func() (kubernetes.Interface, error) {
+		created++
+		return client, nil
+	}), &created
+}
+
+func TestValidateRef(t *testing.T) {
+	tests := []struct {
+		name  string
+		ref   *models.KubernetesRef
+		valid bool
+	}{
+		{name: "nil", valid: true},
+		{name: "pod", ref: &models.KubernetesRef{Namespace: "shop", Pod: "api-7d9f8c6b5-x2x7q"}, valid: true},
+		{name: "deployment and container", ref: &models.KubernetesRef{Namespace: "shop", Deployment: "api", Container: "app"}, valid: true},
+		{name: "missing namespace", ref: &models.KubernetesRef{Pod: "api"}},
+		{name: "invalid namespace", ref: &models.KubernetesRef{Namespace: "Shop_Prod", Pod: "api"}},
+		{name: "missing pod and deployment", ref: &models.KubernetesRef{Namespace: "shop"}},
+		{name: "invalid pod", ref: &models.KubernetesRef{Namespace: "shop", Pod: "api/../secrets"}},
+		{name: "invalid container", ref: &models.KubernetesRef{Namespace: "shop", Pod: "api", Container: "app.v2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRef(tt.ref)
+			if tt.valid && err != nil {
+				t.Errorf("rejected: %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidReference) {
+				t.Errorf("ValidateRef() = %v, want ErrInvalidReference", err)
+			}
+		})
+	}
+}
+
+func TestEnrichNamespaceAllowlist(t *testing.T) {
+	tests := []struct {
+		name       string
+		namespaces []string
+		allowed    bool
+	}{
+		{name: "empty list", namespaces: nil},
+		{name: "other namespace", namespaces: []string{"payments"}},
+		{name: "listed", namespaces: []string{"payments", "shop"}, allowed: true},
+		{name: "wildcard", namespaces: []string{"*"}, allowed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, created := newTestKubernetes()
+			text, report := k.Enrich(context.Background(), models.KubernetesEnrichmentConfig{Namespaces: tt.namespaces},
+				models.KubernetesRef{Namespace: "shop", Pod: "api-1"})
+
+			if tt.allowed {
+				if report.Error != "" || !strings.Contains(text, "Pod shop/api-1") {
+					t.Errorf("unexpected enrichment %q: %+v", text, report)
+				}
+				return
+			}
+			if text != "" || !strings.Contains(report.Error, "namespace não permitido") {
+				t.Errorf("namespace not rejected: %q, %+v", text, report)
+			}
+			// Namespaces recusados não chegam a criar o clientset
+			if *created != 0 {
+				t.Error("clientset created for a rejected namespace")
+			}
+		})
+	}
+}
+
+func TestEnrichDeployment(t *testing.T) {
+	k, _ := newTestKubernetes()
+	text, report := k.Enrich(context.Background(), models.KubernetesEnrichmentConfig{Namespaces: []string{"shop"}},
+		models.KubernetesRef{Namespace: "shop", Deployment: "api"})
+
+	if report.Error != "" || report.Truncated {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	// O pod com mais reinícios é o descrito
+	if want := []string{"Deployment/shop/api", "Pod/shop/api-2"}; strings.Join(report.Objects, ",") != strings.Join(want, ",") {
+		t.Errorf("objects %v, want %v", report.Objects, want)
+	}
+	if !strings.HasSuffix(text, "Logs do container app (execução anterior):\nfake logs") {
+		t.Errorf("logs of the previous run missing:\n%s", text)
+	}
+	if report.Bytes != len(text) {
+		t.Errorf("report.Bytes = %d, want %d", report.Bytes, len(text))
+	}
+}
+
+func TestEnrichTruncatesLogs(t *testing.T) {
+	k, _ := newTestKubernetes()
+	config := models.KubernetesEnrichmentConfig{Namespaces: []string{"shop"}}
+	ref := models.KubernetesRef{Namespace: "shop", Pod: "api-1"}
+	full, _ := k.Enrich(context.Background(), config, ref)
+
+	config.MaxBytes = len(full) - 20
+	text, report := k.Enrich(context.Background(), config, ref)
+	if !report.Truncated || len(text) > config.MaxBytes || report.Bytes != len(text) {
+		t.Fatalf("text of %d bytes with limit %d: %+v", len(text), config.MaxBytes, report)
+	}
+	// O início dos logs é cortado e o describe fica intacto
+	details := full[:strings.Index(full, "\n\nLogs do container")]
+	if !strings.HasPrefix(text, details+"\n\n") || !strings.HasSuffix(text, "fake logs") || strings.Contains(text, "Logs do container") {
+		t.Errorf("unexpected truncation:\n%s", text)
+	}
+}
+
+func TestBoundText(t *testing.T) {
+	tests := []struct {
+		name      string
+		details   string
+		logs      string
+		maxBytes  int
+		want      string
+		truncated bool
+	}{
+		{name: "fits", details: "Pod shop/api", logs: "line 1\nline 2", maxBytes: 100, want: "Pod shop/api\n\nline 1\nline 2"},
+		{name: "no logs", details: "Pod shop/api", maxBytes: 100, want: "Pod shop/api"},
+		{name: "keeps the end of the logs", details: "Pod", logs: "line 1\nline 2", maxBytes: 11, want: "Pod\n\nline 2", truncated: true},
+		{name: "no room for logs", details: "Pod shop/api", logs: "line 1", maxBytes: 13, want: "Pod shop/api", truncated: true},
+		{name: "details above the limit", details: "Pod shop/api", logs: "line 1", maxBytes: 3, want: "Pod", truncated: true},
+		// "ã" ocupa 2 bytes e não pode ser partido ao meio
+		{name: "utf-8 logs", details: "Pod", logs: "conexão recusada", maxBytes: 16, want: "Pod\n\no recusada", truncated: true},
+		{name: "utf-8 details", details: "Não pronto", logs: "line 1", maxBytes: 2, want: "N", truncated: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := &models.EnrichmentReport{}
+			got := boundText(tt.details, tt.logs, tt.maxBytes, report)
+			if got != tt.want || report.Truncated != tt.truncated {
+				t.Errorf("boundText() = %q (truncated %v), want %q (truncated %v)", got, report.Truncated, tt.want, tt.truncated)
+			}
+		})
+	}
+}
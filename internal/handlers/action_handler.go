@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"hefestus-api/internal/actions"
+	"hefestus-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ActionHandler expõe a consulta e a aprovação das ações de autocorreção
+type ActionHandler struct {
+	runner *actions.Runner
+}
+
+// NewActionHandler cria um novo manipulador de ações
+func NewActionHandler(runner *actions.Runner) *ActionHandler {
+	return &ActionHandler{
+		runner: runner,
+	}
+}
+
+// GetAction retorna o estado de uma ação
+// @Summary      Consultar ação de autocorreção
+// @Description  Retorna uma ação disparada ou pendente, atualizando o estado da execução no Rundeck
+// @Tags         actions
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        X-Tenant-ID  header  string  false "Tenant da requisição que originou a ação"
+// @Param        id           path    string  true  "ID da ação"
+// @Success      200  {object}  models.ActionResult
+// @Failure      401  {object}  models.APIError  "Credenciais ausentes ou inválidas"
+// @Failure      404  {object}  models.APIError  "Ação não encontrada ou expirada"
+// @Router       /actions/{id} [get]
+func (h *ActionHandler) GetAction(c *gin.Context) {
+	result, err := h.runner.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// ApproveAction dispara uma ação que aguardava aprovação
+// @Summary      Aprovar ação de autocorreção
+// @Description  Dispara no Rundeck uma ação com require_approval que está em pending_approval
+// @Tags         actions
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        X-Tenant-ID  header  string  false "Tenant da requisição que originou a ação"
+// @Param        id           path    string  true  "ID da ação"
+// @Success      200  {object}  models.ActionResult
+// @Failure      401  {object}  models.APIError  "Credenciais ausentes ou inválidas"
+// @Failure      403  {object}  models.APIError  "Escopo actions:approve ausente"
+// @Failure      404  {object}  models.APIError  "Ação não encontrada ou expirada"
+// @Failure      409  {object}  models.APIError  "Ação não aguarda aprovação"
+// @Router       /actions/{id}/approve [post]
+func (h *ActionHandler) ApproveAction(c *gin.Context) {
+	result, err := h.runner.Approve(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *ActionHandler) respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, actions.ErrNotFound):
+		c.JSON(http.StatusNotFound, models.APIError{
+			Code:    http.StatusNotFound,
+			Message: "Ação não encontrada",
+			Details: c.Param("id"),
+		})
+	case errors.Is(err, actions.ErrNotPending):
+		c.JSON(http.StatusConflict, models.APIError{
+			Code:    http.StatusConflict,
+			Message: "Ação não aguarda aprovação",
+			Details: err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.APIError{
+			Code:    http.StatusInternalServerError,
+			Message: "Erro ao processar ação",
+			Details: err.Error(),
+		})
+	}
+}
@@ -1,23 +1,39 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
+	"hefestus-api/internal/actions"
+	"hefestus-api/internal/enrichment"
+	"hefestus-api/internal/metrics"
 	"hefestus-api/internal/models"
+	"hefestus-api/internal/notifier"
 	"hefestus-api/internal/services"
+	"hefestus-api/internal/tracing"
+	"hefestus-api/pkg/ollama"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// streamKeepAlive é o intervalo entre comentários de keep-alive no stream SSE
+	streamKeepAlive = 15 * time.Second
+	// unavailableRetryAfter é a espera sugerida em Retry-After quando o modelo está indisponível
+	unavailableRetryAfter = "30"
 )
 
 // ErrorHandler encapsula a manipulação de requisições de análise de erros
 type ErrorHandler struct {
-	llmService *services.LLMService
+	errorService *services.ErrorService
 }
 
 // NewErrorHandler cria um novo manipulador de erros
-func NewErrorHandler(llmService *services.LLMService) *ErrorHandler {
+func NewErrorHandler(errorService *services.ErrorService) *ErrorHandler {
 	return &ErrorHandler{
-		llmService: llmService,
+		errorService: errorService,
 	}
 }
 
@@ -27,16 +43,148 @@ func NewErrorHandler(llmService *services.LLMService) *ErrorHandler {
 // @Tags         errors
 // @Accept       json
 // @Produce      json
+// @Security     ApiKeyAuth
+// @Param        X-Tenant-ID  header  string              false "Tenant cujas configurações e dicionários serão usados"
 // @Param        domain   path      string                 true  "Domínio técnico (kubernetes, github, argocd)"   Enums(kubernetes, github, argocd)
 // @Param        request  body      models.ErrorRequest    true  "Detalhes do erro e contexto"
 // @Success      200      {object}  models.ErrorResponse   "Solução para o erro"
 // @Failure      400      {object}  models.APIError        "Erro de validação ou requisição inválida"
+// @Failure      401      {object}  models.APIError        "Credenciais ausentes ou inválidas"
+// @Failure      403      {object}  models.APIError        "Escopo analyze:{domain} ausente, ou actions:execute ausente com action_mode=execute"
 // @Failure      404      {object}  models.APIError        "Domínio não encontrado"
+// @Failure      413      {object}  models.APIError        "Erro não cabe na janela de contexto do modelo"
+// @Failure      429      {object}  models.APIError        "Limite de requisições ou cota diária excedidos"
 // @Failure      500      {object}  models.APIError        "Erro interno do servidor"
+// @Failure      503      {object}  models.APIError        "Modelo indisponível; tente novamente após Retry-After"
 // @Router       /errors/{domain} [post]
 func (h *ErrorHandler) AnalyzeError(c *gin.Context) {
 	domain := c.Param("domain")
 
+	// Domínios inválidos são agrupados para não explodir a cardinalidade das métricas
+	start := time.Now()
+	defer func() {
+		label := domain
+		if !isValidDomain(domain) {
+			label = "unknown"
+		}
+		metrics.ObserveRequest(label, c.Writer.Status(), time.Since(start))
+	}()
+
+	request, ok := bindErrorRequest(c, domain)
+	if !ok {
+		return
+	}
+
+	ctx, span := tracing.Start(c.Request.Context(), "ErrorHandler.AnalyzeError",
+		attribute.String("hefestus.domain", domain),
+		attribute.Int("hefestus.error_details.bytes", len(request.ErrorDetails)))
+	defer span.End()
+
+	// Pré-processar o log e obter resolução do serviço LLM
+	response, err := h.errorService.ProcessError(ctx, domain, request)
+	tracing.RecordError(span, err)
+	if err != nil {
+		apiErr := analysisError(err)
+		if apiErr.Code == http.StatusServiceUnavailable {
+			c.Header("Retry-After", unavailableRetryAfter)
+		}
+		c.JSON(apiErr.Code, apiErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// AnalyzeErrorStream processa a análise enviando o progresso como Server-Sent Events
+// @Summary      Analisar erros com progresso em Server-Sent Events
+// @Description  Igual a POST /errors/{domain}, mas responde em text/event-stream: um evento status ao iniciar, comentários de keep-alive enquanto o modelo responde e, ao final, um evento result com o ErrorResponse ou error com o APIError. Erros de validação, autenticação e limite são respondidos antes do stream, como JSON.
+// @Tags         errors
+// @Accept       json
+// @Produce      text/event-stream
+// @Security     ApiKeyAuth
+// @Param        X-Tenant-ID  header  string              false "Tenant cujas configurações e dicionários serão usados"
+// @Param        domain   path      string                 true  "Domínio técnico (kubernetes, github, argocd)"   Enums(kubernetes, github, argocd)
+// @Param        request  body      models.ErrorRequest    true  "Detalhes do erro e contexto"
+// @Success      200      {object}  models.ErrorResponse   "Stream com os eventos status e result (ou error)"
+// @Failure      400      {object}  models.APIError        "Erro de validação ou requisição inválida"
+// @Failure      401      {object}  models.APIError        "Credenciais ausentes ou inválidas"
+// @Failure      403      {object}  models.APIError        "Escopo analyze:{domain} ausente"
+// @Failure      404      {object}  models.APIError        "Domínio não encontrado"
+// @Failure      429      {object}  models.APIError        "Limite de requisições ou cota diária excedidos"
+// @Router       /errors/{domain}/stream [post]
+func (h *ErrorHandler) AnalyzeErrorStream(c *gin.Context) {
+	domain := c.Param("domain")
+
+	// O stream responde 200 mesmo quando termina com um evento error; nesse
+	// caso a métrica registra o código do APIError enviado
+	start := time.Now()
+	status := 0
+	defer func() {
+		label := domain
+		if !isValidDomain(domain) {
+			label = "unknown"
+		}
+		if status == 0 {
+			status = c.Writer.Status()
+		}
+		metrics.ObserveRequest(label, status, time.Since(start))
+	}()
+
+	request, ok := bindErrorRequest(c, domain)
+	if !ok {
+		return
+	}
+
+	ctx, span := tracing.Start(c.Request.Context(), "ErrorHandler.AnalyzeErrorStream",
+		attribute.String("hefestus.domain", domain),
+		attribute.Int("hefestus.error_details.bytes", len(request.ErrorDetails)))
+	defer span.End()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	// Evita que proxies como o nginx segurem os eventos em buffer
+	c.Header("X-Accel-Buffering", "no")
+	c.SSEvent("status", gin.H{"stage": "analyzing", "domain": domain})
+	c.Writer.Flush()
+
+	type outcome struct {
+		response *models.ErrorResponse
+		err      error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		response, err := h.errorService.ProcessError(ctx, domain, request)
+		done <- outcome{response, err}
+	}()
+
+	// O keep-alive impede que proxies encerrem a conexão ociosa enquanto o
+	// modelo responde; se o cliente desconectar, o ctx cancela a análise
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-keepAlive.C:
+			_, _ = c.Writer.WriteString(": keep-alive\n\n")
+			c.Writer.Flush()
+		case result := <-done:
+			tracing.RecordError(span, result.err)
+			if result.err != nil {
+				apiErr := analysisError(result.err)
+				status = apiErr.Code
+				c.SSEvent("error", apiErr)
+			} else {
+				c.SSEvent("result", result.response)
+			}
+			c.Writer.Flush()
+			return
+		}
+	}
+}
+
+// bindErrorRequest valida o domínio e o corpo, respondendo o erro quando inválidos
+func bindErrorRequest(c *gin.Context, domain string) (models.ErrorRequest, bool) {
+	var request models.ErrorRequest
+
 	// Validação do domínio
 	if !isValidDomain(domain) {
 		c.JSON(http.StatusNotFound, models.APIError{
@@ -44,17 +192,16 @@ func (h *ErrorHandler) AnalyzeError(c *gin.Context) {
 			Message: "Domínio não encontrado",
 			Details: "Domínios válidos: kubernetes, github, argocd",
 		})
-		return
+		return request, false
 	}
 
-	var request models.ErrorRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, models.APIError{
 			Code:    http.StatusBadRequest,
 			Message: "Requisição inválida",
 			Details: err.Error(),
 		})
-		return
+		return request, false
 	}
 
 	// Validar dados da requisição
@@ -64,29 +211,57 @@ func (h *ErrorHandler) AnalyzeError(c *gin.Context) {
 			Message: "Campos obrigatórios não preenchidos",
 			Details: "O campo error_details é obrigatório",
 		})
-		return
+		return request, false
 	}
+	return request, true
+}
 
-	// Obter resolução do serviço LLM
-	resolution, err := h.llmService.GetResolution(
-		c.Request.Context(),
-		domain,
-		request.ErrorDetails,
-		request.Context,
-	)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.APIError{
+// analysisError converte o erro da análise na resposta da API
+func analysisError(err error) models.APIError {
+	switch {
+	case errors.Is(err, actions.ErrInvalidMode):
+		return models.APIError{
+			Code:    http.StatusBadRequest,
+			Message: "Modo de ação inválido",
+			Details: err.Error(),
+		}
+	case errors.Is(err, actions.ErrExecutionNotAllowed):
+		return models.APIError{
+			Code:    http.StatusForbidden,
+			Message: "Acesso negado",
+			Details: "O escopo actions:execute é necessário para action_mode=execute (com a autenticação desativada, actions.allow_unauthenticated em auth.json)",
+		}
+	case errors.Is(err, notifier.ErrSinkNotAllowed):
+		return models.APIError{
+			Code:    http.StatusBadRequest,
+			Message: "Destino de notificação inválido",
+			Details: err.Error(),
+		}
+	case errors.Is(err, enrichment.ErrInvalidReference):
+		return models.APIError{
+			Code:    http.StatusBadRequest,
+			Message: "Referência Kubernetes inválida",
+			Details: err.Error(),
+		}
+	case errors.Is(err, ollama.ErrContextWindowExceeded):
+		return models.APIError{
+			Code:    http.StatusRequestEntityTooLarge,
+			Message: "Erro excede a janela de contexto do modelo",
+			Details: err.Error(),
+		}
+	case errors.Is(err, ollama.ErrUnavailable):
+		return models.APIError{
+			Code:    http.StatusServiceUnavailable,
+			Message: "Modelo indisponível",
+			Details: err.Error(),
+		}
+	default:
+		return models.APIError{
 			Code:    http.StatusInternalServerError,
 			Message: "Erro ao processar solução",
 			Details: err.Error(),
-		})
-		return
+		}
 	}
-
-	c.JSON(http.StatusOK, models.ErrorResponse{
-		Error:   resolution,
-		Message: "Análise concluída com sucesso",
-	})
 }
 
 // HealthCheck verifica a saúde do serviço
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"hefestus-api/internal/actions"
+	"hefestus-api/internal/metrics"
+	"hefestus-api/internal/models"
+	"hefestus-api/internal/notifier"
+	"hefestus-api/internal/services"
+	"hefestus-api/pkg/ollama"
+	"hefestus-api/pkg/ollama/ollamatest"
+	"hefestus-api/pkg/rundeck"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// newTestErrorService monta os serviços do servidor com a configuração do
+// repositório e o Ollama falso
+func newTestErrorService(t *testing.T) (*services.ErrorService, *services.DictionaryService, *ollamatest.Server) {
+	t.Helper()
+	srv := ollamatest.NewServer()
+	t.Cleanup(srv.Close)
+
+	dictService, err := services.NewDictionaryService(filepath.Join("..", ".."))
+	if err != nil {
+		t.Fatal(err)
+	}
+	llmService := services.NewLLMService(services.NewOllamaClient(dictService, ollama.WithBaseURL(srv.URL)), dictService)
+	errorService := services.NewErrorService(llmService, services.NewPreprocessService(dictService), notifier.NewNotifier(), actions.NewRunner(rundeck.NewClient()), nil)
+	return errorService, dictService, srv
+}
+
+// newTestRouter monta as rotas de análise com os serviços de newTestErrorService
+func newTestRouter(t *testing.T) (*gin.Engine, *ollamatest.Server) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	errorService, _, srv := newTestErrorService(t)
+	handler := NewErrorHandler(errorService)
+
+	r := gin.New()
+	r.POST("/api/errors/:domain", handler.AnalyzeError)
+	r.POST("/api/errors/:domain/stream", handler.AnalyzeErrorStream)
+	return r, srv
+}
+
+func serve(r http.Handler, path string, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAnalyzeError(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		body     string
+		response ollamatest.Response
+		status   int
+		message  string
+	}{
+		{
+			name:     "diagnosis",
+			path:     "/api/errors/kubernetes",
+			body:     `{"error_details": "Warning BackOff: CrashLoopBackOff", "context": "Deployment api"}`,
+			response: ollamatest.Diagnosis("Container reiniciando", "kubectl logs api --previous"),
+			status:   http.StatusOK,
+			message:  "Análise concluída com sucesso",
+		},
+		{name: "unknown domain", path: "/api/errors/jenkins", body: `{"error_details": "x"}`, status: http.StatusNotFound, message: "Domínio não encontrado"},
+		{name: "invalid json", path: "/api/errors/kubernetes", body: `{"error_details":`, status: http.StatusBadRequest, message: "Requisição inválida"},
+		{name: "missing details", path: "/api/errors/kubernetes", body: `{"context": "x"}`, status: http.StatusBadRequest, message: "Requisição inválida"},
+		{name: "disallowed sink", path: "/api/errors/kubernetes", body: `{"error_details": "x", "notify": [{"type": "slack", "url": "http://169.254.169.254/"}]}`, status: http.StatusBadRequest, message: "Destino de notificação inválido"},
+		{
+			name:     "ollama unavailable",
+			path:     "/api/errors/kubernetes",
+			body:     `{"error_details": "x"}`,
+			response: ollamatest.Failure(http.StatusServiceUnavailable, "server busy"),
+			status:   http.StatusServiceUnavailable,
+			message:  "Modelo indisponível",
+		},
+		{
+			name:     "ollama failure",
+			path:     "/api/errors/kubernetes",
+			body:     `{"error_details": "x"}`,
+			response: ollamatest.Failure(http.StatusBadRequest, "invalid options"),
+			status:   http.StatusInternalServerError,
+			message:  "Erro ao processar solução",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, srv := newTestRouter(t)
+			if tt.response != (ollamatest.Response{}) {
+				srv.Enqueue(tt.response)
+			}
+
+			w := serve(r, tt.path, tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status != http.StatusOK {
+				var apiErr models.APIError
+				if err := json.Unmarshal(w.Body.Bytes(), &apiErr); err != nil || apiErr.Code != tt.status || apiErr.Message != tt.message {
+					t.Errorf("unexpected error body %s", w.Body)
+				}
+				// Só a indisponibilidade do modelo sugere uma nova tentativa
+				if got := w.Header().Get("Retry-After"); (tt.status == http.StatusServiceUnavailable) != (got != "") {
+					t.Errorf("Retry-After = %q with status %d", got, tt.status)
+				}
+				return
+			}
+
+			var response models.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+			if response.Message != tt.message || response.Error == nil || response.Error.Causa != "Container reiniciando" {
+				t.Errorf("unexpected response %s", w.Body)
+			}
+			// O padrão do dicionário que casou volta na resposta e vai para o prompt
+			if len(response.Error.Patterns) != 1 || response.Error.Patterns[0] != "pod_crash_loop" {
+				t.Errorf("patterns = %v, want [pod_crash_loop]", response.Error.Patterns)
+			}
+			if prompt := srv.Requests()[0].Prompt; !strings.Contains(prompt, "CrashLoopBackOff") || !strings.Contains(prompt, "Deployment api") {
+				t.Errorf("prompt without error details and context:\n%s", prompt)
+			}
+		})
+	}
+}
+
+func TestAnalyzeErrorStream(t *testing.T) {
+	tests := []struct {
+		name     string
+		response ollamatest.Response
+		events   []string
+		contains string
+		status   string
+	}{
+		{
+			name:     "result",
+			response: ollamatest.Diagnosis("Falta de memória", "kubectl top pod"),
+			events:   []string{"event:status", "event:result"},
+			contains: "Falta de memória",
+			status:   "200",
+		},
+		{
+			name:     "error",
+			response: ollamatest.Failure(http.StatusBadRequest, "invalid options"),
+			events:   []string{"event:status", "event:error"},
+			contains: `"code":500`,
+			status:   "500",
+		},
+		{
+			name:     "unavailable",
+			response: ollamatest.Failure(http.StatusServiceUnavailable, "server busy"),
+			events:   []string{"event:status", "event:error"},
+			contains: `"code":503`,
+			status:   "503",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, srv := newTestRouter(t)
+			srv.Enqueue(tt.response)
+			// A métrica registra o código do evento final, não o 200 do stream
+			requests := metrics.HTTPRequests.WithLabelValues("kubernetes", tt.status)
+			before := testutil.ToFloat64(requests)
+
+			w := serve(r, "/api/errors/kubernetes/stream", `{"error_details": "OOMKilled: container api"}`)
+			if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream") {
+				t.Fatalf("status %d, content type %q", w.Code, w.Header().Get("Content-Type"))
+			}
+			body := w.Body.String()
+			last := 0
+			for _, event := range tt.events {
+				i := strings.Index(body[last:], event)
+				if i < 0 {
+					t.Fatalf("missing %q after offset %d in stream:\n%s", event, last, body)
+				}
+				last += i
+			}
+			if !strings.Contains(body[last:], tt.contains) {
+				t.Errorf("final event without %q:\n%s", tt.contains, body[last:])
+			}
+			if got := testutil.ToFloat64(requests) - before; got != 1 {
+				t.Errorf("%v requests recorded with status %s, want 1", got, tt.status)
+			}
+		})
+	}
+}
+
+func TestAnalyzeErrorStreamValidatesBeforeStreaming(t *testing.T) {
+	r, srv := newTestRouter(t)
+
+	w := serve(r, "/api/errors/kubernetes/stream", `{"error_details": ""}`)
+	if w.Code != http.StatusBadRequest || !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		t.Fatalf("status %d, content type %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if len(srv.Requests()) != 0 {
+		t.Errorf("Ollama called for an invalid request")
+	}
+}
@@ -0,0 +1,449 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"hefestus-api/internal/integrations"
+	"hefestus-api/internal/logging"
+	"hefestus-api/internal/metrics"
+	"hefestus-api/internal/models"
+	"hefestus-api/internal/services"
+	"hefestus-api/internal/tracing"
+	"hefestus-api/pkg/github"
+	"hefestus-api/pkg/zabbix"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// DomainLimiter aplica rate limit e cota por domínio dentro do handler, já que
+// nas integrações o domínio só é conhecido depois de ler o evento
+type DomainLimiter interface {
+	// Admit reserva a análise do domínio para o cliente da requisição; release
+	// ajusta a cota às chamadas ao LLM feitas com o ctx retornado
+	Admit(c *gin.Context, ctx context.Context, domain string) (context.Context, func(), *models.APIError)
+}
+
+// IntegrationHandler recebe eventos de ferramentas externas e analisa cada
+// item como uma requisição de erro
+type IntegrationHandler struct {
+	errorService *services.ErrorService
+	dictService  *services.DictionaryService
+	config       *integrations.Config
+	zabbixClient *zabbix.Client
+	githubClient *github.Client
+	limiter      DomainLimiter
+}
+
+// NewIntegrationHandler cria um novo manipulador de integrações
+func NewIntegrationHandler(errorService *services.ErrorService, dictService *services.DictionaryService, config *integrations.Config, zabbixClient *zabbix.Client, githubClient *github.Client, limiter DomainLimiter) *IntegrationHandler {
+	return &IntegrationHandler{
+		errorService: errorService,
+		dictService:  dictService,
+		config:       config,
+		zabbixClient: zabbixClient,
+		githubClient: githubClient,
+		limiter:      limiter,
+	}
+}
+
+// Alertmanager aceita os alertas disparados recebidos do Alertmanager e os analisa em segundo plano
+// @Summary      Receber alertas do Alertmanager
+// @Description  Recebe o webhook do Prometheus Alertmanager e enfileira a análise de cada alerta disparado; a descrição vira error_details e os labels, o contexto. A resposta sai antes da análise, para não estourar o timeout do Alertmanager (10s por padrão) e provocar reenvios; o diagnóstico segue para os destinos de notify. Alertas resolvidos são ignorados.
+// @Tags         integrations
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        X-Tenant-ID  header  string                      false "Tenant cujas configurações e dicionários serão usados"
+// @Param        payload      body    models.AlertmanagerWebhook  true  "Payload do webhook do Alertmanager"
+// @Success      200  {object}  models.IntegrationResult  "Nenhum alerta enfileirado"
+// @Success      202  {object}  models.IntegrationResult  "Alertas enfileirados para análise"
+// @Failure      400  {object}  models.APIError  "Payload inválido"
+// @Failure      401  {object}  models.APIError  "Credenciais ausentes ou inválidas"
+// @Failure      403  {object}  models.APIError  "Escopo integrations:alertmanager ausente"
+// @Failure      429  {object}  models.APIError  "Limite de requisições ou cota diária excedidos"
+// @Router       /integrations/alertmanager [post]
+func (h *IntegrationHandler) Alertmanager(c *gin.Context) {
+	var payload models.AlertmanagerWebhook
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		h.invalidPayload(c, err)
+		return
+	}
+
+	ctx, span := tracing.Start(c.Request.Context(), "IntegrationHandler.Alertmanager",
+		attribute.Int("hefestus.integration.items", len(payload.Alerts)))
+	defer span.End()
+
+	type admitted struct {
+		alert   integrations.AlertRequest
+		ctx     context.Context
+		release func()
+	}
+	var queue []admitted
+
+	result := models.IntegrationResult{Received: len(payload.Alerts)}
+	for _, alert := range h.config.Alertmanager.Requests(payload) {
+		if alert.Item.Status != "" {
+			addItem(&result, h.analyze(ctx, "alertmanager", alert.Item, alert.Request))
+			continue
+		}
+		// Alertas recusados pelo limite do domínio voltam como falha; os demais
+		// do mesmo lote seguem
+		itemCtx, release, apiErr := h.admit(c, ctx, alert.Item)
+		if apiErr != nil {
+			alert.Item.Status = integrations.StatusFailed
+			alert.Item.Reason = apiErr.Message
+			addItem(&result, h.analyze(ctx, "alertmanager", alert.Item, alert.Request))
+			continue
+		}
+		queue = append(queue, admitted{alert, itemCtx, release})
+		result.Items = append(result.Items, queued("alertmanager", alert.Item))
+		result.Queued++
+	}
+
+	if len(queue) == 0 {
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	// O Alertmanager reenvia o lote se a resposta demorar mais que o seu
+	// timeout, o que repetiria a análise e o consumo de cota
+	go func() {
+		for _, item := range queue {
+			h.analyze(context.WithoutCancel(item.ctx), "alertmanager", item.alert.Item, item.alert.Request)
+			item.release()
+		}
+	}()
+	c.JSON(http.StatusAccepted, result)
+}
+
+// Zabbix aceita um problema enviado pelo media type webhook do Zabbix e o analisa em segundo plano
+// @Summary      Receber eventos do Zabbix
+// @Description  Recebe o payload do script do media type webhook do Zabbix e enfileira a análise do problema; o domínio vem das tags ou dos grupos de hosts. A resposta sai antes da análise, para caber no timeout do media type. Com acknowledge habilitado, o diagnóstico é registrado como comentário do problema. Recuperações e atualizações são ignoradas.
+// @Tags         integrations
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        X-Tenant-ID  header  string              false "Tenant cujas configurações e dicionários serão usados"
+// @Param        event        body    models.ZabbixEvent  true  "Evento do Zabbix"
+// @Success      200  {object}  models.IntegrationResult  "Evento ignorado"
+// @Success      202  {object}  models.IntegrationResult  "Problema enfileirado para análise"
+// @Failure      400  {object}  models.APIError  "Payload inválido"
+// @Failure      401  {object}  models.APIError  "Credenciais ausentes ou inválidas"
+// @Failure      403  {object}  models.APIError  "Escopo integrations:zabbix ausente"
+// @Failure      429  {object}  models.APIError  "Limite de requisições ou cota diária excedidos"
+// @Router       /integrations/zabbix [post]
+func (h *IntegrationHandler) Zabbix(c *gin.Context) {
+	var event models.ZabbixEvent
+	if err := c.ShouldBindJSON(&event); err != nil {
+		h.invalidPayload(c, err)
+		return
+	}
+
+	ctx, span := tracing.Start(c.Request.Context(), "IntegrationHandler.Zabbix",
+		attribute.String("hefestus.zabbix.event_id", event.EventID))
+	defer span.End()
+
+	planned := h.config.Zabbix.Request(event)
+	result := models.IntegrationResult{Received: 1}
+	if planned.Item.Status != "" {
+		addItem(&result, h.analyze(ctx, "zabbix", planned.Item, planned.Request))
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	ctx, release, apiErr := h.admit(c, ctx, planned.Item)
+	if apiErr != nil {
+		c.JSON(apiErr.Code, apiErr)
+		return
+	}
+
+	go func() {
+		defer release()
+		h.processZabbix(context.WithoutCancel(ctx), event.EventID, planned)
+	}()
+
+	result.Queued = 1
+	result.Items = append(result.Items, queued("zabbix", planned.Item))
+	c.JSON(http.StatusAccepted, result)
+}
+
+// processZabbix analisa o problema e, se configurado, comenta o diagnóstico nele
+func (h *IntegrationHandler) processZabbix(ctx context.Context, eventID string, planned integrations.ZabbixRequest) {
+	item := h.analyze(ctx, "zabbix", planned.Item, planned.Request)
+	if item.Status != integrations.StatusAnalyzed || !h.config.Zabbix.Acknowledge {
+		return
+	}
+
+	comment := integrations.ZabbixComment(item.Domain, item.Response)
+	if err := h.zabbixClient.Acknowledge(ctx, eventID, comment); err != nil {
+		logging.FromContext(ctx).Warn("falha ao comentar problema no Zabbix", "event_id", eventID, "error", err)
+	}
+}
+
+// GitHub recebe os webhooks workflow_run e workflow_job do GitHub
+// @Summary      Receber webhooks do GitHub Actions
+// @Description  Verifica a assinatura X-Hub-Signature-256 e, para execuções ou jobs concluídos com falha, baixa os logs dos jobs e os analisa em segundo plano, comentando o diagnóstico no commit ou pull request quando configurado. Dispensa API key.
+// @Tags         integrations
+// @Accept       json
+// @Produce      json
+// @Param        X-GitHub-Event       header  string                      true  "Tipo do evento"  Enums(workflow_run, workflow_job, ping)
+// @Param        X-Hub-Signature-256  header  string                      true  "Assinatura HMAC-SHA256 do corpo"
+// @Param        event                body    models.GitHubWorkflowEvent  true  "Payload do webhook"
+// @Success      200  {object}  models.IntegrationResult  "Evento ignorado"
+// @Success      202  {object}  models.IntegrationResult  "Falha enfileirada para análise"
+// @Failure      400  {object}  models.APIError  "Payload inválido"
+// @Failure      401  {object}  models.APIError  "Assinatura inválida"
+// @Failure      429  {object}  models.APIError  "Limite de requisições ou cota diária excedidos"
+// @Failure      503  {object}  models.APIError  "Integração não configurada"
+// @Router       /integrations/github [post]
+func (h *IntegrationHandler) GitHub(c *gin.Context) {
+	secret := h.config.GitHub.Secret()
+	if secret == "" {
+		c.JSON(http.StatusServiceUnavailable, models.APIError{
+			Code:    http.StatusServiceUnavailable,
+			Message: "Integração com o GitHub não configurada",
+			Details: "Defina " + h.config.GitHub.SecretEnv,
+		})
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		h.invalidPayload(c, err)
+		return
+	}
+	if !integrations.VerifyGitHubSignature(secret, body, c.GetHeader("X-Hub-Signature-256")) {
+		c.JSON(http.StatusUnauthorized, models.APIError{
+			Code:    http.StatusUnauthorized,
+			Message: "Assinatura inválida",
+			Details: "O header X-Hub-Signature-256 não confere com o segredo do webhook",
+		})
+		return
+	}
+
+	eventType := c.GetHeader("X-GitHub-Event")
+	if eventType == "ping" {
+		c.JSON(http.StatusOK, models.IntegrationResult{})
+		return
+	}
+
+	var event models.GitHubWorkflowEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		h.invalidPayload(c, err)
+		return
+	}
+
+	target, item := integrations.GitHubTargetFor(eventType, event)
+	result := models.IntegrationResult{Received: 1}
+	if item.Status != "" {
+		metrics.IntegrationEvents.WithLabelValues("github", item.Status).Inc()
+		addItem(&result, item)
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	item.Domain = h.config.GitHub.Domain
+	ctx, release, apiErr := h.admit(c, c.Request.Context(), item)
+	if apiErr != nil {
+		c.JSON(apiErr.Code, apiErr)
+		return
+	}
+
+	// O GitHub espera resposta em até 10s; logs e análise seguem em segundo plano
+	item.Status = integrations.StatusQueued
+	metrics.IntegrationEvents.WithLabelValues("github", item.Status).Inc()
+	go func() {
+		defer release()
+		h.processGitHub(context.WithoutCancel(ctx), target)
+	}()
+
+	result.Queued = 1
+	result.Items = append(result.Items, item)
+	c.JSON(http.StatusAccepted, result)
+}
+
+// processGitHub analisa os jobs com falha de uma execução e comenta o
+// diagnóstico conforme a configuração
+func (h *IntegrationHandler) processGitHub(ctx context.Context, target integrations.GitHubTarget) {
+	ctx, span := tracing.Start(ctx, "IntegrationHandler.processGitHub",
+		attribute.String("hefestus.github.repo", target.Repo),
+		attribute.Int64("hefestus.github.run_id", target.RunID))
+	defer span.End()
+
+	logger := logging.FromContext(ctx).With("repo", target.Repo, "run_id", target.RunID)
+
+	jobs := []github.Job{}
+	if target.Job != nil {
+		jobs = append(jobs, *target.Job)
+	} else {
+		failed, err := h.githubClient.FailedJobs(ctx, target.Repo, target.RunID)
+		if err != nil {
+			tracing.RecordError(span, err)
+			logger.Error("falha ao listar jobs da execução", "error", err)
+			return
+		}
+		jobs = failed
+	}
+
+	for _, job := range jobs {
+		logs, err := h.githubClient.JobLogs(ctx, target.Repo, job.ID)
+		if err != nil {
+			logger.Error("falha ao baixar log do job", "job_id", job.ID, "error", err)
+			metrics.IntegrationEvents.WithLabelValues("github", integrations.StatusFailed).Inc()
+			continue
+		}
+
+		item := models.IntegrationItem{ID: strconv.FormatInt(job.ID, 10), Name: job.Name, Domain: h.config.GitHub.Domain}
+		item = h.analyze(ctx, "github", item, h.config.GitHub.GitHubRequest(target, job, logs))
+		if item.Status != integrations.StatusAnalyzed {
+			logger.Warn("job do GitHub não analisado", "job_id", job.ID, "reason", item.Reason)
+			continue
+		}
+
+		logger.Info("job do GitHub analisado", "job_id", job.ID, "causa", item.Response.Error.Causa)
+		if err := h.commentGitHub(ctx, target, integrations.GitHubComment(target, job, item.Response)); err != nil {
+			logger.Warn("falha ao comentar diagnóstico no GitHub", "job_id", job.ID, "error", err)
+		}
+	}
+}
+
+// commentGitHub publica o comentário no pull request ou no commit
+func (h *IntegrationHandler) commentGitHub(ctx context.Context, target integrations.GitHubTarget, comment string) error {
+	mode := h.config.GitHub.Comment
+	if mode == integrations.CommentNone {
+		return nil
+	}
+
+	if mode == integrations.CommentPullRequest || mode == integrations.CommentAuto {
+		pulls := target.PullRequests
+		if len(pulls) == 0 {
+			found, err := h.githubClient.PullRequestsForCommit(ctx, target.Repo, target.SHA)
+			if err != nil {
+				return err
+			}
+			pulls = found
+		}
+		if len(pulls) > 0 {
+			return h.githubClient.CreateIssueComment(ctx, target.Repo, pulls[0], comment)
+		}
+		if mode == integrations.CommentPullRequest {
+			return nil
+		}
+	}
+
+	return h.githubClient.CreateCommitComment(ctx, target.Repo, target.SHA, comment)
+}
+
+// ArgoCD analisa uma notificação do notifications-controller do Argo CD
+// @Summary      Receber notificações do Argo CD
+// @Description  Recebe o webhook do notifications-controller (triggers on-sync-failed e on-health-degraded) com a Application serializada e analisa as mensagens da operação, das condições e dos recursos degradados
+// @Tags         integrations
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        X-Tenant-ID   header  string                     false "Tenant cujas configurações e dicionários serão usados"
+// @Param        notification  body    models.ArgoCDNotification  true  "Notificação do Argo CD"
+// @Success      200  {object}  models.IntegrationResult
+// @Failure      400  {object}  models.APIError  "Payload inválido"
+// @Failure      401  {object}  models.APIError  "Credenciais ausentes ou inválidas"
+// @Failure      403  {object}  models.APIError  "Escopo integrations:argocd ausente"
+// @Failure      429  {object}  models.APIError  "Limite de requisições ou cota diária excedidos"
+// @Router       /integrations/argocd [post]
+func (h *IntegrationHandler) ArgoCD(c *gin.Context) {
+	var notification models.ArgoCDNotification
+	if err := c.ShouldBindJSON(&notification); err != nil {
+		h.invalidPayload(c, err)
+		return
+	}
+
+	ctx, span := tracing.Start(c.Request.Context(), "IntegrationHandler.ArgoCD",
+		attribute.String("hefestus.argocd.application", notification.App.Metadata.Name))
+	defer span.End()
+
+	item, request := h.config.ArgoCD.ArgoCDRequest(notification)
+	ctx, release, apiErr := h.admit(c, ctx, item)
+	if apiErr != nil {
+		c.JSON(apiErr.Code, apiErr)
+		return
+	}
+	result := models.IntegrationResult{Received: 1}
+	addItem(&result, h.analyze(ctx, "argocd", item, request))
+	release()
+	c.JSON(http.StatusOK, result)
+}
+
+// admit aplica o limite do domínio de um item a ser analisado; itens já
+// resolvidos (ignorados) não consomem limite nem cota
+func (h *IntegrationHandler) admit(c *gin.Context, ctx context.Context, item models.IntegrationItem) (context.Context, func(), *models.APIError) {
+	if item.Status != "" || h.limiter == nil {
+		return ctx, func() {}, nil
+	}
+	ctx, release, apiErr := h.limiter.Admit(c, ctx, item.Domain)
+	if apiErr != nil {
+		return ctx, func() {}, apiErr
+	}
+	return ctx, release, nil
+}
+
+// analyze processa um item já convertido, registrando o resultado nas métricas
+func (h *IntegrationHandler) analyze(ctx context.Context, source string, item models.IntegrationItem, request models.ErrorRequest) models.IntegrationItem {
+	defer func() {
+		metrics.IntegrationEvents.WithLabelValues(source, item.Status).Inc()
+	}()
+
+	if item.Status != "" {
+		return item
+	}
+
+	if _, ok := h.dictService.GetDomainConfig(ctx, item.Domain); !ok {
+		item.Status = integrations.StatusFailed
+		item.Reason = "domínio desconhecido: " + item.Domain
+		return item
+	}
+
+	response, err := h.errorService.ProcessError(ctx, item.Domain, request)
+	if err != nil {
+		logging.FromContext(ctx).Warn("falha ao analisar item de integração",
+			"source", source, "item", item.ID, "domain", item.Domain, "error", err)
+		item.Status = integrations.StatusFailed
+		item.Reason = err.Error()
+		return item
+	}
+
+	item.Status = integrations.StatusAnalyzed
+	item.Response = response
+	return item
+}
+
+// queued marca o item como enfileirado para análise em segundo plano
+func queued(source string, item models.IntegrationItem) models.IntegrationItem {
+	item.Status = integrations.StatusQueued
+	metrics.IntegrationEvents.WithLabelValues(source, item.Status).Inc()
+	return item
+}
+
+// addItem contabiliza um item no resumo conforme o seu estado
+func addItem(result *models.IntegrationResult, item models.IntegrationItem) {
+	switch item.Status {
+	case integrations.StatusAnalyzed:
+		result.Analyzed++
+	case integrations.StatusIgnored:
+		result.Ignored++
+	default:
+		result.Failed++
+	}
+	result.Items = append(result.Items, item)
+}
+
+func (h *IntegrationHandler) invalidPayload(c *gin.Context, err error) {
+	c.JSON(http.StatusBadRequest, models.APIError{
+		Code:    http.StatusBadRequest,
+		Message: "Payload inválido",
+		Details: err.Error(),
+	})
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"hefestus-api/internal/integrations"
+	"hefestus-api/internal/models"
+	"hefestus-api/pkg/github"
+	"hefestus-api/pkg/ollama/ollamatest"
+	"hefestus-api/pkg/zabbix"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newIntegrationRouter monta as rotas do Alertmanager e do Zabbix. O Ollama
+// falso só responde depois de unblock, para provar que o webhook é respondido
+// antes da análise
+func newIntegrationRouter(t *testing.T, config *integrations.Config) (r *gin.Engine, srv *ollamatest.Server, unblock func()) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	errorService, dictService, srv := newTestErrorService(t)
+	release := make(chan struct{})
+	unblock = sync.OnceFunc(func() { close(release) })
+	// Roda antes de srv.Close, que espera as requisições em andamento
+	t.Cleanup(unblock)
+	srv.SetHandler(func(ollamatest.Request) ollamatest.Response {
+		<-release
+		return ollamatest.Diagnosis("Container reiniciando", "kubectl logs api --previous")
+	})
+
+	handler := NewIntegrationHandler(errorService, dictService, config, zabbix.NewClient(), github.NewClient(), nil)
+	r = gin.New()
+	r.POST("/api/integrations/alertmanager", handler.Alertmanager)
+	r.POST("/api/integrations/zabbix", handler.Zabbix)
+	return r, srv, unblock
+}
+
+func decodeResult(t *testing.T, w *httptest.ResponseRecorder) models.IntegrationResult {
+	t.Helper()
+	var result models.IntegrationResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("invalid response %s: %v", w.Body, err)
+	}
+	return result
+}
+
+// waitFor espera a condição por até 5s
+func waitFor(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestAlertmanagerAnalyzesInBackground(t *testing.T) {
+	r, srv, unblock := newIntegrationRouter(t, &integrations.Config{
+		Alertmanager: integrations.AlertmanagerConfig{DomainLabel: "hefestus_domain", ErrorAnnotations: []string{"description"}},
+	})
+
+	w := serve(r, "/api/integrations/alertmanager", `{
+		"status": "firing",
+		"alerts": [
+			{"status": "firing", "fingerprint": "a1", "labels": {"alertname": "KubePodCrashLooping", "hefestus_domain": "kubernetes"}, "annotations": {"description": "Pod api in CrashLoopBackOff"}},
+			{"status": "resolved", "fingerprint": "a2", "labels": {"alertname": "KubePodNotReady", "hefestus_domain": "kubernetes"}}
+		]
+	}`)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("status %d while the model is still answering, want 202: %s", w.Code, w.Body)
+	}
+	result := decodeResult(t, w)
+	if result.Received != 2 || result.Queued != 1 || result.Ignored != 1 || result.Analyzed != 0 {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if len(result.Items) != 2 || result.Items[0].Status != integrations.StatusQueued || result.Items[1].Status != integrations.StatusIgnored {
+		t.Errorf("unexpected items %+v", result.Items)
+	}
+
+	waitFor(t, "the background analysis", func() bool { return len(srv.Requests()) == 1 })
+	if prompt := srv.Requests()[0].Prompt; !strings.Contains(prompt, "CrashLoopBackOff") {
+		t.Errorf("prompt without the alert description:\n%s", prompt)
+	}
+	unblock()
+}
+
+func TestAlertmanagerWithoutFiringAlerts(t *testing.T) {
+	r, srv, _ := newIntegrationRouter(t, &integrations.Config{})
+
+	w := serve(r, "/api/integrations/alertmanager", `{"status": "resolved", "alerts": [{"status": "resolved", "fingerprint": "a1"}]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200", w.Code)
+	}
+	if result := decodeResult(t, w); result.Ignored != 1 || result.Queued != 0 {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if len(srv.Requests()) != 0 {
+		t.Errorf("Ollama called for resolved alerts")
+	}
+}
+
+func TestZabbixAcknowledgesInBackground(t *testing.T) {
+	acknowledged := make(chan string, 1)
+	zabbixServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		acknowledged <- string(body)
+		_, _ = io.WriteString(w, `{"jsonrpc": "2.0", "result": {"eventids": [4211]}, "id": 1}`)
+	}))
+	t.Cleanup(zabbixServer.Close)
+	t.Setenv("ZABBIX_URL", zabbixServer.URL)
+	t.Setenv("ZABBIX_API_TOKEN", "token")
+
+	r, _, unblock := newIntegrationRouter(t, &integrations.Config{
+		Zabbix: integrations.ZabbixConfig{DomainTag: "hefestus_domain", Acknowledge: true},
+	})
+
+	w := serve(r, "/api/integrations/zabbix", `{
+		"event_id": "4211",
+		"event_name": "Pod api in CrashLoopBackOff",
+		"event_value": "1",
+		"tags": "hefestus_domain:kubernetes",
+		"item_value": "Back-off restarting failed container"
+	}`)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("status %d while the model is still answering, want 202: %s", w.Code, w.Body)
+	}
+	if result := decodeResult(t, w); result.Queued != 1 || len(result.Items) != 1 || result.Items[0].Status != integrations.StatusQueued {
+		t.Errorf("unexpected result %+v", result)
+	}
+
+	unblock()
+	select {
+	case body := <-acknowledged:
+		if !strings.Contains(body, `"event.acknowledge"`) || !strings.Contains(body, "Container reiniciando") {
+			t.Errorf("unexpected acknowledge %s", body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("problem not acknowledged after the analysis")
+	}
+}
+
+func TestZabbixIgnoresRecovery(t *testing.T) {
+	r, srv, _ := newIntegrationRouter(t, &integrations.Config{})
+
+	w := serve(r, "/api/integrations/zabbix", `{"event_id": "4212", "event_value": "0"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200", w.Code)
+	}
+	if result := decodeResult(t, w); result.Ignored != 1 || result.Queued != 0 {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if len(srv.Requests()) != 0 {
+		t.Errorf("Ollama called for a recovery event")
+	}
+}
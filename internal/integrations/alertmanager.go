@@ -0,0 +1,141 @@
+package integrations
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"hefestus-api/internal/models"
+)
+
+// AlertmanagerConfig define como alertas viram requisições de análise
+type AlertmanagerConfig struct {
+	// DomainLabel é o label (ou anotação) que escolhe o domínio explicitamente
+	DomainLabel string `json:"domain_label"`
+	// DefaultDomain é usado quando nenhum label ou rota define o domínio
+	DefaultDomain string  `json:"default_domain"`
+	Routes        []Route `json:"routes"`
+	// ErrorAnnotations são as anotações usadas como descrição do erro, em ordem de preferência
+	ErrorAnnotations []string                  `json:"error_annotations"`
+	Notify           []models.NotificationSink `json:"notify"`
+}
+
+func (c *AlertmanagerConfig) applyDefaults() {
+	if c.DomainLabel == "" {
+		c.DomainLabel = "hefestus_domain"
+	}
+	if len(c.ErrorAnnotations) == 0 {
+		c.ErrorAnnotations = []string{"description", "message", "summary"}
+	}
+}
+
+// AlertRequest é a análise planejada para um alerta
+type AlertRequest struct {
+	Item    models.IntegrationItem
+	Request models.ErrorRequest
+}
+
+// Requests converte os alertas do payload; alertas resolvidos ou sem domínio
+// voltam já marcados como ignorados
+func (c AlertmanagerConfig) Requests(payload models.AlertmanagerWebhook) []AlertRequest {
+	requests := make([]AlertRequest, 0, len(payload.Alerts))
+	for _, alert := range payload.Alerts {
+		labels := merge(payload.CommonLabels, alert.Labels)
+		annotations := merge(payload.CommonAnnotations, alert.Annotations)
+
+		item := models.IntegrationItem{
+			ID:   alert.Fingerprint,
+			Name: labels["alertname"],
+		}
+
+		if alert.Status == "resolved" {
+			item.Status = StatusIgnored
+			item.Reason = "alerta resolvido"
+			requests = append(requests, AlertRequest{Item: item})
+			continue
+		}
+
+		domain, notify := c.route(labels, annotations)
+		if domain == "" {
+			item.Status = StatusIgnored
+			item.Reason = "nenhum domínio associado ao alerta"
+			requests = append(requests, AlertRequest{Item: item})
+			continue
+		}
+		item.Domain = domain
+
+		requests = append(requests, AlertRequest{
+			Item: item,
+			Request: models.ErrorRequest{
+				ErrorDetails:     c.errorDetails(item.Name, annotations),
+				Context:          alertContext(labels, alert),
+				ConfiguredNotify: notify,
+			},
+		})
+	}
+	return requests
+}
+
+// route escolhe o domínio pelo label explícito, pela primeira rota que casar
+// ou pelo domínio padrão
+func (c AlertmanagerConfig) route(labels map[string]string, annotations map[string]string) (string, []models.NotificationSink) {
+	notify := append([]models.NotificationSink(nil), c.Notify...)
+
+	if domain := labels[c.DomainLabel]; domain != "" {
+		return domain, notify
+	}
+	if domain := annotations[c.DomainLabel]; domain != "" {
+		return domain, notify
+	}
+	for _, route := range c.Routes {
+		if route.matches(labels) {
+			return route.Domain, append(notify, route.Notify...)
+		}
+	}
+	return c.DefaultDomain, notify
+}
+
+func (c AlertmanagerConfig) errorDetails(name string, annotations map[string]string) string {
+	for _, key := range c.ErrorAnnotations {
+		if text := strings.TrimSpace(annotations[key]); text != "" {
+			return fmt.Sprintf("[%s] %s", name, text)
+		}
+	}
+	return name
+}
+
+// alertContext descreve os labels e a origem do alerta
+func alertContext(labels map[string]string, alert models.Alert) string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+labels[key])
+	}
+
+	context := "Alerta do Prometheus Alertmanager. Labels: " + strings.Join(pairs, ", ")
+	if !alert.StartsAt.IsZero() {
+		context += ". Disparado em " + alert.StartsAt.UTC().Format(time.RFC3339)
+	}
+	if alert.GeneratorURL != "" {
+		context += ". Origem: " + alert.GeneratorURL
+	}
+	return context
+}
+
+// merge combina os valores comuns do grupo com os do alerta, que prevalecem
+func merge(common map[string]string, specific map[string]string) map[string]string {
+	merged := make(map[string]string, len(common)+len(specific))
+	for key, value := range common {
+		merged[key] = value
+	}
+	for key, value := range specific {
+		merged[key] = value
+	}
+	return merged
+}
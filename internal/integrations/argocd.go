@@ -0,0 +1,84 @@
+package integrations
+
+import (
+	"fmt"
+	"strings"
+
+	"hefestus-api/internal/models"
+)
+
+// ArgoCDConfig define como notificações do Argo CD são analisadas
+type ArgoCDConfig struct {
+	Domain string                    `json:"domain"`
+	Notify []models.NotificationSink `json:"notify"`
+}
+
+func (c *ArgoCDConfig) applyDefaults() {
+	if c.Domain == "" {
+		c.Domain = "argocd"
+	}
+}
+
+// ArgoCDRequest converte a notificação usando as mensagens da operação, das
+// condições e dos recursos com falha ou degradados; aplicações sem problema
+// voltam com o item marcado como ignorado
+func (c ArgoCDConfig) ArgoCDRequest(notification models.ArgoCDNotification) (models.IntegrationItem, models.ErrorRequest) {
+	app := notification.App
+	status := app.Status
+	item := models.IntegrationItem{
+		ID:     strings.TrimPrefix(app.Metadata.Namespace+"/"+app.Metadata.Name, "/"),
+		Name:   app.Metadata.Name,
+		Domain: c.Domain,
+	}
+
+	var problems []string
+	if op := status.OperationState; op != nil && (op.Phase == "Failed" || op.Phase == "Error") {
+		problems = append(problems, fmt.Sprintf("Operação de sync %s: %s", op.Phase, op.Message))
+		if op.SyncResult != nil {
+			for _, result := range op.SyncResult.Resources {
+				if result.Status == "SyncFailed" || result.HookPhase == "Failed" || result.HookPhase == "Error" {
+					problems = append(problems, fmt.Sprintf("%s: %s %s",
+						resourceName(result.Kind, result.Namespace, result.Name), firstNonEmpty(result.Status, result.HookPhase), result.Message))
+				}
+			}
+		}
+	}
+	for _, condition := range status.Conditions {
+		if strings.HasSuffix(condition.Type, "Error") {
+			problems = append(problems, fmt.Sprintf("%s: %s", condition.Type, condition.Message))
+		}
+	}
+	for _, resource := range status.Resources {
+		if resource.Health != nil && (resource.Health.Status == "Degraded" || resource.Health.Status == "Missing") {
+			problems = append(problems, fmt.Sprintf("%s: health %s %s",
+				resourceName(resource.Kind, resource.Namespace, resource.Name), resource.Health.Status, resource.Health.Message))
+		}
+	}
+
+	if len(problems) == 0 && status.Health.Status != "Degraded" {
+		item.Status = StatusIgnored
+		item.Reason = "aplicação sem falhas de sync ou recursos degradados"
+		return item, models.ErrorRequest{}
+	}
+
+	header := fmt.Sprintf("Application %s: sync %s, health %s", app.Metadata.Name, status.Sync.Status, status.Health.Status)
+	if status.Health.Message != "" {
+		header += " (" + status.Health.Message + ")"
+	}
+
+	return item, models.ErrorRequest{
+		ErrorDetails: strings.TrimSpace(header + "\n" + strings.Join(problems, "\n")),
+		Context: fmt.Sprintf("Argo CD: application %q do projeto %s, destino %s namespace %s, repositório %s path %s revisão %s. Trigger: %s",
+			app.Metadata.Name, app.Spec.Project, app.Spec.Destination.Server, app.Spec.Destination.Namespace,
+			app.Spec.Source.RepoURL, app.Spec.Source.Path, firstNonEmpty(status.Sync.Revision, app.Spec.Source.TargetRevision),
+			notification.Trigger),
+		ConfiguredNotify: append([]models.NotificationSink(nil), c.Notify...),
+	}
+}
+
+func resourceName(kind string, namespace string, name string) string {
+	if namespace == "" {
+		return kind + "/" + name
+	}
+	return kind + "/" + namespace + "/" + name
+}
@@ -0,0 +1,115 @@
+// Package integrations converte eventos de ferramentas externas (Alertmanager,
+// Zabbix, GitHub, Argo CD...) em requisições de análise.
+package integrations
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"hefestus-api/internal/models"
+	"hefestus-api/internal/notifier"
+)
+
+// Estados de cada item de um evento de integração
+const (
+	StatusAnalyzed = "analyzed"
+	StatusIgnored  = "ignored"
+	StatusFailed   = "failed"
+	StatusQueued   = "queued"
+)
+
+// Config agrupa a configuração de cada integração (config/integrations.json)
+type Config struct {
+	Alertmanager AlertmanagerConfig `json:"alertmanager"`
+	Zabbix       ZabbixConfig       `json:"zabbix"`
+	GitHub       GitHubConfig       `json:"github"`
+	ArgoCD       ArgoCDConfig       `json:"argocd"`
+}
+
+// Route associa os itens cujos labels contêm todos os pares de Match a um
+// domínio e, opcionalmente, a destinos de notificação
+type Route struct {
+	Match  map[string]string         `json:"match"`
+	Domain string                    `json:"domain"`
+	Notify []models.NotificationSink `json:"notify"`
+}
+
+// matches informa se todos os labels da rota estão presentes com o mesmo valor
+func (r Route) matches(labels map[string]string) bool {
+	for key, value := range r.Match {
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// LoadConfig lê INTEGRATIONS_CONFIG (padrão config/integrations.json); sem o
+// arquivo, cada integração usa seus valores padrão
+func LoadConfig() (*Config, error) {
+	path := os.Getenv("INTEGRATIONS_CONFIG")
+	if path == "" {
+		path = "config/integrations.json"
+	}
+
+	config := &Config{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		config.applyDefaults()
+		return config, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read integrations config: %w", err)
+	}
+
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse integrations config: %w", err)
+	}
+	config.applyDefaults()
+
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func (c *Config) applyDefaults() {
+	c.Alertmanager.applyDefaults()
+	c.Zabbix.applyDefaults()
+	c.GitHub.applyDefaults()
+	c.ArgoCD.applyDefaults()
+}
+
+func (c *Config) validate() error {
+	if err := validateRoutes("alertmanager", c.Alertmanager.Notify, c.Alertmanager.Routes); err != nil {
+		return err
+	}
+	if err := validateRoutes("zabbix", c.Zabbix.Notify, c.Zabbix.Routes); err != nil {
+		return err
+	}
+	if err := c.GitHub.validate(); err != nil {
+		return err
+	}
+	return validateRoutes("argocd", c.ArgoCD.Notify, nil)
+}
+
+func validateRoutes(integration string, notify []models.NotificationSink, routes []Route) error {
+	for _, sink := range notify {
+		if err := notifier.ValidateSink(sink); err != nil {
+			return fmt.Errorf("invalid notification for %s: %w", integration, err)
+		}
+	}
+	for i, route := range routes {
+		if route.Domain == "" {
+			return fmt.Errorf("%s route %d: domain cannot be empty", integration, i)
+		}
+		for _, sink := range route.Notify {
+			if err := notifier.ValidateSink(sink); err != nil {
+				return fmt.Errorf("invalid notification for %s route %d: %w", integration, i, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package integrations
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"hefestus-api/internal/models"
+	"hefestus-api/pkg/github"
+)
+
+// Destinos do comentário com o diagnóstico no GitHub
+const (
+	CommentNone        = ""
+	CommentCommit      = "commit"
+	CommentPullRequest = "pull_request"
+	// CommentAuto comenta no pull request aberto do commit ou, sem ele, no commit
+	CommentAuto = "auto"
+)
+
+// GitHubConfig define como execuções com falha do GitHub Actions são analisadas
+type GitHubConfig struct {
+	Domain string `json:"domain"`
+	// SecretEnv é a variável de ambiente com o segredo do webhook
+	SecretEnv string                    `json:"secret_env"`
+	Comment   string                    `json:"comment"`
+	Notify    []models.NotificationSink `json:"notify"`
+}
+
+func (c *GitHubConfig) applyDefaults() {
+	if c.Domain == "" {
+		c.Domain = "github"
+	}
+	if c.SecretEnv == "" {
+		c.SecretEnv = "GITHUB_WEBHOOK_SECRET"
+	}
+}
+
+func (c GitHubConfig) validate() error {
+	switch c.Comment {
+	case CommentNone, CommentCommit, CommentPullRequest, CommentAuto:
+	default:
+		return fmt.Errorf("invalid github comment target: %q", c.Comment)
+	}
+	return validateRoutes("github", c.Notify, nil)
+}
+
+// Secret retorna o segredo do webhook; vazio desativa a integração
+func (c GitHubConfig) Secret() string {
+	return os.Getenv(c.SecretEnv)
+}
+
+// VerifyGitHubSignature confere o header X-Hub-Signature-256 do corpo recebido
+func VerifyGitHubSignature(secret string, body []byte, signature string) bool {
+	expected, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal([]byte(expected), []byte(hex.EncodeToString(mac.Sum(nil))))
+}
+
+// GitHubTarget é uma execução ou um job com falha a ser analisado
+type GitHubTarget struct {
+	Repo         string
+	RunID        int64
+	Workflow     string
+	SHA          string
+	Branch       string
+	URL          string
+	PullRequests []int
+	// Job é preenchido nos eventos workflow_job; em workflow_run os jobs com
+	// falha são consultados na API
+	Job *github.Job
+}
+
+// GitHubTargetFor interpreta um evento; eventos que não são falhas concluídas
+// voltam com o item já marcado como ignorado
+func GitHubTargetFor(eventType string, event models.GitHubWorkflowEvent) (GitHubTarget, models.IntegrationItem) {
+	target := GitHubTarget{Repo: event.Repository.FullName}
+	var item models.IntegrationItem
+	var conclusion string
+
+	switch {
+	case eventType == "workflow_run" && event.WorkflowRun != nil:
+		run := event.WorkflowRun
+		item = models.IntegrationItem{ID: strconv.FormatInt(run.ID, 10), Name: run.Name}
+		conclusion = run.Conclusion
+		target.RunID = run.ID
+		target.Workflow = run.Name
+		target.SHA = run.HeadSHA
+		target.Branch = run.HeadBranch
+		target.URL = run.HTMLURL
+		for _, pull := range run.PullRequests {
+			target.PullRequests = append(target.PullRequests, pull.Number)
+		}
+	case eventType == "workflow_job" && event.WorkflowJob != nil:
+		job := event.WorkflowJob
+		item = models.IntegrationItem{ID: strconv.FormatInt(job.ID, 10), Name: job.WorkflowName + " / " + job.Name}
+		conclusion = job.Conclusion
+		target.RunID = job.RunID
+		target.Workflow = job.WorkflowName
+		target.SHA = job.HeadSHA
+		target.Branch = job.HeadBranch
+		target.URL = job.HTMLURL
+		target.Job = &github.Job{
+			ID:           job.ID,
+			RunID:        job.RunID,
+			Name:         job.Name,
+			Conclusion:   job.Conclusion,
+			HTMLURL:      job.HTMLURL,
+			HeadSHA:      job.HeadSHA,
+			HeadBranch:   job.HeadBranch,
+			WorkflowName: job.WorkflowName,
+		}
+		for _, step := range job.Steps {
+			target.Job.Steps = append(target.Job.Steps, github.Step{Name: step.Name, Number: step.Number, Conclusion: step.Conclusion})
+		}
+	default:
+		item.Status = StatusIgnored
+		item.Reason = "evento não suportado: " + eventType
+		return target, item
+	}
+
+	switch {
+	case event.Action != "completed":
+		item.Status = StatusIgnored
+		item.Reason = "execução não concluída"
+	case conclusion != "failure" && conclusion != "timed_out":
+		item.Status = StatusIgnored
+		item.Reason = "conclusão " + conclusion
+	}
+	return target, item
+}
+
+// GitHubRequest monta a análise de um job com falha a partir do seu log
+func (c GitHubConfig) GitHubRequest(target GitHubTarget, job github.Job, logs string) models.ErrorRequest {
+	var failedSteps []string
+	for _, step := range job.Steps {
+		if step.Conclusion == "failure" || step.Conclusion == "timed_out" {
+			failedSteps = append(failedSteps, step.Name)
+		}
+	}
+
+	details := logs
+	if len(failedSteps) > 0 {
+		details = "Passos com falha: " + strings.Join(failedSteps, ", ") + "\n" + logs
+	}
+
+	return models.ErrorRequest{
+		ErrorDetails: details,
+		Context: fmt.Sprintf("GitHub Actions: workflow %q, job %q no repositório %s, branch %s, commit %s. Execução: %s",
+			target.Workflow, job.Name, target.Repo, target.Branch, target.SHA, job.HTMLURL),
+		ConfiguredNotify: append([]models.NotificationSink(nil), c.Notify...),
+	}
+}
+
+// GitHubComment formata o diagnóstico de um job como comentário em Markdown
+func GitHubComment(target GitHubTarget, job github.Job, response *models.ErrorResponse) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<!-- hefestus -->\n### 🔧 Hefestus: [%s / %s](%s)\n\n", target.Workflow, job.Name, job.HTMLURL)
+	fmt.Fprintf(&b, "**Causa:** %s\n\n**Passos sugeridos:**\n\n```\n%s\n```\n", response.Error.Causa, response.Error.Solucao)
+	if len(response.Error.References) > 0 {
+		b.WriteString("\n**Referências:**\n\n")
+		for _, reference := range response.Error.References {
+			fmt.Fprintf(&b, "- %s\n", reference)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,162 @@
+package integrations
+
+import (
+	"fmt"
+	"strings"
+
+	"hefestus-api/internal/models"
+)
+
+// ZabbixConfig define como eventos do Zabbix viram requisições de análise
+type ZabbixConfig struct {
+	// DomainTag é a tag do evento que escolhe o domínio explicitamente
+	DomainTag     string `json:"domain_tag"`
+	DefaultDomain string `json:"default_domain"`
+	// Routes casam as tags do evento (nome:valor)
+	Routes []Route `json:"routes"`
+	// HostGroups associa grupos de hosts a domínios, consultados após as rotas
+	HostGroups map[string]string `json:"host_groups"`
+	// Acknowledge registra o diagnóstico como comentário do problema via API do Zabbix
+	Acknowledge bool                      `json:"acknowledge"`
+	Notify      []models.NotificationSink `json:"notify"`
+}
+
+func (c *ZabbixConfig) applyDefaults() {
+	if c.DomainTag == "" {
+		c.DomainTag = "hefestus_domain"
+	}
+}
+
+// ZabbixRequest é a análise planejada para um evento
+type ZabbixRequest struct {
+	Item    models.IntegrationItem
+	Request models.ErrorRequest
+}
+
+// Request converte o evento; recuperações, atualizações (inclusive os
+// comentários feitos pelo próprio Hefestus) e eventos sem domínio voltam já
+// marcados como ignorados
+func (c ZabbixConfig) Request(event models.ZabbixEvent) ZabbixRequest {
+	item := models.IntegrationItem{
+		ID:   event.EventID,
+		Name: firstNonEmpty(event.EventName, event.TriggerName),
+	}
+
+	switch {
+	case event.EventValue == "0":
+		item.Status = StatusIgnored
+		item.Reason = "evento de recuperação"
+		return ZabbixRequest{Item: item}
+	case event.EventUpdateStatus == "1":
+		item.Status = StatusIgnored
+		item.Reason = "atualização de problema"
+		return ZabbixRequest{Item: item}
+	}
+
+	tags := splitTags(event.Tags)
+	domain, notify := c.route(tags, splitList(event.HostGroups))
+	if domain == "" {
+		item.Status = StatusIgnored
+		item.Reason = "nenhum domínio associado ao evento"
+		return ZabbixRequest{Item: item}
+	}
+	item.Domain = domain
+
+	return ZabbixRequest{
+		Item: item,
+		Request: models.ErrorRequest{
+			ErrorDetails:     zabbixErrorDetails(event),
+			Context:          zabbixContext(event),
+			ConfiguredNotify: notify,
+		},
+	}
+}
+
+// route escolhe o domínio pela tag explícita, pela primeira rota que casar
+// com as tags, pelo primeiro grupo de hosts mapeado ou pelo domínio padrão
+func (c ZabbixConfig) route(tags map[string]string, groups []string) (string, []models.NotificationSink) {
+	notify := append([]models.NotificationSink(nil), c.Notify...)
+
+	if domain := tags[c.DomainTag]; domain != "" {
+		return domain, notify
+	}
+	for _, route := range c.Routes {
+		if route.matches(tags) {
+			return route.Domain, append(notify, route.Notify...)
+		}
+	}
+	for _, group := range groups {
+		if domain := c.HostGroups[group]; domain != "" {
+			return domain, notify
+		}
+	}
+	return c.DefaultDomain, notify
+}
+
+func zabbixErrorDetails(event models.ZabbixEvent) string {
+	var lines []string
+	lines = append(lines, fmt.Sprintf("[%s] %s", event.Severity, firstNonEmpty(event.EventName, event.TriggerName)))
+	if event.TriggerDescription != "" {
+		lines = append(lines, event.TriggerDescription)
+	}
+	if event.ItemValue != "" {
+		lines = append(lines, fmt.Sprintf("%s: %s", firstNonEmpty(event.ItemName, "Valor"), event.ItemValue))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func zabbixContext(event models.ZabbixEvent) string {
+	context := fmt.Sprintf("Problema do Zabbix no host %s (severidade %s, trigger %q)",
+		event.Host, event.Severity, event.TriggerName)
+	if event.HostGroups != "" {
+		context += ". Grupos: " + event.HostGroups
+	}
+	if event.Tags != "" {
+		context += ". Tags: " + event.Tags
+	}
+	return context
+}
+
+// ZabbixComment formata o diagnóstico como comentário de reconhecimento
+func ZabbixComment(domain string, response *models.ErrorResponse) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Hefestus (%s): %s\n", domain, response.Error.Causa)
+	for _, step := range strings.Split(response.Error.Solucao, "\n") {
+		if step = strings.TrimSpace(step); step != "" {
+			fmt.Fprintf(&b, "- %s\n", step)
+		}
+	}
+	for _, reference := range response.Error.References {
+		fmt.Fprintf(&b, "Ref: %s\n", reference)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// splitTags converte "nome:valor,nome2:valor2" ({EVENT.TAGS}) em mapa
+func splitTags(raw string) map[string]string {
+	tags := make(map[string]string)
+	for _, tag := range splitList(raw) {
+		name, value, _ := strings.Cut(tag, ":")
+		tags[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return tags
+}
+
+func splitList(raw string) []string {
+	var values []string
+	for _, value := range strings.Split(raw, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
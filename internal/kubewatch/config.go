@@ -0,0 +1,120 @@
+// Package kubewatch observa o cluster Kubernetes com informers e analisa
+// automaticamente pods com falha, sem depender de alguém chamar a API.
+package kubewatch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"hefestus-api/internal/models"
+	"hefestus-api/internal/notifier"
+)
+
+// Motivos de falha detectados pelo watcher
+const (
+	ReasonCrashLoopBackOff = "CrashLoopBackOff"
+	ReasonImagePullBackOff = "ImagePullBackOff"
+	ReasonErrImagePull     = "ErrImagePull"
+	ReasonOOMKilled        = "OOMKilled"
+	ReasonFailedScheduling = "FailedScheduling"
+)
+
+var defaultReasons = []string{
+	ReasonCrashLoopBackOff,
+	ReasonImagePullBackOff,
+	ReasonErrImagePull,
+	ReasonOOMKilled,
+	ReasonFailedScheduling,
+}
+
+// Config define o modo watch (config/watch.json)
+type Config struct {
+	Enabled            bool                      `json:"enabled"`
+	Domain             string                    `json:"domain"`
+	Namespaces         []string                  `json:"namespaces"`
+	Reasons            []string                  `json:"reasons"`
+	LogLines           int64                     `json:"log_lines"`
+	MaxEvents          int                       `json:"max_events"`
+	DedupWindowMinutes int                       `json:"dedup_window_minutes"`
+	Workers            int                       `json:"workers"`
+	Notify             []models.NotificationSink `json:"notify"`
+}
+
+// LoadConfig lê WATCH_CONFIG (padrão config/watch.json); sem o arquivo o modo
+// watch fica desativado
+func LoadConfig() (*Config, error) {
+	path := os.Getenv("WATCH_CONFIG")
+	if path == "" {
+		path = "config/watch.json"
+	}
+
+	config := &Config{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		config.applyDefaults()
+		return config, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read watch config: %w", err)
+	}
+
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse watch config: %w", err)
+	}
+	config.applyDefaults()
+
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func (c *Config) applyDefaults() {
+	if c.Domain == "" {
+		c.Domain = "kubernetes"
+	}
+	if len(c.Reasons) == 0 {
+		c.Reasons = defaultReasons
+	}
+	if c.LogLines <= 0 {
+		c.LogLines = 50
+	}
+	if c.MaxEvents <= 0 {
+		c.MaxEvents = 10
+	}
+	if c.DedupWindowMinutes <= 0 {
+		c.DedupWindowMinutes = 30
+	}
+	if c.Workers <= 0 {
+		c.Workers = 2
+	}
+}
+
+func (c *Config) validate() error {
+	for _, reason := range c.Reasons {
+		known := false
+		for _, r := range defaultReasons {
+			known = known || r == reason
+		}
+		if !known {
+			return fmt.Errorf("unsupported watch reason: %q", reason)
+		}
+	}
+	for _, sink := range c.Notify {
+		if err := notifier.ValidateSink(sink); err != nil {
+			return fmt.Errorf("invalid notification for watch: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c *Config) watches(reason string) bool {
+	for _, r := range c.Reasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
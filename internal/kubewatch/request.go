@@ -0,0 +1,53 @@
+package kubewatch
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"hefestus-api/internal/logging"
+	"hefestus-api/internal/models"
+	"hefestus-api/pkg/kube"
+)
+
+// buildRequest monta a requisição de análise com a falha, os eventos e os
+// últimos logs do container em error_details e a spec do pod em context.
+// Falhas ao buscar logs ou eventos não impedem a análise
+func (w *Watcher) buildRequest(ctx context.Context, d detection) models.ErrorRequest {
+	logger := logging.FromContext(ctx)
+
+	var details strings.Builder
+	fmt.Fprintf(&details, "%s: pod %s/%s", d.reason, d.pod.Namespace, d.pod.Name)
+	if d.container != "" {
+		fmt.Fprintf(&details, ", container %s", d.container)
+	}
+	if d.message != "" {
+		fmt.Fprintf(&details, ": %s", d.message)
+	}
+	details.WriteString("\n")
+
+	events, err := kube.ObjectEvents(ctx, w.client, d.pod.Namespace, "Pod", d.pod.Name, w.config.MaxEvents)
+	if err != nil {
+		logger.Warn("falha ao listar eventos do pod", "pod", d.pod.Name, "error", err)
+	}
+	if len(events) > 0 {
+		details.WriteString("\nEventos recentes:\n" + strings.Join(events, "\n") + "\n")
+	}
+
+	// Containers que não chegaram a rodar (imagem, scheduling) não têm logs
+	if d.container != "" && (d.reason == ReasonCrashLoopBackOff || d.reason == ReasonOOMKilled) {
+		logs, err := kube.PodLogs(ctx, w.client, d.pod, d.container, d.previous, w.config.LogLines)
+		if err != nil {
+			logger.Warn("falha ao ler logs do container", "pod", d.pod.Name, "container", d.container, "error", err)
+		}
+		if logs != "" {
+			fmt.Fprintf(&details, "\nLogs do container %s:\n%s\n", d.container, logs)
+		}
+	}
+
+	return models.ErrorRequest{
+		ErrorDetails: logging.Redact(strings.TrimSpace(details.String())),
+		Context: logging.Redact(fmt.Sprintf("Falha detectada pelo watcher do Kubernetes no %s %s.\n%s",
+			d.workload.Kind, d.workload.Name, kube.DescribePod(d.pod))),
+	}
+}
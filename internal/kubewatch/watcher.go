@@ -0,0 +1,269 @@
+package kubewatch
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"hefestus-api/internal/logging"
+	"hefestus-api/internal/metrics"
+	"hefestus-api/internal/models"
+	"hefestus-api/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// source identifica o watcher na métrica de eventos de integração
+const source = "kubernetes"
+
+// Analyzer é a parte do ErrorService usada pelo watcher
+type Analyzer interface {
+	ProcessError(ctx context.Context, domain string, req models.ErrorRequest) (*models.ErrorResponse, error)
+}
+
+// Workload é o controlador dono do pod (Deployment, StatefulSet, Job...) ou o
+// próprio pod quando ele não tem dono
+type Workload struct {
+	Kind string
+	Name string
+}
+
+// detection é uma falha encontrada em um pod, ainda sem contexto
+type detection struct {
+	reason    string
+	message   string
+	container string
+	// previous indica que o erro está nos logs da execução anterior do container
+	previous bool
+	pod      *corev1.Pod
+	workload Workload
+}
+
+// Watcher observa pods e eventos e analisa cada falha uma vez por workload
+// dentro da janela de deduplicação
+type Watcher struct {
+	client   kubernetes.Interface
+	analyzer Analyzer
+	config   Config
+	queue    chan detection
+
+	mu   sync.Mutex
+	seen map[string]time.Time
+	now  func() time.Time
+}
+
+// NewWatcher cria o watcher; client pode ser um fake.NewClientset nos testes
+func NewWatcher(client kubernetes.Interface, analyzer Analyzer, config Config) *Watcher {
+	config.applyDefaults()
+	return &Watcher{
+		client:   client,
+		analyzer: analyzer,
+		config:   config,
+		queue:    make(chan detection, 100),
+		seen:     make(map[string]time.Time),
+		now:      time.Now,
+	}
+}
+
+// Run inicia os informers e os workers e bloqueia até o context ser cancelado
+func (w *Watcher) Run(ctx context.Context) error {
+	namespaces := w.config.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+
+	for _, namespace := range namespaces {
+		factory := informers.NewSharedInformerFactoryWithOptions(w.client, 0, informers.WithNamespace(namespace))
+		pods := factory.Core().V1().Pods().Informer()
+		if _, err := pods.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    w.onPod,
+			UpdateFunc: func(_, obj interface{}) { w.onPod(obj) },
+		}); err != nil {
+			return fmt.Errorf("failed to watch pods: %w", err)
+		}
+		if err := startInformers(ctx, factory); err != nil {
+			return err
+		}
+
+		// Eventos procuram o pod no cache; o informer de eventos só começa com
+		// o de pods sincronizado para não perder os eventos já existentes
+		events := factory.Core().V1().Events().Informer()
+		if _, err := events.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj interface{}) { w.onEvent(pods.GetStore(), obj) },
+			UpdateFunc: func(_, obj interface{}) { w.onEvent(pods.GetStore(), obj) },
+		}); err != nil {
+			return fmt.Errorf("failed to watch events: %w", err)
+		}
+		if err := startInformers(ctx, factory); err != nil {
+			return err
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < w.config.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case d := <-w.queue:
+					w.process(ctx, d)
+				}
+			}
+		}()
+	}
+
+	logging.FromContext(ctx).Info("Watcher do Kubernetes iniciado",
+		"namespaces", w.config.Namespaces, "reasons", w.config.Reasons)
+	wg.Wait()
+	return nil
+}
+
+// startInformers inicia os informers ainda parados da factory e aguarda o cache
+func startInformers(ctx context.Context, factory informers.SharedInformerFactory) error {
+	factory.Start(ctx.Done())
+	for informer, synced := range factory.WaitForCacheSync(ctx.Done()) {
+		if !synced {
+			return fmt.Errorf("failed to sync informer cache for %v", informer)
+		}
+	}
+	return nil
+}
+
+// onPod detecta containers em CrashLoopBackOff, falha de pull de imagem ou
+// encerrados por OOMKilled
+func (w *Watcher) onPod(obj interface{}) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok || pod.DeletionTimestamp != nil {
+		return
+	}
+
+	statuses := append(append([]corev1.ContainerStatus(nil), pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		reason, message := containerFailure(status)
+		if reason != "" && w.config.watches(reason) {
+			w.enqueue(detection{reason: reason, message: message, container: status.Name,
+				previous: status.State.Terminated == nil, pod: pod, workload: WorkloadOf(pod)})
+			return
+		}
+	}
+}
+
+// containerFailure retorna o motivo da falha de um container; OOMKilled tem
+// precedência sobre o CrashLoopBackOff que ele costuma causar
+func containerFailure(status corev1.ContainerStatus) (string, string) {
+	if terminated := status.State.Terminated; terminated != nil && terminated.Reason == ReasonOOMKilled {
+		return ReasonOOMKilled, fmt.Sprintf("container encerrado por falta de memória (exit code %d)", terminated.ExitCode)
+	}
+
+	waiting := status.State.Waiting
+	if waiting == nil {
+		return "", ""
+	}
+	switch waiting.Reason {
+	case ReasonCrashLoopBackOff:
+		if last := status.LastTerminationState.Terminated; last != nil && last.Reason == ReasonOOMKilled {
+			return ReasonOOMKilled, fmt.Sprintf("container reiniciado após OOMKilled (exit code %d): %s", last.ExitCode, waiting.Message)
+		}
+		return ReasonCrashLoopBackOff, waiting.Message
+	case ReasonImagePullBackOff, ReasonErrImagePull:
+		return waiting.Reason, waiting.Message
+	}
+	return "", ""
+}
+
+// onEvent detecta pods que o scheduler não conseguiu alocar
+func (w *Watcher) onEvent(pods cache.Store, obj interface{}) {
+	event, ok := obj.(*corev1.Event)
+	if !ok || event.Reason != ReasonFailedScheduling || event.InvolvedObject.Kind != "Pod" || !w.config.watches(ReasonFailedScheduling) {
+		return
+	}
+
+	item, exists, err := pods.GetByKey(event.InvolvedObject.Namespace + "/" + event.InvolvedObject.Name)
+	if err != nil || !exists {
+		return
+	}
+	pod := item.(*corev1.Pod)
+	w.enqueue(detection{reason: ReasonFailedScheduling, message: event.Message, pod: pod, workload: WorkloadOf(pod)})
+}
+
+// enqueue descarta falhas já analisadas para o mesmo workload e motivo dentro
+// da janela de deduplicação
+func (w *Watcher) enqueue(d detection) {
+	key := strings.Join([]string{d.pod.Namespace, d.workload.Kind, d.workload.Name, d.reason}, "/")
+	now := w.now()
+	window := time.Duration(w.config.DedupWindowMinutes) * time.Minute
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for k, at := range w.seen {
+		if now.Sub(at) >= window {
+			delete(w.seen, k)
+		}
+	}
+	if _, ok := w.seen[key]; ok {
+		return
+	}
+
+	select {
+	case w.queue <- d:
+		w.seen[key] = now
+	default:
+		logging.FromContext(context.Background()).Warn("fila do watcher cheia, falha descartada", "key", key)
+	}
+}
+
+// process monta o contexto da falha, analisa e entrega o diagnóstico aos
+// destinos configurados
+func (w *Watcher) process(ctx context.Context, d detection) {
+	ctx = logging.WithRequestID(ctx, logging.NewRequestID())
+	ctx, span := tracing.Start(ctx, "kubewatch.process",
+		attribute.String("k8s.namespace.name", d.pod.Namespace),
+		attribute.String("k8s.pod.name", d.pod.Name),
+		attribute.String("hefestus.watch.reason", d.reason))
+	defer span.End()
+
+	logger := logging.FromContext(ctx).With("namespace", d.pod.Namespace, "pod", d.pod.Name,
+		"workload", d.workload.Kind+"/"+d.workload.Name, "reason", d.reason)
+
+	request := w.buildRequest(ctx, d)
+	// Os destinos do watch.json são do operador; não passam pela allowlist
+	// aplicada aos informados por clientes
+	request.ConfiguredNotify = append([]models.NotificationSink(nil), w.config.Notify...)
+
+	status := "analyzed"
+	response, err := w.analyzer.ProcessError(ctx, w.config.Domain, request)
+	if err != nil {
+		status = "failed"
+		tracing.RecordError(span, err)
+		logger.Error("falha ao analisar pod", "error", err)
+	} else if response.Error != nil {
+		logger.Info("pod analisado", "causa", response.Error.Causa)
+	}
+	metrics.IntegrationEvents.WithLabelValues(source, status).Inc()
+}
+
+// WorkloadOf identifica o workload dono do pod sem consultar a API: o nome do
+// Deployment é o do ReplicaSet sem o pod-template-hash
+func WorkloadOf(pod *corev1.Pod) Workload {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return Workload{Kind: "Pod", Name: pod.Name}
+	}
+
+	if owner.Kind == "ReplicaSet" {
+		if hash := pod.Labels["pod-template-hash"]; hash != "" && strings.HasSuffix(owner.Name, "-"+hash) {
+			return Workload{Kind: "Deployment", Name: strings.TrimSuffix(owner.Name, "-"+hash)}
+		}
+	}
+	return Workload{Kind: owner.Kind, Name: owner.Name}
+}
@@ -0,0 +1,192 @@
+package kubewatch
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"hefestus-api/internal/models"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// recorder é um Analyzer que entrega as requisições recebidas no canal
+type recorder struct {
+	requests chan models.ErrorRequest
+}
+
+func (r *recorder) ProcessError(_ context.Context, _ string, req models.ErrorRequest) (*models.ErrorResponse, error) {
+	r.requests <- req
+	return &models.ErrorResponse{Error: &models.ErrorSolution{Causa: "teste"}}, nil
+}
+
+func newRecorder() *recorder {
+	return &recorder{requests: make(chan models.ErrorRequest, 10)}
+}
+
+var testSink = models.NotificationSink{Type: "slack", URL: "https://hooks.example.internal/ops"}
+
+// deploymentPod cria um pod de um ReplicaSet do Deployment api
+func deploymentPod(name string, status corev1.PodStatus) *corev1.Pod {
+	controller := true
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "shop",
+			Labels:    map[string]string{"pod-template-hash": "7d9f8c6b5"},
+			OwnerReferences: []metav1.OwnerReference{
+				{Kind: "ReplicaSet", Name: "api-7d9f8c6b5", Controller: &controller},
+			},
+		},
+		Spec:   corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "shop/api:1.2"}}},
+		Status: status,
+	}
+}
+
+func crashLooping() corev1.PodStatus {
+	return corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+		Name:  "app",
+		State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: ReasonCrashLoopBackOff, Message: "back-off restarting"}},
+	}}}
+}
+
+func TestContainerFailure(t *testing.T) {
+	tests := []struct {
+		name   string
+		status corev1.ContainerStatus
+		reason string
+	}{
+		{"running", corev1.ContainerStatus{State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}}, ""},
+		{"crash loop", crashLooping().ContainerStatuses[0], ReasonCrashLoopBackOff},
+		{"crash loop after OOM", corev1.ContainerStatus{
+			State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: ReasonCrashLoopBackOff}},
+			LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: ReasonOOMKilled, ExitCode: 137}},
+		}, ReasonOOMKilled},
+		{"image pull", corev1.ContainerStatus{State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: ReasonImagePullBackOff}}}, ReasonImagePullBackOff},
+		{"completed", corev1.ContainerStatus{State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Completed"}}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if reason, _ := containerFailure(tt.status); reason != tt.reason {
+				t.Errorf("containerFailure() = %q, want %q", reason, tt.reason)
+			}
+		})
+	}
+}
+
+func TestWorkloadOf(t *testing.T) {
+	if got := WorkloadOf(deploymentPod("api-7d9f8c6b5-x2x7q", corev1.PodStatus{})); got != (Workload{Kind: "Deployment", Name: "api"}) {
+		t.Errorf("WorkloadOf(replicaset pod) = %+v", got)
+	}
+	bare := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "debug", Namespace: "shop"}}
+	if got := WorkloadOf(bare); got != (Workload{Kind: "Pod", Name: "debug"}) {
+		t.Errorf("WorkloadOf(bare pod) = %+v", got)
+	}
+}
+
+func TestEnqueueDeduplicatesByWorkload(t *testing.T) {
+	w := NewWatcher(fake.NewClientset(), newRecorder(), Config{DedupWindowMinutes: 10})
+	now := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	w.now = func() time.Time { return now }
+
+	// Dois pods do mesmo Deployment com o mesmo motivo contam como uma falha
+	w.onPod(deploymentPod("api-7d9f8c6b5-aaaaa", crashLooping()))
+	w.onPod(deploymentPod("api-7d9f8c6b5-bbbbb", crashLooping()))
+	if got := len(w.queue); got != 1 {
+		t.Fatalf("queued %d detections for the same workload, want 1", got)
+	}
+
+	// Outro motivo no mesmo workload é outra falha
+	oom := corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+		Name:  "app",
+		State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: ReasonOOMKilled, ExitCode: 137}},
+	}}}
+	w.onPod(deploymentPod("api-7d9f8c6b5-aaaaa", oom))
+	if got := len(w.queue); got != 2 {
+		t.Fatalf("queued %d detections, want 2 after a new reason", got)
+	}
+
+	// Depois da janela a mesma falha volta a ser analisada
+	now = now.Add(10 * time.Minute)
+	w.onPod(deploymentPod("api-7d9f8c6b5-ccccc", crashLooping()))
+	if got := len(w.queue); got != 3 {
+		t.Fatalf("queued %d detections, want 3 after the dedup window", got)
+	}
+}
+
+func TestEnqueueIgnoresUnwatchedReasons(t *testing.T) {
+	w := NewWatcher(fake.NewClientset(), newRecorder(), Config{Reasons: []string{ReasonOOMKilled}})
+	w.onPod(deploymentPod("api-7d9f8c6b5-aaaaa", crashLooping()))
+
+	deleting := deploymentPod("api-7d9f8c6b5-bbbbb", corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+		Name:  "app",
+		State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: ReasonOOMKilled}},
+	}}})
+	deleting.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	w.onPod(deleting)
+
+	if got := len(w.queue); got != 0 {
+		t.Fatalf("queued %d detections, want 0", got)
+	}
+}
+
+func TestRunAnalyzesFailingPods(t *testing.T) {
+	pending := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker", Namespace: "shop"},
+		Status:     corev1.PodStatus{Phase: corev1.PodPending},
+	}
+	scheduling := &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "worker.1", Namespace: "shop"},
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Namespace: "shop", Name: "worker"},
+		Reason:         ReasonFailedScheduling,
+		Message:        "0/3 nodes are available: 3 Insufficient memory.",
+		Type:           corev1.EventTypeWarning,
+	}
+	client := fake.NewClientset(deploymentPod("api-7d9f8c6b5-x2x7q", crashLooping()), pending, scheduling)
+
+	analyzer := newRecorder()
+	w := NewWatcher(client, analyzer, Config{Namespaces: []string{"shop"}, Notify: []models.NotificationSink{testSink}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- w.Run(ctx) }()
+	defer func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Run() = %v", err)
+		}
+	}()
+
+	got := map[string]models.ErrorRequest{}
+	for len(got) < 2 {
+		select {
+		case req := <-analyzer.requests:
+			reason, _, _ := strings.Cut(req.ErrorDetails, ":")
+			got[reason] = req
+		case <-time.After(10 * time.Second):
+			t.Fatalf("analyzed %d failures, want 2", len(got))
+		}
+	}
+
+	crash := got[ReasonCrashLoopBackOff]
+	if !strings.Contains(crash.ErrorDetails, "pod shop/api-7d9f8c6b5-x2x7q, container app") ||
+		!strings.Contains(crash.ErrorDetails, "Logs do container app") {
+		t.Errorf("unexpected crash loop details:\n%s", crash.ErrorDetails)
+	}
+	if !strings.Contains(crash.Context, "Deployment api") {
+		t.Errorf("context without workload: %s", crash.Context)
+	}
+	if !strings.Contains(got[ReasonFailedScheduling].ErrorDetails, "Insufficient memory") {
+		t.Errorf("unexpected scheduling details:\n%s", got[ReasonFailedScheduling].ErrorDetails)
+	}
+
+	// Os destinos do watch.json vão como configurados, fora da allowlist dos clientes
+	for reason, req := range got {
+		if len(req.Notify) != 0 || len(req.ConfiguredNotify) != 1 || req.ConfiguredNotify[0] != testSink {
+			t.Errorf("%s: notify = %v, configured = %v", reason, req.Notify, req.ConfiguredNotify)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Package logging configura o log/slog da aplicação e propaga o ID da
+// requisição para os serviços através do context.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"hefestus-api/internal/tenant"
+	"hefestus-api/pkg/telemetry"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader é o header usado para receber e devolver o ID da requisição
+const RequestIDHeader = telemetry.RequestIDHeader
+
+// Setup instala o logger padrão conforme LOG_LEVEL (debug, info, warn, error)
+// e LOG_FORMAT (text ou json)
+func Setup() {
+	slog.SetDefault(New(os.Stdout, os.Getenv("LOG_LEVEL"), os.Getenv("LOG_FORMAT")))
+}
+
+// New cria um logger com o nível e formato informados; valores desconhecidos
+// caem em info e text
+func New(w io.Writer, level string, format string) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: ParseLevel(level)}
+	if strings.EqualFold(format, "json") {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
+
+// ParseLevel converte o valor de LOG_LEVEL em slog.Level
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// WithRequestID associa um ID de requisição ao context
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return telemetry.WithRequestID(ctx, id)
+}
+
+// RequestID retorna o ID de requisição do context, se houver
+func RequestID(ctx context.Context) string {
+	return telemetry.RequestID(ctx)
+}
+
+// FromContext retorna o logger padrão enriquecido com o ID da requisição e o tenant
+func FromContext(ctx context.Context) *slog.Logger {
+	logger := slog.Default()
+	if id := RequestID(ctx); id != "" {
+		logger = logger.With("request_id", id)
+	}
+	if id := tenant.FromContext(ctx); id != "" {
+		logger = logger.With("tenant", id)
+	}
+	return logger
+}
+
+// Middleware aceita ou gera o X-Request-ID, devolve-o na resposta, propaga-o
+// no context da requisição e registra uma linha de acesso ao final
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if id == "" || len(id) > 128 {
+			id = NewRequestID()
+		}
+		c.Header(RequestIDHeader, id)
+
+		ctx := WithRequestID(c.Request.Context(), id)
+		c.Request = c.Request.WithContext(ctx)
+
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		if status >= 500 {
+			level = slog.LevelError
+		}
+		FromContext(ctx).Log(ctx, level, "requisição concluída",
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"status", status,
+			"duration_ms", time.Since(start).Milliseconds(),
+			"client_ip", c.ClientIP())
+	}
+}
+
+// NewRequestID gera um ID aleatório para requisições e análises sem ID de origem
+func NewRequestID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return time.Now().UTC().Format("20060102T150405.000000000")
+	}
+	return hex.EncodeToString(buf)
+}
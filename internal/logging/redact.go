@@ -0,0 +1,8 @@
+package logging
+
+import "hefestus-api/pkg/redact"
+
+// Redact mascara credenciais antes que prompts e respostas sejam registrados
+func Redact(text string) string {
+	return redact.Secrets(text)
+}
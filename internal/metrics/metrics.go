@@ -0,0 +1,115 @@
+// Package metrics reúne os coletores Prometheus expostos em /metrics.
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "hefestus"
+
+var (
+	// HTTPRequests conta as requisições de análise por domínio e status HTTP
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Requisições de análise recebidas, por domínio e status HTTP.",
+	}, []string{"domain", "status"})
+
+	// HTTPRequestDuration mede a latência das requisições de análise
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latência das requisições de análise, por domínio e status HTTP.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 40, 80},
+	}, []string{"domain", "status"})
+
+	// LLMRequestDuration mede a latência das chamadas ao Ollama
+	LLMRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "llm_request_duration_seconds",
+		Help:      "Latência das chamadas ao Ollama, por domínio, modelo e resultado.",
+		Buckets:   []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 40, 80},
+	}, []string{"domain", "model", "outcome"})
+
+	// LLMPromptTokens soma os tokens de prompt reportados pelo Ollama (prompt_eval_count)
+	LLMPromptTokens = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "llm_prompt_tokens_total",
+		Help:      "Tokens de prompt avaliados pelo Ollama.",
+	}, []string{"domain", "model"})
+
+	// LLMCompletionTokens soma os tokens gerados reportados pelo Ollama (eval_count)
+	LLMCompletionTokens = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "llm_completion_tokens_total",
+		Help:      "Tokens gerados pelo Ollama.",
+	}, []string{"domain", "model"})
+
+	// LLMInFlight indica quantas chamadas ao Ollama aguardam resposta
+	LLMInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "llm_inflight_requests",
+		Help:      "Chamadas ao Ollama em andamento (fila de espera do modelo).",
+	})
+
+	// LLMParseFailures conta respostas do modelo descartadas, por motivo
+	LLMParseFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "llm_parse_failures_total",
+		Help:      "Respostas do LLM rejeitadas na validação, por domínio e motivo.",
+	}, []string{"domain", "reason"})
+
+	// DictionaryLookups conta as consultas ao dicionário de padrões
+	DictionaryLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "dictionary_lookups_total",
+		Help:      "Consultas ao dicionário de padrões, por domínio.",
+	}, []string{"domain"})
+
+	// DictionaryMatches conta os padrões encontrados; dividido por
+	// DictionaryLookups dá a taxa de acerto de cada padrão
+	DictionaryMatches = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "dictionary_matches_total",
+		Help:      "Padrões do dicionário que casaram com o erro, por domínio e padrão.",
+	}, []string{"domain", "pattern"})
+
+	// ResolutionCache conta as consultas ao cache de diagnósticos (LLM_CACHE_TTL)
+	ResolutionCache = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "resolution_cache_requests_total",
+		Help:      "Consultas ao cache de diagnósticos, por domínio e resultado (hit ou miss).",
+	}, []string{"domain", "result"})
+
+	// Notifications conta as entregas aos webhooks de notificação
+	Notifications = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "notifications_total",
+		Help:      "Notificações enviadas ao Slack/Teams, por tipo e resultado.",
+	}, []string{"type", "outcome"})
+
+	// Actions conta as ações de autocorreção por tipo e estado resultante
+	Actions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "actions_total",
+		Help:      "Ações de autocorreção planejadas ou disparadas, por tipo e estado.",
+	}, []string{"type", "status"})
+
+	// IntegrationEvents conta os itens recebidos das integrações por origem e resultado
+	IntegrationEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "integration_events_total",
+		Help:      "Itens recebidos das integrações (alertas, triggers...), por origem e resultado.",
+	}, []string{"source", "status"})
+)
+
+// ObserveRequest registra contagem e latência de uma requisição de análise
+func ObserveRequest(domain string, status int, elapsed time.Duration) {
+	code := strconv.Itoa(status)
+	HTTPRequests.WithLabelValues(domain, code).Inc()
+	HTTPRequestDuration.WithLabelValues(domain, code).Observe(elapsed.Seconds())
+}
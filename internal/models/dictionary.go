@@ -1,10 +1,29 @@
 package models
 
+import "hefestus-api/pkg/ollama"
+
 type ErrorPattern struct {
-	Pattern    string   `json:"pattern"`
-	Category   string   `json:"category"`
-	Solutions  []string `json:"solutions"`
-	References []string `json:"references"`
+	Name       string         `json:"-"`
+	Pattern    string         `json:"pattern"`
+	Category   string         `json:"category"`
+	Solutions  []string       `json:"solutions"`
+	References []string       `json:"references"`
+	Actions    []ActionConfig `json:"actions,omitempty"`
+}
+
+// ActionConfig descreve uma ação de autocorreção de um padrão ou domínio:
+// um job do Rundeck (type rundeck) ou uma requisição HTTP (type webhook). Os
+// valores de options e o body são text/templates sobre a análise.
+type ActionConfig struct {
+	Type            string            `json:"type"`
+	JobID           string            `json:"job_id,omitempty"`
+	Options         map[string]string `json:"options,omitempty"`
+	URL             string            `json:"url,omitempty"`
+	Method          string            `json:"method,omitempty"`
+	Headers         map[string]string `json:"headers,omitempty"`
+	Body            string            `json:"body,omitempty"`
+	SecretEnv       string            `json:"secret_env,omitempty"`
+	RequireApproval bool              `json:"require_approval"`
 }
 
 type ErrorDictionary struct {
@@ -13,4 +32,18 @@ type ErrorDictionary struct {
 
 type DomainsConfig struct {
 	Domains map[string]DomainConfig `json:"domains"`
+	// Models informa a janela de contexto de cada modelo do Ollama
+	Models map[string]ollama.ModelConfig `json:"models"`
+}
+
+// TenantsConfig agrupa as sobrescritas de cada tenant (config/tenants.json)
+type TenantsConfig struct {
+	Tenants map[string]TenantConfig `json:"tenants"`
+}
+
+// TenantConfig sobrescreve campos das configurações globais de domínio. O
+// dictionary_path de um tenant é aplicado sobre o dicionário global: padrões
+// com o mesmo nome substituem os globais e os demais são adicionados.
+type TenantConfig struct {
+	Domains map[string]DomainConfig `json:"domains"`
 }
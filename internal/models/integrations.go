@@ -0,0 +1,198 @@
+package models
+
+import "time"
+
+// IntegrationResult resume o processamento de um evento recebido de uma integração
+// @Description Resultado da análise de cada item (alerta, trigger, workflow...) de um evento de integração
+type IntegrationResult struct {
+	Received int               `json:"received" example:"3"`
+	Analyzed int               `json:"analyzed" example:"1"`
+	Ignored  int               `json:"ignored" example:"1"`
+	Failed   int               `json:"failed" example:"1"`
+	Queued   int               `json:"queued,omitempty" example:"0"`
+	Items    []IntegrationItem `json:"items"`
+}
+
+// IntegrationItem descreve o resultado de um item do evento
+// @Description Item analisado, ignorado (com o motivo), que falhou ou enfileirado para análise em segundo plano
+type IntegrationItem struct {
+	ID       string         `json:"id" example:"3b5f0f0c2a6b7e4d"`
+	Name     string         `json:"name" example:"KubePodCrashLooping"`
+	Domain   string         `json:"domain,omitempty" example:"kubernetes"`
+	Status   string         `json:"status" example:"analyzed" enums:"analyzed,ignored,failed,queued"`
+	Reason   string         `json:"reason,omitempty" example:"alerta resolvido"`
+	Response *ErrorResponse `json:"response,omitempty"`
+}
+
+// AlertmanagerWebhook é o payload enviado pelo webhook_config do Alertmanager
+// @Description Payload do webhook do Prometheus Alertmanager (version 4)
+type AlertmanagerWebhook struct {
+	Version           string            `json:"version" example:"4"`
+	GroupKey          string            `json:"groupKey"`
+	Status            string            `json:"status" example:"firing" enums:"firing,resolved"`
+	Receiver          string            `json:"receiver" example:"hefestus"`
+	GroupLabels       map[string]string `json:"groupLabels"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	ExternalURL       string            `json:"externalURL"`
+	Alerts            []Alert           `json:"alerts"`
+}
+
+// Alert é um alerta do payload do Alertmanager
+type Alert struct {
+	Status       string            `json:"status" example:"firing" enums:"firing,resolved"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+// ZabbixEvent é o payload enviado pelo script do media type webhook do Zabbix
+// @Description Evento do Zabbix; os campos correspondem às macros {EVENT.*}, {HOST.NAME}, {TRIGGER.*} e {ITEM.*}. host_groups e tags são listas separadas por vírgula, como nas macros {TRIGGER.HOSTGROUP.NAME} e {EVENT.TAGS}.
+type ZabbixEvent struct {
+	EventID            string `json:"event_id" example:"4211" binding:"required"`
+	EventName          string `json:"event_name" example:"Pod api-7f in CrashLoopBackOff"`
+	EventValue         string `json:"event_value" example:"1" enums:"0,1"`
+	EventUpdateStatus  string `json:"event_update_status" example:"0" enums:"0,1"`
+	Severity           string `json:"severity" example:"High"`
+	Host               string `json:"host" example:"k8s-node-01"`
+	HostGroups         string `json:"host_groups" example:"Kubernetes nodes,Linux servers"`
+	Tags               string `json:"tags" example:"service:api,hefestus_domain:kubernetes"`
+	TriggerName        string `json:"trigger_name" example:"Pod is crash looping"`
+	TriggerDescription string `json:"trigger_description"`
+	ItemName           string `json:"item_name" example:"Pod api-7f status"`
+	ItemValue          string `json:"item_value" example:"Back-off restarting failed container"`
+}
+
+// GitHubWorkflowEvent é o subconjunto usado dos eventos workflow_run e workflow_job
+// @Description Payload dos webhooks workflow_run e workflow_job do GitHub; o tipo vem do header X-GitHub-Event
+type GitHubWorkflowEvent struct {
+	Action      string             `json:"action" example:"completed"`
+	WorkflowRun *GitHubWorkflowRun `json:"workflow_run,omitempty"`
+	WorkflowJob *GitHubWorkflowJob `json:"workflow_job,omitempty"`
+	Repository  GitHubRepository   `json:"repository"`
+}
+
+// GitHubWorkflowRun é a execução de workflow do evento workflow_run
+type GitHubWorkflowRun struct {
+	ID           int64               `json:"id" example:"30433642"`
+	Name         string              `json:"name" example:"CI"`
+	Conclusion   string              `json:"conclusion" example:"failure"`
+	HeadSHA      string              `json:"head_sha"`
+	HeadBranch   string              `json:"head_branch" example:"main"`
+	HTMLURL      string              `json:"html_url"`
+	PullRequests []GitHubPullRequest `json:"pull_requests"`
+}
+
+// GitHubWorkflowJob é o job do evento workflow_job
+type GitHubWorkflowJob struct {
+	ID           int64        `json:"id" example:"399444496"`
+	RunID        int64        `json:"run_id" example:"30433642"`
+	Name         string       `json:"name" example:"build"`
+	WorkflowName string       `json:"workflow_name" example:"CI"`
+	Conclusion   string       `json:"conclusion" example:"failure"`
+	HeadSHA      string       `json:"head_sha"`
+	HeadBranch   string       `json:"head_branch" example:"main"`
+	HTMLURL      string       `json:"html_url"`
+	Steps        []GitHubStep `json:"steps"`
+}
+
+// GitHubStep é um passo de um job
+type GitHubStep struct {
+	Name       string `json:"name" example:"Run tests"`
+	Number     int    `json:"number" example:"4"`
+	Conclusion string `json:"conclusion" example:"failure"`
+}
+
+// GitHubPullRequest identifica um pull request associado à execução
+type GitHubPullRequest struct {
+	Number int `json:"number" example:"42"`
+}
+
+// GitHubRepository identifica o repositório do evento
+type GitHubRepository struct {
+	FullName string `json:"full_name" example:"octo-org/octo-repo"`
+}
+
+// ArgoCDNotification é o corpo enviado pelo template de webhook do
+// notifications-controller do Argo CD ({"trigger": ..., "app": {{toJson .app}}})
+// @Description Notificação do Argo CD com a Application serializada
+type ArgoCDNotification struct {
+	Trigger string            `json:"trigger" example:"on-sync-failed"`
+	App     ArgoCDApplication `json:"app"`
+}
+
+// ArgoCDApplication é o subconjunto usado do recurso Application
+type ArgoCDApplication struct {
+	Metadata struct {
+		Name      string `json:"name" example:"guestbook"`
+		Namespace string `json:"namespace" example:"argocd"`
+	} `json:"metadata"`
+	Spec struct {
+		Project string `json:"project" example:"default"`
+		Source  struct {
+			RepoURL        string `json:"repoURL"`
+			Path           string `json:"path"`
+			TargetRevision string `json:"targetRevision"`
+		} `json:"source"`
+		Destination struct {
+			Server    string `json:"server"`
+			Namespace string `json:"namespace"`
+		} `json:"destination"`
+	} `json:"spec"`
+	Status ArgoCDApplicationStatus `json:"status"`
+}
+
+// ArgoCDApplicationStatus é o estado de sincronização e saúde da Application
+type ArgoCDApplicationStatus struct {
+	Sync struct {
+		Status   string `json:"status" example:"OutOfSync"`
+		Revision string `json:"revision"`
+	} `json:"sync"`
+	Health         ArgoCDHealth           `json:"health"`
+	OperationState *ArgoCDOperationState  `json:"operationState,omitempty"`
+	Conditions     []ArgoCDCondition      `json:"conditions"`
+	Resources      []ArgoCDResourceStatus `json:"resources"`
+}
+
+// ArgoCDHealth é o estado de saúde de uma Application ou recurso
+type ArgoCDHealth struct {
+	Status  string `json:"status" example:"Degraded"`
+	Message string `json:"message,omitempty"`
+}
+
+// ArgoCDOperationState é o resultado da última operação de sincronização
+type ArgoCDOperationState struct {
+	Phase      string `json:"phase" example:"Failed"`
+	Message    string `json:"message"`
+	SyncResult *struct {
+		Resources []ArgoCDSyncResult `json:"resources"`
+	} `json:"syncResult,omitempty"`
+}
+
+// ArgoCDSyncResult é o resultado da sincronização de um recurso
+type ArgoCDSyncResult struct {
+	Kind      string `json:"kind" example:"Deployment"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Status    string `json:"status" example:"SyncFailed"`
+	HookPhase string `json:"hookPhase,omitempty"`
+	Message   string `json:"message"`
+}
+
+// ArgoCDCondition é uma condição de erro ou aviso da Application
+type ArgoCDCondition struct {
+	Type    string `json:"type" example:"ComparisonError"`
+	Message string `json:"message"`
+}
+
+// ArgoCDResourceStatus é o estado de um recurso gerenciado pela Application
+type ArgoCDResourceStatus struct {
+	Kind      string        `json:"kind" example:"Deployment"`
+	Namespace string        `json:"namespace"`
+	Name      string        `json:"name"`
+	Status    string        `json:"status" example:"Synced"`
+	Health    *ArgoCDHealth `json:"health,omitempty"`
+}
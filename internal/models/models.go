@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // APIError representa um erro padronizado da API
 // @Description Estrutura de erro padrão retornada pela API
 type APIError struct {
@@ -11,26 +13,154 @@ type APIError struct {
 // ErrorRequest representa uma solicitação para analisar um erro
 // @Description Requisição contendo os detalhes do erro a ser analisado
 type ErrorRequest struct {
-	ErrorDetails string `json:"error_details" validate:"required" example:"CrashLoopBackOff: container failed to start" binding:"required"`
-	Context      string `json:"context" example:"Deployment em cluster Kubernetes 1.26 com imagem Docker personalizada"`
+	ErrorDetails string             `json:"error_details" validate:"required" example:"CrashLoopBackOff: container failed to start" binding:"required"`
+	Context      string             `json:"context" example:"Deployment em cluster Kubernetes 1.26 com imagem Docker personalizada"`
+	Notify       []NotificationSink `json:"notify,omitempty"`
+	ActionMode   string             `json:"action_mode,omitempty" example:"dry_run" enums:"none,dry_run,execute"`
+	Kubernetes   *KubernetesRef     `json:"kubernetes,omitempty"`
+	// ConfiguredNotify são destinos definidos pelo operador (integrações,
+	// kubewatch); não vêm do corpo da requisição e dispensam a allowlist
+	ConfiguredNotify []NotificationSink `json:"-"`
+}
+
+// KubernetesRef aponta o recurso do cluster relacionado ao erro
+// @Description Recurso cujos detalhes, eventos e logs são anexados ao contexto quando o domínio tem kubernetes_enrichment; informe pod ou deployment
+type KubernetesRef struct {
+	Namespace  string `json:"namespace" example:"shop"`
+	Pod        string `json:"pod,omitempty" example:"api-7d9f8c6b5-x2x7q"`
+	Deployment string `json:"deployment,omitempty" example:"api"`
+	Container  string `json:"container,omitempty" example:"app"`
+}
+
+// NotificationSink descreve um incoming webhook que receberá o diagnóstico
+// @Description Webhook do Slack ou Microsoft Teams; template é um text/template opcional para o texto da mensagem
+type NotificationSink struct {
+	Type     string `json:"type" example:"slack" enums:"slack,teams"`
+	URL      string `json:"url" example:"https://hooks.slack.com/services/T000/B000/XXXX"`
+	Template string `json:"template,omitempty" example:"{{.Domain}}: {{.Causa}}"`
 }
 
 // ErrorResponse representa a resposta da API com a solução do erro
 // @Description Resposta contendo análise e solução para o erro reportado
 type ErrorResponse struct {
-	Error   *ErrorSolution `json:"error" binding:"required"`
-	Message string         `json:"message" example:"Análise concluída com sucesso"`
+	Error         *ErrorSolution    `json:"error" binding:"required"`
+	Message       string            `json:"message" example:"Análise concluída com sucesso"`
+	Preprocessing *PreprocessReport `json:"preprocessing,omitempty"`
+	Enrichment    *EnrichmentReport `json:"enrichment,omitempty"`
+	Actions       []ActionResult    `json:"actions,omitempty"`
+}
+
+// EnrichmentReport descreve o contexto buscado no cluster e anexado ao prompt
+// @Description Objetos consultados no enriquecimento de contexto; error indica falha parcial, sem impedir a análise
+type EnrichmentReport struct {
+	Source    string   `json:"source" example:"kubernetes"`
+	Objects   []string `json:"objects,omitempty" example:"Deployment/shop/api,Pod/shop/api-7d9f8c6b5-x2x7q"`
+	Bytes     int      `json:"bytes" example:"4096"`
+	Truncated bool     `json:"truncated" example:"false"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// ActionResult descreve uma ação de autocorreção associada a um padrão encontrado
+// @Description Ação do dicionário planejada ou disparada; status segue o Rundeck (running, succeeded, failed...) ou vale dry_run, pending_approval, approved, disabled, delivering, delivered ou error
+type ActionResult struct {
+	ID          string            `json:"id" example:"5f2b8c1e9a7d4e3f"`
+	Pattern     string            `json:"pattern" example:"image_pull_backoff"`
+	Type        string            `json:"type" example:"rundeck"`
+	JobID       string            `json:"job_id,omitempty" example:"a1b2c3d4-0000-0000-0000-000000000000"`
+	Options     map[string]string `json:"options,omitempty"`
+	URL         string            `json:"url,omitempty" example:"https://awx.example.com/api/v2/job_templates/42/launch/"`
+	Status      string            `json:"status" example:"pending_approval"`
+	ExecutionID int               `json:"execution_id,omitempty" example:"1234"`
+	Permalink   string            `json:"permalink,omitempty" example:"https://rundeck.example.com/project/ops/execution/show/1234"`
+	Error       string            `json:"error,omitempty"`
+	Attempts    []DeliveryAttempt `json:"attempts,omitempty"`
+}
+
+// DeliveryAttempt registra uma tentativa de entrega de uma ação webhook
+// @Description Tentativa de entrega de um webhook, com o status HTTP ou o erro de rede
+type DeliveryAttempt struct {
+	Attempt    int       `json:"attempt" example:"1"`
+	Time       time.Time `json:"time"`
+	StatusCode int       `json:"status_code,omitempty" example:"503"`
+	DurationMs int64     `json:"duration_ms" example:"120"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// PreprocessReport descreve o que foi removido de error_details antes da análise
+// @Description Resumo do pré-processamento aplicado ao log recebido
+type PreprocessReport struct {
+	OriginalLines           int  `json:"original_lines" example:"5231"`
+	ResultLines             int  `json:"result_lines" example:"48"`
+	OriginalTokens          int  `json:"original_tokens" example:"180344"`
+	ResultTokens            int  `json:"result_tokens" example:"1910"`
+	TokenBudget             int  `json:"token_budget" example:"2048"`
+	ANSICodesRemoved        int  `json:"ansi_codes_removed" example:"812"`
+	TimestampsRemoved       int  `json:"timestamps_removed" example:"5231"`
+	DuplicateLinesCollapsed int  `json:"duplicate_lines_collapsed" example:"97"`
+	ErrorRegions            int  `json:"error_regions" example:"3"`
+	LinesDropped            int  `json:"lines_dropped" example:"5086"`
+	Truncated               bool `json:"truncated" example:"true"`
 }
 
 // ErrorSolution contém a causa raiz e a solução do erro
 // @Description Estrutura contendo a causa identificada e soluções propostas para o erro
 type ErrorSolution struct {
-	Causa   string `json:"causa" example:"Imagem Docker inválida" binding:"required"`
-	Solucao string `json:"solucao" example:"kubectl describe pod meu-pod\nkubectl logs meu-pod --previous" binding:"required"`
+	Causa      string   `json:"causa" example:"Imagem Docker inválida" binding:"required"`
+	Solucao    string   `json:"solucao" example:"kubectl describe pod meu-pod\nkubectl logs meu-pod --previous" binding:"required"`
+	References []string `json:"references,omitempty" example:"https://kubernetes.io/docs/tasks/debug/debug-application/debug-pods/"`
+	Patterns   []string `json:"patterns,omitempty" example:"image_pull_backoff"`
 }
 type DomainConfig struct {
 	Name           string                 `json:"name" example:"GitHub Actions"`
 	PromptTemplate string                 `json:"prompt_template"`
 	Parameters     map[string]interface{} `json:"parameters"`
 	DictionaryPath string                 `json:"dictionary_path"`
+	Preprocessing  PreprocessConfig       `json:"preprocessing"`
+	ContextPolicy  string                 `json:"context_policy" example:"trim"`
+	RateLimit      RateLimitConfig        `json:"rate_limit"`
+	Notifications  []NotificationSink     `json:"notifications"`
+	Actions        []ActionConfig         `json:"actions"`
+	// KubernetesEnrichment anexa ao prompt detalhes do recurso citado na requisição
+	KubernetesEnrichment *KubernetesEnrichmentConfig `json:"kubernetes_enrichment"`
+}
+
+// KubernetesEnrichmentConfig define o enriquecimento de contexto via API do
+// Kubernetes; só os namespaces listados podem ser lidos ("*" libera todos)
+type KubernetesEnrichmentConfig struct {
+	Enabled    bool     `json:"enabled"`
+	Namespaces []string `json:"namespaces"`
+	LogLines   int64    `json:"log_lines"`
+	MaxEvents  int      `json:"max_events"`
+	MaxBytes   int      `json:"max_bytes"`
+}
+
+// RateLimitConfig limita a taxa de requisições e as chamadas diárias ao LLM.
+// Valores zero significam sem limite.
+type RateLimitConfig struct {
+	RequestsPerMinute float64 `json:"requests_per_minute"`
+	Burst             int     `json:"burst"`
+	DailyLLMQuota     int     `json:"daily_llm_quota"`
+}
+
+// QuotaUsage representa o consumo de cota de um cliente
+// @Description Uso da cota diária de chamadas ao LLM por domínio
+type QuotaUsage struct {
+	Client  string        `json:"client" example:"key:zabbix"`
+	Domains []DomainQuota `json:"domains"`
+}
+
+// DomainQuota representa o consumo de cota em um domínio
+// @Description Consumo da cota diária de um domínio; limit 0 indica sem limite
+type DomainQuota struct {
+	Domain       string `json:"domain" example:"kubernetes"`
+	Used         int    `json:"used" example:"42"`
+	Limit        int    `json:"limit" example:"500"`
+	Remaining    int    `json:"remaining" example:"458"`
+	ResetSeconds int    `json:"reset_seconds" example:"3600"`
+}
+
+// PreprocessConfig define os limites do pré-processamento de logs de um domínio
+type PreprocessConfig struct {
+	MaxInputTokens int `json:"max_input_tokens"`
+	ContextLines   int `json:"context_lines"`
 }
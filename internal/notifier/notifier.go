@@ -0,0 +1,322 @@
+// Package notifier envia os diagnósticos para incoming webhooks do Slack e do
+// Microsoft Teams.
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"hefestus-api/internal/logging"
+	"hefestus-api/internal/metrics"
+	"hefestus-api/internal/models"
+	"hefestus-api/internal/retry"
+	"hefestus-api/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// SinkSlack publica em um incoming webhook do Slack
+	SinkSlack = "slack"
+	// SinkTeams publica em um incoming webhook do Microsoft Teams
+	SinkTeams = "teams"
+
+	defaultAllowedHosts = "hooks.slack.com,*.webhook.office.com,*.logic.azure.com"
+	maxErrorExcerpt     = 500
+)
+
+// ErrSinkNotAllowed indica um destino informado na requisição que não está na
+// lista NOTIFY_ALLOWED_HOSTS
+var ErrSinkNotAllowed = errors.New("notification sink not allowed")
+
+// Notification reúne os dados disponíveis para os templates de mensagem
+type Notification struct {
+	Domain       string
+	Tenant       string
+	RequestID    string
+	Causa        string
+	Steps        []string
+	References   []string
+	ErrorDetails string
+	Context      string
+	HistoryURL   string
+}
+
+// Notifier entrega notificações com retentativas e backoff exponencial
+type Notifier struct {
+	httpClient   *http.Client
+	allowedHosts []string
+	historyURL   string
+	maxAttempts  int
+	backoff      time.Duration
+}
+
+// NewNotifier cria um notificador; NOTIFY_ALLOWED_HOSTS restringe os hosts
+// aceitos em destinos enviados pelas requisições (curinga *.dominio permitido)
+// e NOTIFY_HISTORY_URL, se definido, é prefixado ao ID da requisição para
+// compor o link do histórico
+func NewNotifier() *Notifier {
+	hosts := os.Getenv("NOTIFY_ALLOWED_HOSTS")
+	if hosts == "" {
+		hosts = defaultAllowedHosts
+	}
+
+	var allowed []string
+	for _, host := range strings.Split(hosts, ",") {
+		if host = strings.TrimSpace(strings.ToLower(host)); host != "" {
+			allowed = append(allowed, host)
+		}
+	}
+
+	n := &Notifier{
+		allowedHosts: allowed,
+		historyURL:   os.Getenv("NOTIFY_HISTORY_URL"),
+		maxAttempts:  3,
+		backoff:      time.Second,
+	}
+	n.httpClient = &http.Client{Timeout: 10 * time.Second, CheckRedirect: n.checkRedirect}
+	return n
+}
+
+// ValidateSink verifica tipo, URL e template de um destino
+func ValidateSink(sink models.NotificationSink) error {
+	if sink.Type != SinkSlack && sink.Type != SinkTeams {
+		return fmt.Errorf("unsupported notification type: %q", sink.Type)
+	}
+
+	u, err := url.Parse(sink.URL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("invalid notification URL: %q", sink.URL)
+	}
+
+	if _, err := parseTemplate(sink); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ValidateRequestSinks valida destinos enviados pelo cliente, exigindo que o
+// host esteja na lista permitida
+func (n *Notifier) ValidateRequestSinks(sinks []models.NotificationSink) error {
+	for _, sink := range sinks {
+		if err := ValidateSink(sink); err != nil {
+			return fmt.Errorf("%w: %v", ErrSinkNotAllowed, err)
+		}
+
+		u, _ := url.Parse(sink.URL)
+		if !n.hostAllowed(u.Hostname()) {
+			return fmt.Errorf("%w: host %s", ErrSinkNotAllowed, u.Hostname())
+		}
+	}
+	return nil
+}
+
+func (n *Notifier) hostAllowed(host string) bool {
+	host = strings.ToLower(host)
+	for _, allowed := range n.allowedHosts {
+		if suffix, ok := strings.CutPrefix(allowed, "*"); ok {
+			if strings.HasSuffix(host, suffix) {
+				return true
+			}
+			continue
+		}
+		if host == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRedirect só segue redirecionamentos para hosts permitidos, para que um
+// webhook aceito não encaminhe a notificação para endereços internos
+func (n *Notifier) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	if !n.hostAllowed(req.URL.Hostname()) {
+		return fmt.Errorf("%w: redirect to host %s", ErrSinkNotAllowed, req.URL.Hostname())
+	}
+	return nil
+}
+
+// Notify entrega a notificação a cada destino em segundo plano, sem atrasar a
+// resposta da análise. O context é desacoplado do cancelamento da requisição
+// mas preserva request ID e trace.
+func (n *Notifier) Notify(ctx context.Context, sinks []models.NotificationSink, notification Notification) {
+	ctx = context.WithoutCancel(ctx)
+	if n.historyURL != "" && notification.RequestID != "" {
+		notification.HistoryURL = n.historyURL + url.PathEscape(notification.RequestID)
+	}
+	for _, sink := range sinks {
+		go func(sink models.NotificationSink) {
+			if err := n.Deliver(ctx, sink, notification); err != nil {
+				logging.FromContext(ctx).Error("falha ao enviar notificação",
+					"type", sink.Type,
+					"host", hostOf(sink.URL),
+					"error", err)
+			}
+		}(sink)
+	}
+}
+
+// Deliver renderiza e envia a notificação para um destino, com retentativas
+// em erros de rede, 429 e 5xx e espera limitada a retry.DefaultMaxWait
+func (n *Notifier) Deliver(ctx context.Context, sink models.NotificationSink, notification Notification) (err error) {
+	ctx, span := tracing.StartClient(ctx, "notifier.Deliver", attribute.String("hefestus.notifier.type", sink.Type))
+	defer func() {
+		outcome := "success"
+		if err != nil {
+			outcome = "failure"
+		}
+		metrics.Notifications.WithLabelValues(sink.Type, outcome).Inc()
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
+	payload, err := buildPayload(sink, notification)
+	if err != nil {
+		return err
+	}
+
+	policy := retry.Policy{MaxAttempts: n.maxAttempts, Backoff: n.backoff}
+	attempts, err := policy.Do(ctx, func(int) error {
+		return n.post(ctx, sink.URL, payload)
+	}, func(attempt int, wait time.Duration, err error) {
+		logging.FromContext(ctx).Warn("notificação falhou, tentando novamente",
+			"type", sink.Type, "attempt", attempt, "wait", wait, "error", err)
+	})
+	if err != nil {
+		return fmt.Errorf("attempt %d: %w", attempts, err)
+	}
+	return nil
+}
+
+// permanentError marca falhas que não adianta repetir: respostas 4xx exceto
+// 429, ou err quando a requisição nem chega a ser enviada
+type permanentError struct {
+	status int
+	err    error
+}
+
+func (e *permanentError) Error() string {
+	if e.err != nil {
+		return fmt.Sprintf("failed to send notification: %v", e.err)
+	}
+	return fmt.Sprintf("webhook rejected notification with status %d", e.status)
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// post faz uma tentativa de entrega; erros em 429 e 5xx podem ser repetidos,
+// os demais são permanentes
+func (n *Notifier) post(ctx context.Context, target string, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(payload))
+	if err != nil {
+		return retry.Permanent(&permanentError{err: err})
+	}
+	req.Header.Set("Content-Type", "application/json")
+	tracing.Inject(ctx, req.Header)
+
+	resp, err := n.httpClient.Do(req)
+	if errors.Is(err, ErrSinkNotAllowed) {
+		return retry.Permanent(&permanentError{err: err})
+	}
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return retry.After(fmt.Errorf("webhook answered with status %d", resp.StatusCode),
+			retry.ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now()))
+	default:
+		return retry.Permanent(&permanentError{status: resp.StatusCode})
+	}
+}
+
+// buildPayload monta o corpo esperado por cada tipo de webhook
+func buildPayload(sink models.NotificationSink, notification Notification) ([]byte, error) {
+	tmpl, err := parseTemplate(sink)
+	if err != nil {
+		return nil, err
+	}
+
+	if runes := []rune(notification.ErrorDetails); len(runes) > maxErrorExcerpt {
+		notification.ErrorDetails = string(runes[len(runes)-maxErrorExcerpt:])
+	}
+
+	var text bytes.Buffer
+	if err := tmpl.Execute(&text, notification); err != nil {
+		return nil, fmt.Errorf("failed to render notification template: %w", err)
+	}
+
+	switch sink.Type {
+	case SinkTeams:
+		return json.Marshal(map[string]string{
+			"@type":      "MessageCard",
+			"@context":   "https://schema.org/extensions",
+			"summary":    "Hefestus: " + notification.Causa,
+			"themeColor": "D70000",
+			"title":      "Hefestus — " + notification.Domain,
+			"text":       text.String(),
+		})
+	default:
+		return json.Marshal(map[string]string{"text": text.String()})
+	}
+}
+
+func parseTemplate(sink models.NotificationSink) (*template.Template, error) {
+	source := sink.Template
+	if source == "" {
+		source = defaultTemplates[sink.Type]
+	}
+
+	tmpl, err := template.New(sink.Type).Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid notification template: %w", err)
+	}
+	return tmpl, nil
+}
+
+func hostOf(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil {
+		return u.Host
+	}
+	return ""
+}
+
+var defaultTemplates = map[string]string{
+	SinkSlack: `*Hefestus — {{.Domain}}*{{if .Tenant}} ({{.Tenant}}){{end}}
+*Causa:* {{.Causa}}
+*Passos sugeridos:*
+{{range .Steps}}• ` + "`{{.}}`" + `
+{{end}}{{if .References}}*Referências:*
+{{range .References}}• <{{.}}>
+{{end}}{{end}}{{if .HistoryURL}}<{{.HistoryURL}}|Requisição {{.RequestID}}>{{else}}_Requisição {{.RequestID}}_{{end}}`,
+
+	SinkTeams: `**Causa:** {{.Causa}}{{if .Tenant}} — tenant {{.Tenant}}{{end}}
+
+**Passos sugeridos:**
+
+{{range .Steps}}- ` + "`{{.}}`" + `
+{{end}}{{if .References}}
+**Referências:**
+
+{{range .References}}- [{{.}}]({{.}})
+{{end}}{{end}}
+{{if .HistoryURL}}[Requisição {{.RequestID}}]({{.HistoryURL}}){{else}}_Requisição {{.RequestID}}_{{end}}`,
+}
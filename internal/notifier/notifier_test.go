@@ -0,0 +1,274 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"hefestus-api/internal/models"
+)
+
+var sample = Notification{
+	Domain:       "kubernetes",
+	Tenant:       "acme",
+	RequestID:    "req-123",
+	Causa:        "Falta de memória",
+	Steps:        []string{"kubectl top pod", "kubectl describe pod api"},
+	References:   []string{"https://kubernetes.io/docs/"},
+	ErrorDetails: "OOMKilled",
+	HistoryURL:   "https://hefestus.example/history/req-123",
+}
+
+// receiver é um webhook de teste que responde os status informados, um por
+// tentativa, e guarda os corpos recebidos
+type receiver struct {
+	*httptest.Server
+	statuses []int
+	calls    atomic.Int32
+	bodies   chan []byte
+}
+
+func newReceiver(t *testing.T, statuses ...int) *receiver {
+	t.Helper()
+	r := &receiver{statuses: statuses, bodies: make(chan []byte, 10)}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		call := int(r.calls.Add(1)) - 1
+		body, _ := io.ReadAll(req.Body)
+		r.bodies <- body
+		status := http.StatusOK
+		if call < len(r.statuses) {
+			status = r.statuses[call]
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func testNotifier(r *receiver) *Notifier {
+	return &Notifier{
+		httpClient:   r.Client(),
+		allowedHosts: []string{"hooks.slack.com", "*.webhook.office.com"},
+		maxAttempts:  3,
+		backoff:      time.Millisecond,
+	}
+}
+
+func TestBuildPayloadSlack(t *testing.T) {
+	data, err := buildPayload(models.NotificationSink{Type: SinkSlack, URL: "https://hooks.slack.com/x"}, sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var payload map[string]string
+	if err := json.Unmarshal(data, &payload); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"*Hefestus — kubernetes* (acme)",
+		"*Causa:* Falta de memória",
+		"• `kubectl top pod`",
+		"• <https://kubernetes.io/docs/>",
+		"<https://hefestus.example/history/req-123|Requisição req-123>",
+	} {
+		if !strings.Contains(payload["text"], want) {
+			t.Errorf("slack text missing %q:\n%s", want, payload["text"])
+		}
+	}
+}
+
+func TestBuildPayloadTeams(t *testing.T) {
+	data, err := buildPayload(models.NotificationSink{Type: SinkTeams, URL: "https://x.webhook.office.com/y"}, sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var payload map[string]string
+	if err := json.Unmarshal(data, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload["@type"] != "MessageCard" || payload["title"] != "Hefestus — kubernetes" || payload["summary"] != "Hefestus: Falta de memória" {
+		t.Errorf("unexpected card fields: %v", payload)
+	}
+	for _, want := range []string{"**Causa:** Falta de memória — tenant acme", "- `kubectl describe pod api`", "[Requisição req-123](https://hefestus.example/history/req-123)"} {
+		if !strings.Contains(payload["text"], want) {
+			t.Errorf("teams text missing %q:\n%s", want, payload["text"])
+		}
+	}
+}
+
+func TestBuildPayloadTemplate(t *testing.T) {
+	notification := sample
+	notification.ErrorDetails = strings.Repeat("a", maxErrorExcerpt) + "FIM"
+
+	data, err := buildPayload(models.NotificationSink{Type: SinkSlack, Template: "{{.Causa}}|{{.ErrorDetails}}"}, notification)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payload map[string]string
+	if err := json.Unmarshal(data, &payload); err != nil {
+		t.Fatal(err)
+	}
+	causa, details, _ := strings.Cut(payload["text"], "|")
+	if causa != "Falta de memória" {
+		t.Errorf("causa = %q", causa)
+	}
+	// O trecho do erro mantém o final, onde costuma estar a falha
+	if len(details) != maxErrorExcerpt || !strings.HasSuffix(details, "FIM") {
+		t.Errorf("error details not trimmed to the last %d runes: %d %q", maxErrorExcerpt, len(details), details[len(details)-5:])
+	}
+
+	if _, err := buildPayload(models.NotificationSink{Type: SinkSlack, Template: "{{.Causa"}, sample); err == nil {
+		t.Error("invalid template accepted")
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		wantErr  bool
+		attempts int32
+	}{
+		{name: "success", statuses: []int{http.StatusOK}, attempts: 1},
+		{name: "retries 429 and 5xx", statuses: []int{http.StatusTooManyRequests, http.StatusServiceUnavailable, http.StatusOK}, attempts: 3},
+		{name: "gives up after max attempts", statuses: []int{500, 502, 503, 200}, wantErr: true, attempts: 3},
+		{name: "permanent 4xx", statuses: []int{http.StatusNotFound, http.StatusOK}, wantErr: true, attempts: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newReceiver(t, tt.statuses...)
+			err := testNotifier(r).Deliver(context.Background(), models.NotificationSink{Type: SinkSlack, URL: r.URL}, sample)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Deliver() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := r.calls.Load(); got != tt.attempts {
+				t.Errorf("attempts = %d, want %d", got, tt.attempts)
+			}
+		})
+	}
+}
+
+func TestDeliverPermanentError(t *testing.T) {
+	r := newReceiver(t, http.StatusForbidden)
+	err := testNotifier(r).Deliver(context.Background(), models.NotificationSink{Type: SinkTeams, URL: r.URL}, sample)
+
+	var permanent *permanentError
+	if !errors.As(err, &permanent) || permanent.status != http.StatusForbidden {
+		t.Fatalf("expected permanent error with status 403, got %v", err)
+	}
+}
+
+func TestDeliverRedirects(t *testing.T) {
+	allowed, internal := newReceiver(t), newReceiver(t)
+	// O webhook aceito redireciona para o endereço informado em ?to=
+	redirector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		http.Redirect(w, req, req.URL.Query().Get("to"), http.StatusTemporaryRedirect)
+	}))
+	t.Cleanup(redirector.Close)
+	// internal só é alcançável por localhost, fora da lista permitida
+	internalURL := strings.Replace(internal.URL, "127.0.0.1", "localhost", 1)
+
+	n := NewNotifier()
+	n.allowedHosts = []string{"127.0.0.1"}
+	n.maxAttempts = 3
+	n.backoff = time.Millisecond
+
+	sink := models.NotificationSink{Type: SinkSlack, URL: redirector.URL + "/?to=" + url.QueryEscape(allowed.URL)}
+	if err := n.Deliver(context.Background(), sink, sample); err != nil {
+		t.Fatalf("redirect to an allowed host: %v", err)
+	}
+	if got := allowed.calls.Load(); got != 1 {
+		t.Errorf("allowed receiver called %d times, want 1", got)
+	}
+
+	sink.URL = redirector.URL + "/?to=" + url.QueryEscape(internalURL)
+	err := n.Deliver(context.Background(), sink, sample)
+	if !errors.Is(err, ErrSinkNotAllowed) {
+		t.Fatalf("redirect to a host outside the allowlist: %v", err)
+	}
+	if got := internal.calls.Load(); got != 0 {
+		t.Errorf("internal receiver called %d times after a redirect", got)
+	}
+}
+
+func TestDeliverRequestError(t *testing.T) {
+	r := newReceiver(t)
+	err := testNotifier(r).Deliver(context.Background(), models.NotificationSink{Type: SinkSlack, URL: "http://[::1"}, sample)
+
+	var permanent *permanentError
+	if !errors.As(err, &permanent) || permanent.err == nil || !strings.Contains(err.Error(), "missing ']'") {
+		t.Fatalf("expected permanent error with the cause, got %v", err)
+	}
+}
+
+func TestDeliverStopsOnCancel(t *testing.T) {
+	r := newReceiver(t, 500, 500, 500)
+	n := testNotifier(r)
+	n.backoff = time.Minute
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-r.bodies
+		cancel()
+	}()
+	if err := n.Deliver(ctx, models.NotificationSink{Type: SinkSlack, URL: r.URL}, sample); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestNotifySendsToEverySink(t *testing.T) {
+	slack, teams := newReceiver(t), newReceiver(t)
+	n := testNotifier(slack)
+	n.historyURL = "https://hefestus.example/h/"
+
+	notification := sample
+	notification.HistoryURL = ""
+	n.Notify(context.Background(), []models.NotificationSink{
+		{Type: SinkSlack, URL: slack.URL},
+		{Type: SinkTeams, URL: teams.URL},
+	}, notification)
+
+	for name, r := range map[string]*receiver{"slack": slack, "teams": teams} {
+		select {
+		case body := <-r.bodies:
+			if !strings.Contains(string(body), "https://hefestus.example/h/req-123") {
+				t.Errorf("%s payload without history link: %s", name, body)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s receiver got no notification", name)
+		}
+	}
+}
+
+func TestValidateRequestSinks(t *testing.T) {
+	n := &Notifier{allowedHosts: []string{"hooks.slack.com", "*.webhook.office.com"}}
+	tests := []struct {
+		sink    models.NotificationSink
+		allowed bool
+	}{
+		{models.NotificationSink{Type: SinkSlack, URL: "https://hooks.slack.com/services/x"}, true},
+		{models.NotificationSink{Type: SinkTeams, URL: "https://acme.webhook.office.com/webhookb2/x"}, true},
+		{models.NotificationSink{Type: SinkSlack, URL: "https://attacker.example/x"}, false},
+		{models.NotificationSink{Type: SinkSlack, URL: "https://hooks.slack.com.attacker.example/x"}, false},
+		{models.NotificationSink{Type: "email", URL: "https://hooks.slack.com/x"}, false},
+		{models.NotificationSink{Type: SinkSlack, URL: "ftp://hooks.slack.com/x"}, false},
+	}
+	for _, tt := range tests {
+		err := n.ValidateRequestSinks([]models.NotificationSink{tt.sink})
+		if tt.allowed && err != nil {
+			t.Errorf("%s rejected: %v", tt.sink.URL, err)
+		}
+		if !tt.allowed && !errors.Is(err, ErrSinkNotAllowed) {
+			t.Errorf("%s (%s) accepted", tt.sink.URL, tt.sink.Type)
+		}
+	}
+}
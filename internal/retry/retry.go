@@ -0,0 +1,108 @@
+// Package retry repete entregas HTTP com backoff exponencial, respeitando o
+// Retry-After do destino até um limite e o cancelamento do context.
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DefaultMaxWait limita a espera entre tentativas quando Policy.MaxWait é zero,
+// inclusive a pedida pelo destino em Retry-After
+const DefaultMaxWait = time.Minute
+
+// Policy define quantas tentativas fazer e quanto esperar entre elas
+type Policy struct {
+	MaxAttempts int
+	// Backoff é a primeira espera; dobra a cada nova falha
+	Backoff time.Duration
+	MaxWait time.Duration
+}
+
+// permanentError marca erros que não adianta repetir
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marca o erro como definitivo: Do para sem nova tentativa
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// retryAfterError carrega a espera pedida pelo destino
+type retryAfterError struct {
+	err  error
+	wait time.Duration
+}
+
+func (e *retryAfterError) Error() string { return e.err.Error() }
+func (e *retryAfterError) Unwrap() error { return e.err }
+
+// After pede que a próxima tentativa espere wait, como indicado em Retry-After
+func After(err error, wait time.Duration) error {
+	if wait <= 0 {
+		return err
+	}
+	return &retryAfterError{err: err, wait: wait}
+}
+
+// Do chama attempt até o sucesso, um erro permanente, o fim das tentativas ou
+// o cancelamento do context, e retorna quantas tentativas fez. onRetry, se não
+// for nil, é chamado antes de cada espera
+func (p Policy) Do(ctx context.Context, attempt func(n int) error, onRetry func(n int, wait time.Duration, err error)) (int, error) {
+	maxWait := p.MaxWait
+	if maxWait <= 0 {
+		maxWait = DefaultMaxWait
+	}
+
+	wait := p.Backoff
+	for n := 1; ; n++ {
+		err := attempt(n)
+		if err == nil {
+			return n, nil
+		}
+
+		var permanent *permanentError
+		if errors.As(err, &permanent) || n >= p.MaxAttempts {
+			return n, err
+		}
+
+		var after *retryAfterError
+		if errors.As(err, &after) {
+			wait = after.wait
+		}
+		wait = min(wait, maxWait)
+		if onRetry != nil {
+			onRetry(n, wait, err)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return n, ctx.Err()
+		case <-timer.C:
+		}
+		wait *= 2
+	}
+}
+
+// ParseRetryAfter interpreta Retry-After em segundos ou como data HTTP
+func ParseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(0, seconds)) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(0, date.Sub(now))
+	}
+	return 0
+}
@@ -0,0 +1,70 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestDoCapsRetryAfter(t *testing.T) {
+	policy := Policy{MaxAttempts: 2, Backoff: time.Millisecond, MaxWait: 5 * time.Millisecond}
+
+	var waits []time.Duration
+	attempts, err := policy.Do(context.Background(), func(n int) error {
+		if n == 1 {
+			return After(errors.New("busy"), time.Hour)
+		}
+		return nil
+	}, func(_ int, wait time.Duration, _ error) {
+		waits = append(waits, wait)
+	})
+	if err != nil || attempts != 2 {
+		t.Fatalf("Do() = %d, %v; want 2, nil", attempts, err)
+	}
+	if len(waits) != 1 || waits[0] != 5*time.Millisecond {
+		t.Errorf("waits = %v, want [5ms]", waits)
+	}
+}
+
+func TestDoStopsOnPermanent(t *testing.T) {
+	rejected := errors.New("rejected")
+	attempts, err := Policy{MaxAttempts: 5, Backoff: time.Millisecond}.Do(context.Background(), func(int) error {
+		return Permanent(rejected)
+	}, nil)
+	if attempts != 1 || !errors.Is(err, rejected) {
+		t.Fatalf("Do() = %d, %v; want 1, rejected", attempts, err)
+	}
+}
+
+func TestDoStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := Policy{MaxAttempts: 3, Backoff: time.Minute}.Do(ctx, func(int) error {
+		cancel()
+		return errors.New("unavailable")
+	}, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"30", 30 * time.Second},
+		{"-5", 0},
+		{now.Add(2 * time.Minute).Format(http.TimeFormat), 2 * time.Minute},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+		{"amanhã", 0},
+	}
+	for _, tt := range tests {
+		if got := ParseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("ParseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
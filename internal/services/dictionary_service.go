@@ -1,48 +1,246 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"hefestus-api/internal/actions"
 	"hefestus-api/internal/models"
-	"log"
+	"hefestus-api/internal/notifier"
+	"hefestus-api/internal/tenant"
+	"hefestus-api/internal/tracing"
+	"hefestus-api/pkg/ollama"
+	"log/slog"
 	"os"
+	"path/filepath"
 	"regexp"
+	"sort"
 	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type DictionaryService struct {
 	dictionaries map[string]*models.ErrorDictionary
 	domains      map[string]models.DomainConfig
+	models       map[string]ollama.ModelConfig
+	tenants      map[string]*tenantScope
 	mu           sync.RWMutex
 }
 
-func NewDictionaryService() (*DictionaryService, error) {
+// tenantScope guarda as configurações e dicionários já mesclados com o global
+type tenantScope struct {
+	dictionaries map[string]*models.ErrorDictionary
+	domains      map[string]models.DomainConfig
+}
+
+func NewDictionaryService(baseDir string) (*DictionaryService, error) {
 	// Load domains configuration
-	domainsConfig, err := loadDomainsConfig()
+	domainsConfig, err := loadDomainsConfig(baseDir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load domains config: %w", err)
 	}
 
+	if err := validateDomains(domainsConfig.Domains); err != nil {
+		return nil, err
+	}
+
 	dictionaries := make(map[string]*models.ErrorDictionary)
 
 	// Load dictionaries based on domain configurations
 	for domain, config := range domainsConfig.Domains {
-		dict, err := loadDomainDictionary(config.DictionaryPath)
+		dict, err := loadDomainDictionary(resolvePath(baseDir, config.DictionaryPath))
 		if err != nil {
-			log.Printf("Warning: couldn't load dictionary for domain %s: %v", domain, err)
+			slog.Warn("couldn't load dictionary", "domain", domain, "error", err)
 			continue
 		}
 		dictionaries[domain] = dict
 	}
 
+	tenantsConfig, err := loadTenantsConfig(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tenants config: %w", err)
+	}
+
+	tenants := make(map[string]*tenantScope, len(tenantsConfig.Tenants))
+	for name, config := range tenantsConfig.Tenants {
+		scope, err := buildTenantScope(baseDir, config, domainsConfig.Domains, dictionaries)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %s: %w", name, err)
+		}
+		tenants[name] = scope
+	}
+
 	return &DictionaryService{
 		dictionaries: dictionaries,
 		domains:      domainsConfig.Domains,
+		models:       domainsConfig.Models,
+		tenants:      tenants,
 	}, nil
 }
 
-func loadDomainsConfig() (*models.DomainsConfig, error) {
-	data, err := os.ReadFile("config/domains.json")
+// resolvePath interpreta caminhos relativos da configuração a partir de
+// baseDir; vazio mantém o diretório de trabalho, como no servidor
+func resolvePath(baseDir string, path string) string {
+	if baseDir == "" || path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(baseDir, path)
+}
+
+// loadTenantsConfig lê TENANTS_CONFIG (padrão config/tenants.json em baseDir);
+// sem arquivo, apenas o escopo global existe
+func loadTenantsConfig(baseDir string) (*models.TenantsConfig, error) {
+	path := os.Getenv("TENANTS_CONFIG")
+	if path == "" {
+		path = resolvePath(baseDir, "config/tenants.json")
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &models.TenantsConfig{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tenants config: %w", err)
+	}
+
+	var config models.TenantsConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse tenants config: %w", err)
+	}
+
+	return &config, nil
+}
+
+// buildTenantScope herda todos os domínios e dicionários globais e aplica as
+// sobrescritas do tenant
+func buildTenantScope(baseDir string, config models.TenantConfig, baseDomains map[string]models.DomainConfig, baseDictionaries map[string]*models.ErrorDictionary) (*tenantScope, error) {
+	scope := &tenantScope{
+		dictionaries: make(map[string]*models.ErrorDictionary, len(baseDictionaries)),
+		domains:      make(map[string]models.DomainConfig, len(baseDomains)),
+	}
+	for domain, domainConfig := range baseDomains {
+		scope.domains[domain] = domainConfig
+	}
+	for domain, dict := range baseDictionaries {
+		scope.dictionaries[domain] = dict
+	}
+
+	for domain, override := range config.Domains {
+		base, exists := baseDomains[domain]
+		if !exists {
+			return nil, fmt.Errorf("unknown domain: %s", domain)
+		}
+		scope.domains[domain] = mergeDomainConfig(base, override)
+
+		if override.DictionaryPath == "" {
+			continue
+		}
+		dict, err := loadDomainDictionary(resolvePath(baseDir, override.DictionaryPath))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load dictionary for domain %s: %w", domain, err)
+		}
+		scope.dictionaries[domain] = mergeDictionaries(baseDictionaries[domain], dict)
+	}
+
+	if err := validateDomains(scope.domains); err != nil {
+		return nil, err
+	}
+	return scope, nil
+}
+
+// mergeDomainConfig aplica sobre base os campos preenchidos em override
+func mergeDomainConfig(base models.DomainConfig, override models.DomainConfig) models.DomainConfig {
+	merged := base
+	if override.Name != "" {
+		merged.Name = override.Name
+	}
+	if override.PromptTemplate != "" {
+		merged.PromptTemplate = override.PromptTemplate
+	}
+	if override.ContextPolicy != "" {
+		merged.ContextPolicy = override.ContextPolicy
+	}
+	if override.DictionaryPath != "" {
+		merged.DictionaryPath = override.DictionaryPath
+	}
+
+	if len(override.Parameters) > 0 {
+		merged.Parameters = make(map[string]interface{}, len(base.Parameters)+len(override.Parameters))
+		for key, value := range base.Parameters {
+			merged.Parameters[key] = value
+		}
+		for key, value := range override.Parameters {
+			merged.Parameters[key] = value
+		}
+	}
+
+	if override.Preprocessing.MaxInputTokens > 0 {
+		merged.Preprocessing.MaxInputTokens = override.Preprocessing.MaxInputTokens
+	}
+	if override.Preprocessing.ContextLines > 0 {
+		merged.Preprocessing.ContextLines = override.Preprocessing.ContextLines
+	}
+
+	if override.RateLimit.RequestsPerMinute > 0 {
+		merged.RateLimit.RequestsPerMinute = override.RateLimit.RequestsPerMinute
+	}
+	if override.RateLimit.Burst > 0 {
+		merged.RateLimit.Burst = override.RateLimit.Burst
+	}
+	if override.RateLimit.DailyLLMQuota > 0 {
+		merged.RateLimit.DailyLLMQuota = override.RateLimit.DailyLLMQuota
+	}
+
+	if override.Notifications != nil {
+		merged.Notifications = override.Notifications
+	}
+	if override.Actions != nil {
+		merged.Actions = override.Actions
+	}
+	if override.KubernetesEnrichment != nil {
+		merged.KubernetesEnrichment = override.KubernetesEnrichment
+	}
+
+	return merged
+}
+
+// mergeDictionaries cria um dicionário com os padrões globais e os do tenant,
+// que prevalecem em caso de nome repetido
+func mergeDictionaries(base *models.ErrorDictionary, override *models.ErrorDictionary) *models.ErrorDictionary {
+	merged := &models.ErrorDictionary{Patterns: make(map[string]models.ErrorPattern)}
+	if base != nil {
+		for name, pattern := range base.Patterns {
+			merged.Patterns[name] = pattern
+		}
+	}
+	for name, pattern := range override.Patterns {
+		merged.Patterns[name] = pattern
+	}
+	return merged
+}
+
+// HasTenant indica se o tenant está configurado
+func (s *DictionaryService) HasTenant(name string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, exists := s.tenants[name]
+	return exists
+}
+
+// scope resolve os domínios e dicionários do tenant da requisição; deve ser
+// chamado com mu travado
+func (s *DictionaryService) scope(ctx context.Context) (map[string]models.DomainConfig, map[string]*models.ErrorDictionary) {
+	if scope, exists := s.tenants[tenant.FromContext(ctx)]; exists {
+		return scope.domains, scope.dictionaries
+	}
+	return s.domains, s.dictionaries
+}
+
+func loadDomainsConfig(baseDir string) (*models.DomainsConfig, error) {
+	data, err := os.ReadFile(resolvePath(baseDir, "config/domains.json"))
 	if err != nil {
 		return nil, fmt.Errorf("failed to read domains config: %w", err)
 	}
@@ -55,11 +253,61 @@ func loadDomainsConfig() (*models.DomainsConfig, error) {
 	return &config, nil
 }
 
-func (s *DictionaryService) GetDomainConfig(domain string) (models.DomainConfig, bool) {
+// validateDomains rejeita parâmetros que o Ollama ignoraria, políticas de
+// contexto desconhecidas e notificações ou ações inválidas antes que o
+// servidor aceite requisições
+func validateDomains(domains map[string]models.DomainConfig) error {
+	for domain, config := range domains {
+		if _, err := ollama.NormalizeOptions(config.Parameters); err != nil {
+			return fmt.Errorf("invalid parameters for domain %s: %w", domain, err)
+		}
+
+		switch config.ContextPolicy {
+		case "", ollama.ContextPolicyTrim, ollama.ContextPolicyReject:
+		default:
+			return fmt.Errorf("invalid context_policy for domain %s: %q", domain, config.ContextPolicy)
+		}
+
+		for _, sink := range config.Notifications {
+			if err := notifier.ValidateSink(sink); err != nil {
+				return fmt.Errorf("invalid notification for domain %s: %w", domain, err)
+			}
+		}
+
+		for _, action := range config.Actions {
+			if err := actions.ValidateAction(action); err != nil {
+				return fmt.Errorf("invalid action for domain %s: %w", domain, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Domains retorna os nomes dos domínios configurados, em ordem alfabética
+func (s *DictionaryService) Domains() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names := make([]string, 0, len(s.domains))
+	for name := range s.domains {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Models retorna a janela de contexto dos modelos configurados em domains.json
+func (s *DictionaryService) Models() map[string]ollama.ModelConfig {
+	return s.models
+}
+
+// GetDomainConfig retorna a configuração do domínio no escopo do tenant da requisição
+func (s *DictionaryService) GetDomainConfig(ctx context.Context, domain string) (models.DomainConfig, bool) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	config, exists := s.domains[domain]
+	domains, _ := s.scope(ctx)
+	config, exists := domains[domain]
 	return config, exists
 }
 
@@ -74,14 +322,45 @@ func loadDomainDictionary(path string) (*models.ErrorDictionary, error) {
 		return nil, err
 	}
 
+	// A chave do padrão no arquivo identifica o padrão em métricas e logs
+	for name, pattern := range dict.Patterns {
+		for _, action := range pattern.Actions {
+			if err := actions.ValidateAction(action); err != nil {
+				return nil, fmt.Errorf("invalid action for pattern %s: %w", name, err)
+			}
+		}
+		pattern.Name = name
+		dict.Patterns[name] = pattern
+	}
+
 	return &dict, nil
 }
 
-func (s *DictionaryService) FindMatches(domain string, errorText string) []models.ErrorPattern {
+// Pattern retorna um padrão do dicionário do domínio no escopo do tenant
+func (s *DictionaryService) Pattern(ctx context.Context, domain string, name string) (models.ErrorPattern, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, dictionaries := s.scope(ctx)
+	dict, ok := dictionaries[domain]
+	if !ok {
+		return models.ErrorPattern{}, false
+	}
+	pattern, ok := dict.Patterns[name]
+	return pattern, ok
+}
+
+// FindMatches retorna os padrões do domínio que casam com o erro, ordenados
+// pelo nome para que o prompt (e a chave do cassete) não dependa da ordem do map
+func (s *DictionaryService) FindMatches(ctx context.Context, domain string, errorText string) []models.ErrorPattern {
+	_, span := tracing.Start(ctx, "DictionaryService.FindMatches", attribute.String("hefestus.domain", domain))
+	defer span.End()
+
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	dict, ok := s.dictionaries[domain]
+	_, dictionaries := s.scope(ctx)
+	dict, ok := dictionaries[domain]
 	if !ok {
 		return nil
 	}
@@ -92,5 +371,8 @@ func (s *DictionaryService) FindMatches(domain string, errorText string) []model
 			matches = append(matches, pattern)
 		}
 	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Name < matches[j].Name })
+
+	span.SetAttributes(attribute.Int("hefestus.dictionary.matches", len(matches)))
 	return matches
 }
@@ -3,19 +3,32 @@ package services
 import (
 	"context"
 	"errors"
+	"hefestus-api/internal/actions"
+	"hefestus-api/internal/enrichment"
+	"hefestus-api/internal/logging"
 	"hefestus-api/internal/models"
-	"log"
+	"hefestus-api/internal/notifier"
+	"hefestus-api/internal/tenant"
+	"strings"
 )
 
 // ErrorService define o serviço para processamento de erros
 type ErrorService struct {
-	llmService *LLMService
+	llmService        *LLMService
+	preprocessService *PreprocessService
+	notifier          *notifier.Notifier
+	actionRunner      *actions.Runner
+	kubeEnricher      *enrichment.Kubernetes
 }
 
 // NewErrorService cria uma nova instância do serviço de erros
-func NewErrorService(llmService *LLMService) *ErrorService {
+func NewErrorService(llmService *LLMService, preprocessService *PreprocessService, notifier *notifier.Notifier, actionRunner *actions.Runner, kubeEnricher *enrichment.Kubernetes) *ErrorService {
 	return &ErrorService{
-		llmService: llmService,
+		llmService:        llmService,
+		preprocessService: preprocessService,
+		notifier:          notifier,
+		actionRunner:      actionRunner,
+		kubeEnricher:      kubeEnricher,
 	}
 }
 
@@ -26,17 +39,106 @@ func (s *ErrorService) ProcessError(ctx context.Context, domain string, req mode
 		return nil, errors.New("error details cannot be empty")
 	}
 
-	log.Printf("Processando erro no domínio %s: %s", domain, req.ErrorDetails)
+	// Só os destinos do cliente passam pela allowlist; ConfiguredNotify já foi
+	// validado ao carregar a configuração
+	if err := s.notifier.ValidateRequestSinks(req.Notify); err != nil {
+		return nil, err
+	}
+
+	if err := actions.ValidateMode(ctx, req.ActionMode); err != nil {
+		return nil, err
+	}
+
+	if err := enrichment.ValidateRef(req.Kubernetes); err != nil {
+		return nil, err
+	}
+
+	logger := logging.FromContext(ctx).With("domain", domain)
+
+	// Reduz o log ao trecho relevante antes de montar o prompt
+	errorDetails, report := s.preprocessService.Process(ctx, domain, req.ErrorDetails)
+
+	logger.Info("processando erro",
+		"original_tokens", report.OriginalTokens,
+		"result_tokens", report.ResultTokens,
+		"token_budget", report.TokenBudget,
+		"truncated", report.Truncated)
+	logger.Debug("detalhes do erro pré-processados", "error_details", logging.Redact(errorDetails))
+
+	// Anexa ao contexto o estado atual do recurso citado, se o domínio permitir
+	promptContext, enrichmentReport := s.enrich(ctx, domain, req)
 
 	// Obter resolução através do serviço LLM
-	solution, err := s.llmService.GetResolution(ctx, domain, req.ErrorDetails, req.Context)
+	solution, err := s.llmService.GetResolution(ctx, domain, errorDetails, promptContext)
 	if err != nil {
-		log.Printf("Erro ao obter resolução: %v", err)
+		logger.Error("erro ao obter resolução", "error", err)
 		return nil, err
 	}
 
+	s.notify(ctx, domain, req, solution)
+
 	return &models.ErrorResponse{
-		Error:   solution,
-		Message: "Possíveis resoluções recuperadas com sucesso",
+		Error:         solution,
+		Message:       "Análise concluída com sucesso",
+		Preprocessing: report,
+		Enrichment:    enrichmentReport,
+		Actions:       s.runActions(ctx, domain, req, errorDetails, solution),
 	}, nil
 }
+
+// enrich retorna o contexto do prompt com os detalhes buscados no cluster; o
+// context original segue para notificações e ações
+func (s *ErrorService) enrich(ctx context.Context, domain string, req models.ErrorRequest) (string, *models.EnrichmentReport) {
+	config, _ := s.llmService.dictService.GetDomainConfig(ctx, domain)
+	if req.Kubernetes == nil || s.kubeEnricher == nil || config.KubernetesEnrichment == nil || !config.KubernetesEnrichment.Enabled {
+		return req.Context, nil
+	}
+
+	details, report := s.kubeEnricher.Enrich(ctx, *config.KubernetesEnrichment, *req.Kubernetes)
+	if details == "" {
+		return req.Context, report
+	}
+	return strings.TrimSpace(req.Context + "\n\nEstado atual no cluster:\n" + details), report
+}
+
+// runActions planeja ou dispara as ações dos padrões do dicionário que
+// casaram e as ações do domínio
+func (s *ErrorService) runActions(ctx context.Context, domain string, req models.ErrorRequest, errorDetails string, solution *models.ErrorSolution) []models.ActionResult {
+	var patterns []models.ErrorPattern
+	for _, name := range solution.Patterns {
+		if pattern, ok := s.llmService.dictService.Pattern(ctx, domain, name); ok && len(pattern.Actions) > 0 {
+			patterns = append(patterns, pattern)
+		}
+	}
+	config, _ := s.llmService.dictService.GetDomainConfig(ctx, domain)
+
+	return s.actionRunner.Run(ctx, req.ActionMode, actions.Analysis{
+		Domain:        domain,
+		ErrorDetails:  errorDetails,
+		OriginalError: logging.Redact(req.ErrorDetails),
+		Context:       req.Context,
+		Solution:      solution,
+	}, patterns, config.Actions)
+}
+
+// notify envia o diagnóstico aos webhooks do domínio, aos configurados pelo
+// operador e aos informados na requisição
+func (s *ErrorService) notify(ctx context.Context, domain string, req models.ErrorRequest, solution *models.ErrorSolution) {
+	config, _ := s.llmService.dictService.GetDomainConfig(ctx, domain)
+	sinks := append([]models.NotificationSink{}, config.Notifications...)
+	sinks = append(append(sinks, req.ConfiguredNotify...), req.Notify...)
+	if len(sinks) == 0 {
+		return
+	}
+
+	s.notifier.Notify(ctx, sinks, notifier.Notification{
+		Domain:       domain,
+		Tenant:       tenant.FromContext(ctx),
+		RequestID:    logging.RequestID(ctx),
+		Causa:        solution.Causa,
+		Steps:        strings.Split(solution.Solucao, "\n"),
+		References:   solution.References,
+		ErrorDetails: logging.Redact(req.ErrorDetails),
+		Context:      req.Context,
+	})
+}
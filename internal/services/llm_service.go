@@ -3,26 +3,84 @@ package services
 import (
 	"context"
 	"fmt"
+	"hefestus-api/internal/logging"
+	"hefestus-api/internal/metrics"
 	"hefestus-api/internal/models"
+	"hefestus-api/internal/tenant"
+	"hefestus-api/internal/usage"
 	"hefestus-api/pkg/ollama"
+	"sort"
 	"strings"
 )
 
 type LLMService struct {
 	ollamaClient *ollama.Client
 	dictService  *DictionaryService
+	cache        *resolutionCache
 }
 
+// NewLLMService cria o serviço; com LLM_CACHE_TTL definido os diagnósticos
+// ficam em cache por esse tempo
 func NewLLMService(ollamaClient *ollama.Client, dictService *DictionaryService) *LLMService {
 	return &LLMService{
 		ollamaClient: ollamaClient,
 		dictService:  dictService,
+		cache:        newResolutionCacheFromEnv(),
 	}
 }
 
+// NewOllamaClient cria o cliente do Ollama com as janelas de contexto de
+// domains.json, o logger da requisição e as métricas e o consumo de tokens do
+// servidor; as opções informadas têm precedência
+func NewOllamaClient(dictService *DictionaryService, options ...ollama.ClientOption) *ollama.Client {
+	defaults := []ollama.ClientOption{
+		ollama.WithModels(dictService.Models()),
+		ollama.WithLogger(logging.FromContext),
+		ollama.WithObserver(llmObserver{}),
+	}
+	return ollama.NewClient(append(defaults, options...)...)
+}
+
+// llmObserver exporta as métricas das chamadas ao Ollama e soma os tokens ao
+// consumo da requisição
+type llmObserver struct{}
+
+func (llmObserver) CallStarted(ctx context.Context, domain string, model string) {
+	metrics.LLMInFlight.Inc()
+}
+
+func (llmObserver) CallFinished(ctx context.Context, call ollama.Call) {
+	usage.RecordTokens(ctx, call.PromptTokens, call.CompletionTokens)
+	// Respostas reproduzidas do cassete não passaram pelo Ollama
+	if call.Outcome == ollama.OutcomeReplay {
+		return
+	}
+
+	metrics.LLMInFlight.Dec()
+	metrics.LLMRequestDuration.WithLabelValues(call.Domain, call.Model, call.Outcome).Observe(call.Duration.Seconds())
+	if call.Outcome == ollama.OutcomeSuccess {
+		metrics.LLMPromptTokens.WithLabelValues(call.Domain, call.Model).Add(float64(call.PromptTokens))
+		metrics.LLMCompletionTokens.WithLabelValues(call.Domain, call.Model).Add(float64(call.CompletionTokens))
+	}
+}
+
+func (llmObserver) ParseFailed(ctx context.Context, domain string, reason string) {
+	metrics.LLMParseFailures.WithLabelValues(domain, reason).Inc()
+}
+
 func (s *LLMService) GetResolution(ctx context.Context, domain string, errorDetails string, errorContext string) (*models.ErrorSolution, error) {
+	config, exists := s.dictService.GetDomainConfig(ctx, domain)
+	if !exists {
+		return nil, fmt.Errorf("unknown domain: %s", domain)
+	}
+
 	// Check dictionary first - Pass both domain and errorDetails
-	matches := s.dictService.FindMatches(domain, errorDetails)
+	matches := s.dictService.FindMatches(ctx, domain, errorDetails)
+
+	metrics.DictionaryLookups.WithLabelValues(domain).Inc()
+	for _, match := range matches {
+		metrics.DictionaryMatches.WithLabelValues(domain, match.Name).Inc()
+	}
 
 	var knownSolutions string
 	if len(matches) > 0 {
@@ -33,17 +91,66 @@ func (s *LLMService) GetResolution(ctx context.Context, domain string, errorDeta
 		}
 	}
 
+	// Diagnósticos em cache não contam como chamada ao modelo na cota
+	var cacheKey string
+	if s.cache != nil {
+		cacheKey = resolutionKey(tenant.FromContext(ctx), domain, errorDetails+knownSolutions, errorContext)
+		if solution, ok := s.cache.get(cacheKey); ok {
+			metrics.ResolutionCache.WithLabelValues(domain, "hit").Inc()
+			return solution, nil
+		}
+		metrics.ResolutionCache.WithLabelValues(domain, "miss").Inc()
+	}
+
 	// Enhanced prompt with dictionary knowledge
+	usage.RecordLLMCall(ctx)
 	causa, solucao, err := s.ollamaClient.Query(ctx,
 		errorDetails+knownSolutions,
 		domain,
+		ollama.DomainConfig{
+			Name:           config.Name,
+			PromptTemplate: config.PromptTemplate,
+			Parameters:     config.Parameters,
+			ContextPolicy:  config.ContextPolicy,
+		},
 		errorContext)
 	if err != nil {
 		return nil, err
 	}
 
-	return &models.ErrorSolution{
-		Causa:   causa,
-		Solucao: solucao,
-	}, nil
+	solution := &models.ErrorSolution{
+		Causa:      causa,
+		Solucao:    solucao,
+		References: collectReferences(matches),
+		Patterns:   patternNames(matches),
+	}
+	if s.cache != nil {
+		s.cache.put(cacheKey, solution)
+	}
+	return solution, nil
+}
+
+// collectReferences junta as referências dos padrões encontrados, sem repetição
+func collectReferences(matches []models.ErrorPattern) []string {
+	seen := make(map[string]bool)
+	var references []string
+	for _, match := range matches {
+		for _, reference := range match.References {
+			if !seen[reference] {
+				seen[reference] = true
+				references = append(references, reference)
+			}
+		}
+	}
+	return references
+}
+
+// patternNames lista os nomes dos padrões encontrados, em ordem alfabética
+func patternNames(matches []models.ErrorPattern) []string {
+	var names []string
+	for _, match := range matches {
+		names = append(names, match.Name)
+	}
+	sort.Strings(names)
+	return names
 }
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"hefestus-api/pkg/ollama"
+	"hefestus-api/pkg/ollama/ollamatest"
+)
+
+// testErrorDetails casa com todos os padrões de writeConfig
+const testErrorDetails = "Warning BackOff: CrashLoopBackOff after OOMKilled, ImagePullBackOff on sidecar"
+
+// writeConfig cria em um diretório temporário um domínio kubernetes com a
+// temperatura informada e um dicionário de vários padrões
+func writeConfig(t *testing.T, dir string, temperature float64) {
+	t.Helper()
+	files := map[string]string{
+		"config/domains.json": fmt.Sprintf(`{
+  "domains": {
+    "kubernetes": {
+      "prompt_template": "Analise o erro do Kubernetes.",
+      "parameters": {"temperature": %g, "max_tokens": 100},
+      "dictionary_path": "patterns.json"
+    }
+  }
+}`, temperature),
+		"patterns.json": `{
+  "patterns": {
+    "pod_crash_loop": {"pattern": "CrashLoopBackOff", "category": "POD_LIFECYCLE", "solutions": ["kubectl logs --previous"]},
+    "oom_killed": {"pattern": "OOMKilled", "category": "RESOURCE_LIMITS", "solutions": ["aumentar limits.memory"]},
+    "image_pull_backoff": {"pattern": "ImagePullBackOff", "category": "IMAGE", "solutions": ["verificar a imagem"]},
+    "back_off": {"pattern": "BackOff", "category": "POD_LIFECYCLE", "solutions": ["kubectl describe pod"]}
+  }
+}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func newTestLLMService(t *testing.T, dir string, options ...ollama.ClientOption) *LLMService {
+	t.Helper()
+	dictService, err := NewDictionaryService(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewLLMService(NewOllamaClient(dictService, options...), dictService)
+}
+
+func TestFindMatchesSortedByName(t *testing.T) {
+	dir := t.TempDir()
+	writeConfig(t, dir, 0.2)
+	dictService, err := NewDictionaryService(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"back_off", "image_pull_backoff", "oom_killed", "pod_crash_loop"}
+	for i := 0; i < 20; i++ {
+		var names []string
+		for _, match := range dictService.FindMatches(context.Background(), "kubernetes", testErrorDetails) {
+			names = append(names, match.Name)
+		}
+		if fmt.Sprint(names) != fmt.Sprint(want) {
+			t.Fatalf("FindMatches() = %v, want %v", names, want)
+		}
+	}
+}
+
+func TestCassetteRecordAndReplay(t *testing.T) {
+	t.Setenv("LLM_CACHE_TTL", "")
+	dir := t.TempDir()
+	writeConfig(t, dir, 0.2)
+	cassetteDir := filepath.Join(dir, "cassettes")
+
+	srv := ollamatest.NewServer()
+	srv.Enqueue(ollamatest.Diagnosis("Container sem memória", "kubectl top pod"))
+	record, err := ollama.NewCassette(ollama.CassetteRecord, cassetteDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded, err := newTestLLMService(t, dir, ollama.WithBaseURL(srv.URL), ollama.WithCassette(record)).
+		GetResolution(context.Background(), "kubernetes", testErrorDetails, "Deployment api")
+	srv.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// O replay não chama o Ollama e precisa achar a gravação em toda chamada,
+	// qualquer que seja a ordem do map de padrões
+	replay, err := ollama.NewCassette(ollama.CassetteReplay, cassetteDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		replayed, err := newTestLLMService(t, dir, ollama.WithBaseURL(srv.URL), ollama.WithCassette(replay)).
+			GetResolution(context.Background(), "kubernetes", testErrorDetails, "Deployment api")
+		if err != nil {
+			t.Fatalf("replay %d: %v", i, err)
+		}
+		if replayed.Causa != recorded.Causa || replayed.Solucao != recorded.Solucao || fmt.Sprint(replayed.Patterns) != fmt.Sprint(recorded.Patterns) {
+			t.Fatalf("replay %d = %+v, recorded %+v", i, replayed, recorded)
+		}
+	}
+
+	// Mudar as opções do domínio invalida a gravação
+	writeConfig(t, dir, 0.9)
+	_, err = newTestLLMService(t, dir, ollama.WithBaseURL(srv.URL), ollama.WithCassette(replay)).
+		GetResolution(context.Background(), "kubernetes", testErrorDetails, "Deployment api")
+	if !errors.Is(err, ollama.ErrCassetteMiss) {
+		t.Fatalf("expected ErrCassetteMiss after changing temperature, got %v", err)
+	}
+}
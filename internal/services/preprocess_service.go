@@ -0,0 +1,218 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"hefestus-api/internal/models"
+	"hefestus-api/pkg/ollama"
+	"regexp"
+	"strings"
+)
+
+const (
+	defaultMaxInputTokens = 2048
+	defaultContextLines   = 5
+)
+
+var (
+	ansiPattern      = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07]*\x07`)
+	timestampPattern = regexp.MustCompile(`^\s*(\[?\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})?\]?|\[?\d{2}:\d{2}:\d{2}(\.\d+)?\]?|[A-Z][a-z]{2} +\d{1,2} \d{2}:\d{2}:\d{2})\s*`)
+	failurePattern   = regexp.MustCompile(`(?i)(\berror\b|\berro\b|exception|\bfatal\b|\bpanic\b|\bfailed\b|\bfailure\b|traceback|exit code|exit status|exited with|^\s+at\s|^\s*File ".+", line \d+|^goroutine \d+)`)
+)
+
+// PreprocessService limpa e reduz logs extensos antes de enviá-los ao LLM
+type PreprocessService struct {
+	dictService *DictionaryService
+}
+
+// NewPreprocessService cria um novo serviço de pré-processamento
+func NewPreprocessService(dictService *DictionaryService) *PreprocessService {
+	return &PreprocessService{
+		dictService: dictService,
+	}
+}
+
+// Process remove ruído do log, extrai as regiões de falha e ajusta o resultado
+// ao orçamento de tokens do domínio, informando o que foi descartado
+func (s *PreprocessService) Process(ctx context.Context, domain string, text string) (string, *models.PreprocessReport) {
+	cfg := s.configFor(ctx, domain)
+	report := &models.PreprocessReport{
+		OriginalTokens: ollama.EstimateTokens(text),
+		TokenBudget:    cfg.MaxInputTokens,
+	}
+
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	report.OriginalLines = len(lines)
+
+	lines = cleanLines(lines, report)
+	lines = collapseRepeated(lines, report)
+
+	if estimateLinesTokens(lines) > cfg.MaxInputTokens {
+		lines = extractErrorRegions(lines, cfg.ContextLines, report)
+	}
+	if estimateLinesTokens(lines) > cfg.MaxInputTokens {
+		lines = truncateToBudget(lines, cfg.MaxInputTokens, report)
+	}
+
+	result := strings.TrimSpace(strings.Join(lines, "\n"))
+	report.ResultLines = len(lines)
+	report.ResultTokens = ollama.EstimateTokens(result)
+
+	return result, report
+}
+
+func (s *PreprocessService) configFor(ctx context.Context, domain string) models.PreprocessConfig {
+	var cfg models.PreprocessConfig
+	if domainConfig, exists := s.dictService.GetDomainConfig(ctx, domain); exists {
+		cfg = domainConfig.Preprocessing
+	}
+	if cfg.MaxInputTokens <= 0 {
+		cfg.MaxInputTokens = defaultMaxInputTokens
+	}
+	if cfg.ContextLines <= 0 {
+		cfg.ContextLines = defaultContextLines
+	}
+	return cfg
+}
+
+// cleanLines remove códigos ANSI, sobrescritas de terminal e timestamps iniciais
+func cleanLines(lines []string, report *models.PreprocessReport) []string {
+	cleaned := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if codes := ansiPattern.FindAllStringIndex(line, -1); len(codes) > 0 {
+			report.ANSICodesRemoved += len(codes)
+			line = ansiPattern.ReplaceAllString(line, "")
+		}
+
+		// Barras de progresso reescrevem a linha com \r; apenas o último estado interessa
+		if i := strings.LastIndex(line, "\r"); i >= 0 {
+			line = line[i+1:]
+		}
+
+		if loc := timestampPattern.FindStringIndex(line); loc != nil {
+			report.TimestampsRemoved++
+			line = line[loc[1]:]
+		}
+
+		cleaned = append(cleaned, strings.TrimRight(line, " \t"))
+	}
+	return cleaned
+}
+
+// collapseRepeated agrupa linhas consecutivas idênticas em uma única ocorrência
+func collapseRepeated(lines []string, report *models.PreprocessReport) []string {
+	collapsed := make([]string, 0, len(lines))
+	for i := 0; i < len(lines); {
+		j := i + 1
+		for j < len(lines) && lines[j] == lines[i] {
+			j++
+		}
+
+		count := j - i
+		switch {
+		case count == 1:
+			collapsed = append(collapsed, lines[i])
+		case strings.TrimSpace(lines[i]) == "":
+			collapsed = append(collapsed, "")
+		default:
+			collapsed = append(collapsed, fmt.Sprintf("%s [repetida %d vezes]", lines[i], count))
+		}
+		report.DuplicateLinesCollapsed += count - 1
+		i = j
+	}
+	return collapsed
+}
+
+// extractErrorRegions mantém apenas as linhas próximas a marcadores de falha,
+// além do final do log, onde normalmente está o resumo da execução
+func extractErrorRegions(lines []string, contextLines int, report *models.PreprocessReport) []string {
+	keep := make([]bool, len(lines))
+	for i, line := range lines {
+		if !failurePattern.MatchString(line) {
+			continue
+		}
+		for j := max(0, i-contextLines); j <= min(len(lines)-1, i+contextLines); j++ {
+			keep[j] = true
+		}
+	}
+	for j := max(0, len(lines)-contextLines); j < len(lines); j++ {
+		keep[j] = true
+	}
+
+	var extracted []string
+	omitted := 0
+	for i, line := range lines {
+		if !keep[i] {
+			omitted++
+			continue
+		}
+		if omitted > 0 || len(extracted) == 0 {
+			report.ErrorRegions++
+		}
+		if omitted > 0 {
+			extracted = append(extracted, omittedMarker(omitted))
+			report.LinesDropped += omitted
+			omitted = 0
+		}
+		extracted = append(extracted, line)
+	}
+	return extracted
+}
+
+// truncateToBudget preserva o início (primeiro erro) e o fim (falha final) do
+// log, descartando o meio até que o resultado caiba no orçamento
+func truncateToBudget(lines []string, budget int, report *models.PreprocessReport) []string {
+	report.Truncated = true
+
+	headBudget := budget / 3
+	tailBudget := budget - headBudget
+
+	head := 0
+	used := 0
+	for head < len(lines) {
+		cost := ollama.EstimateTokens(lines[head]) + 1
+		if used+cost > headBudget {
+			break
+		}
+		used += cost
+		head++
+	}
+
+	tail := len(lines)
+	used = 0
+	for tail > head {
+		cost := ollama.EstimateTokens(lines[tail-1]) + 1
+		if used+cost > tailBudget {
+			break
+		}
+		used += cost
+		tail--
+	}
+
+	// Uma única linha maior que o orçamento (ex.: JSON minificado) é cortada
+	// pela mesma estimativa de tokens, já que pontuação conta um token por caractere
+	if head == 0 && tail == len(lines) {
+		last := lines[len(lines)-1]
+		report.LinesDropped += len(lines) - 1
+		return []string{ollama.TrimToTokens(last, budget)}
+	}
+
+	result := append([]string{}, lines[:head]...)
+	if omitted := tail - head; omitted > 0 {
+		result = append(result, omittedMarker(omitted))
+		report.LinesDropped += omitted
+	}
+	return append(result, lines[tail:]...)
+}
+
+func omittedMarker(count int) string {
+	return fmt.Sprintf("[... %d linhas omitidas ...]", count)
+}
+
+func estimateLinesTokens(lines []string) int {
+	total := 0
+	for _, line := range lines {
+		total += ollama.EstimateTokens(line) + 1
+	}
+	return total
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"hefestus-api/internal/models"
+	"hefestus-api/pkg/ollama"
+)
+
+// newTestPreprocessService configura o domínio kubernetes com o orçamento e as
+// linhas de contexto informados
+func newTestPreprocessService(maxInputTokens int, contextLines int) *PreprocessService {
+	return NewPreprocessService(&DictionaryService{
+		domains: map[string]models.DomainConfig{
+			"kubernetes": {Name: "kubernetes", Preprocessing: models.PreprocessConfig{MaxInputTokens: maxInputTokens, ContextLines: contextLines}},
+		},
+	})
+}
+
+func TestPreprocessCleansLines(t *testing.T) {
+	input := strings.Join([]string{
+		"2026-01-02T15:04:05.123Z \x1b[32mINFO\x1b[0m starting build",
+		"[15:04:06] Downloading 10%\rDownloading 50%\rDownloading 100%",
+		"Jan  2 15:04:07 retrying connection",
+		"Jan  2 15:04:08 retrying connection",
+		"Jan  2 15:04:09 retrying connection",
+		"\x1b]0;title\x07\x1b[1;31mERROR\x1b[0m: build failed   ",
+	}, "\r\n")
+
+	result, report := newTestPreprocessService(0, 0).Process(context.Background(), "kubernetes", input)
+
+	want := strings.Join([]string{
+		"INFO starting build",
+		"Downloading 100%",
+		"retrying connection [repetida 3 vezes]",
+		"ERROR: build failed",
+	}, "\n")
+	if result != want {
+		t.Errorf("Process() =\n%s\nwant\n%s", result, want)
+	}
+
+	wantReport := models.PreprocessReport{
+		OriginalLines:    6,
+		ResultLines:      4,
+		OriginalTokens:   ollama.EstimateTokens(input),
+		ResultTokens:     ollama.EstimateTokens(want),
+		TokenBudget:      defaultMaxInputTokens,
+		ANSICodesRemoved: 5,
+		// O timestamp antes do \r some junto com os estados anteriores da barra
+		TimestampsRemoved:       4,
+		DuplicateLinesCollapsed: 2,
+	}
+	if *report != wantReport {
+		t.Errorf("report = %+v\nwant     %+v", *report, wantReport)
+	}
+}
+
+func TestPreprocessExtractsErrorRegions(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 60; i++ {
+		switch i {
+		case 20:
+			lines = append(lines, "panic: runtime error: invalid memory address")
+		case 40:
+			lines = append(lines, "Error: exit status 2")
+		default:
+			lines = append(lines, fmt.Sprintf("compiling package module%d", i))
+		}
+	}
+
+	result, report := newTestPreprocessService(200, 1).Process(context.Background(), "kubernetes", strings.Join(lines, "\n"))
+
+	want := strings.Join([]string{
+		"[... 18 linhas omitidas ...]",
+		"compiling package module19",
+		"panic: runtime error: invalid memory address",
+		"compiling package module21",
+		"[... 17 linhas omitidas ...]",
+		"compiling package module39",
+		"Error: exit status 2",
+		"compiling package module41",
+		"[... 18 linhas omitidas ...]",
+		"compiling package module60",
+	}, "\n")
+	if result != want {
+		t.Errorf("Process() =\n%s\nwant\n%s", result, want)
+	}
+	// Duas regiões de falha e o final do log
+	if report.ErrorRegions != 3 || report.LinesDropped != 53 || report.Truncated {
+		t.Errorf("unexpected report %+v", *report)
+	}
+	if report.ResultTokens > report.TokenBudget {
+		t.Errorf("result uses %d tokens of %d", report.ResultTokens, report.TokenBudget)
+	}
+}
+
+// failingRequests gera n linhas de falha distintas, que não são agrupadas
+func failingRequests(n int) string {
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&b, "error: request %d to upstream service failed with status 500\n", i)
+	}
+	return b.String()
+}
+
+func TestPreprocessTruncatesToBudget(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		head  string
+		tail  string
+	}{
+		{
+			name:  "failure on every line",
+			input: failingRequests(50) + "FAILED: 50 errors",
+			head:  "error: request 1 to upstream service failed with status 500",
+			tail:  "FAILED: 50 errors",
+		},
+		{
+			// Pontuação conta um token por caractere, bem mais que 4 caracteres por token
+			name:  "single json line",
+			input: `{"items":[` + strings.Repeat(`{"a":[1,2],"b":{}},`, 500) + `{"error":"timeout"}]}`,
+			tail:  `{"error":"timeout"}]}`,
+		},
+		{
+			name: "stack trace",
+			input: "panic: boom\n\ngoroutine 1 [running]:\n" + strings.Repeat("main.(*Server).handle(0xc000010000, {0x1, 0x2})\n\t/src/main.go:42 +0x1d\n", 40) +
+				"exit status 2",
+			head: "panic: boom",
+			tail: "exit status 2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			const budget = 60
+			result, report := newTestPreprocessService(budget, 1).Process(context.Background(), "kubernetes", tt.input)
+
+			if !report.Truncated {
+				t.Errorf("report not marked as truncated: %+v", *report)
+			}
+			if tokens := ollama.EstimateTokens(result); tokens > budget || report.ResultTokens != tokens {
+				t.Errorf("result uses %d tokens (reported %d) of %d:\n%s", tokens, report.ResultTokens, budget, result)
+			}
+			if !strings.HasPrefix(result, tt.head) || !strings.HasSuffix(result, tt.tail) {
+				t.Errorf("start or end of the log lost:\n%s", result)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hefestus-api/internal/models"
+	"log/slog"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// maxCachedResolutions limita o cache; ao passar do limite os diagnósticos
+// mais próximos de expirar são descartados
+const maxCachedResolutions = 1024
+
+// resolutionCache guarda os diagnósticos recentes para que o mesmo erro,
+// repetido por alertas ou reenvios, não volte ao modelo antes do TTL
+type resolutionCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]cachedResolution
+	now     func() time.Time
+}
+
+type cachedResolution struct {
+	solution models.ErrorSolution
+	expires  time.Time
+}
+
+// newResolutionCacheFromEnv lê LLM_CACHE_TTL (por exemplo 10m); vazio ou 0
+// desativa o cache e retorna nil
+func newResolutionCacheFromEnv() *resolutionCache {
+	value := os.Getenv("LLM_CACHE_TTL")
+	if value == "" {
+		return nil
+	}
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl < 0 {
+		slog.Warn("LLM_CACHE_TTL inválido, cache de diagnósticos desativado", "value", value)
+		return nil
+	}
+	if ttl == 0 {
+		return nil
+	}
+	return newResolutionCache(ttl)
+}
+
+func newResolutionCache(ttl time.Duration) *resolutionCache {
+	return &resolutionCache{ttl: ttl, entries: make(map[string]cachedResolution), now: time.Now}
+}
+
+// resolutionKey identifica a análise pelo tenant, domínio e texto enviado ao modelo
+func resolutionKey(tenantID string, domain string, errorDetails string, errorContext string) string {
+	sum := sha256.Sum256([]byte(tenantID + "\x00" + domain + "\x00" + errorDetails + "\x00" + errorContext))
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *resolutionCache) get(key string) (*models.ErrorSolution, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if c.now().After(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return cloneSolution(entry.solution), true
+}
+
+func (c *resolutionCache) put(key string, solution *models.ErrorSolution) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if len(c.entries) >= maxCachedResolutions {
+		for k, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+	}
+	for len(c.entries) >= maxCachedResolutions {
+		oldest := ""
+		for k, entry := range c.entries {
+			if oldest == "" || entry.expires.Before(c.entries[oldest].expires) {
+				oldest = k
+			}
+		}
+		delete(c.entries, oldest)
+	}
+	c.entries[key] = cachedResolution{solution: *cloneSolution(*solution), expires: now.Add(c.ttl)}
+}
+
+// cloneSolution copia as listas para que quem recebe o diagnóstico não altere o cache
+func cloneSolution(solution models.ErrorSolution) *models.ErrorSolution {
+	solution.References = slices.Clone(solution.References)
+	solution.Patterns = slices.Clone(solution.Patterns)
+	return &solution
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"hefestus-api/internal/metrics"
+	"hefestus-api/internal/models"
+	"hefestus-api/pkg/ollama"
+	"hefestus-api/pkg/ollama/ollamatest"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestResolutionCacheExpires(t *testing.T) {
+	cache := newResolutionCache(10 * time.Minute)
+	now := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+
+	cache.put("oom", &models.ErrorSolution{Causa: "Falta de memória", Patterns: []string{"oom_killed"}})
+
+	now = now.Add(10 * time.Minute)
+	solution, ok := cache.get("oom")
+	if !ok || solution.Causa != "Falta de memória" {
+		t.Fatalf("get() before the TTL = %+v, %v", solution, ok)
+	}
+	// Quem recebe o diagnóstico não altera a cópia em cache
+	solution.Patterns[0] = "alterado"
+	if again, _ := cache.get("oom"); again.Patterns[0] != "oom_killed" {
+		t.Errorf("cached patterns changed to %v", again.Patterns)
+	}
+
+	now = now.Add(time.Second)
+	if _, ok := cache.get("oom"); ok {
+		t.Fatal("get() returned an expired diagnosis")
+	}
+	if len(cache.entries) != 0 {
+		t.Errorf("expired entry kept in the cache")
+	}
+}
+
+func TestResolutionCacheFromEnv(t *testing.T) {
+	for value, enabled := range map[string]bool{"": false, "0": false, "-1m": false, "bogus": false, "10m": true} {
+		t.Setenv("LLM_CACHE_TTL", value)
+		if got := newResolutionCacheFromEnv() != nil; got != enabled {
+			t.Errorf("LLM_CACHE_TTL=%q: enabled = %v, want %v", value, got, enabled)
+		}
+	}
+}
+
+func TestGetResolutionCountsCacheHitsAndMisses(t *testing.T) {
+	t.Setenv("LLM_CACHE_TTL", "10m")
+	srv := ollamatest.NewServer()
+	defer srv.Close()
+
+	dictService := &DictionaryService{
+		domains: map[string]models.DomainConfig{
+			"cachetest": {Name: "cachetest", PromptTemplate: "Analise o erro."},
+		},
+	}
+	llmService := NewLLMService(NewOllamaClient(dictService, ollama.WithBaseURL(srv.URL)), dictService)
+
+	hits := metrics.ResolutionCache.WithLabelValues("cachetest", "hit")
+	misses := metrics.ResolutionCache.WithLabelValues("cachetest", "miss")
+	for i := 0; i < 3; i++ {
+		if _, err := llmService.GetResolution(context.Background(), "cachetest", "OOMKilled", "Deployment api"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Outro contexto é outra análise
+	if _, err := llmService.GetResolution(context.Background(), "cachetest", "OOMKilled", "Deployment worker"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := testutil.ToFloat64(hits); got != 2 {
+		t.Errorf("hits = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(misses); got != 2 {
+		t.Errorf("misses = %v, want 2", got)
+	}
+	if got := len(srv.Requests()); got != 2 {
+		t.Errorf("Ollama called %d times, want 2", got)
+	}
+}
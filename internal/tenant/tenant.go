@@ -0,0 +1,20 @@
+// Package tenant propaga o tenant da requisição para os serviços através do context.
+package tenant
+
+import "context"
+
+// Header permite escolher o tenant quando a API key não define um
+const Header = "X-Tenant-ID"
+
+type tenantKey struct{}
+
+// WithTenant associa o tenant ao context
+func WithTenant(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+// FromContext retorna o tenant da requisição ou "" para o escopo global
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(tenantKey{}).(string)
+	return id
+}
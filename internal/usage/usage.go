@@ -0,0 +1,49 @@
+// Package usage registra, por requisição, o consumo que conta para as cotas
+// dos clientes, como chamadas efetivas ao LLM.
+package usage
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Tracker acumula o consumo de uma requisição
+type Tracker struct {
+	llmCalls         atomic.Int64
+	promptTokens     atomic.Int64
+	completionTokens atomic.Int64
+}
+
+type trackerKey struct{}
+
+// WithTracker anexa um Tracker novo ao context
+func WithTracker(ctx context.Context) (context.Context, *Tracker) {
+	tracker := &Tracker{}
+	return context.WithValue(ctx, trackerKey{}, tracker), tracker
+}
+
+// RecordLLMCall contabiliza uma chamada ao modelo; respostas vindas de cache
+// ou só do dicionário não devem chamá-la
+func RecordLLMCall(ctx context.Context) {
+	if tracker, ok := ctx.Value(trackerKey{}).(*Tracker); ok {
+		tracker.llmCalls.Add(1)
+	}
+}
+
+// LLMCalls retorna quantas chamadas ao modelo a requisição fez
+func (t *Tracker) LLMCalls() int {
+	return int(t.llmCalls.Load())
+}
+
+// RecordTokens soma os tokens de prompt e de resposta informados pelo modelo
+func RecordTokens(ctx context.Context, prompt int, completion int) {
+	if tracker, ok := ctx.Value(trackerKey{}).(*Tracker); ok {
+		tracker.promptTokens.Add(int64(prompt))
+		tracker.completionTokens.Add(int64(completion))
+	}
+}
+
+// Tokens retorna os tokens de prompt e de resposta consumidos pela requisição
+func (t *Tracker) Tokens() (int, int) {
+	return int(t.promptTokens.Load()), int(t.completionTokens.Load())
+}
@@ -0,0 +1,95 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Fases de um Diagnosis
+const (
+	PhasePending   = "Pending"
+	PhaseAnalyzing = "Analyzing"
+	PhaseCompleted = "Completed"
+	PhaseFailed    = "Failed"
+)
+
+// ConditionAnalyzed indica se a análise terminou com sucesso
+const ConditionAnalyzed = "Analyzed"
+
+// DiagnosisSpec descreve o erro a analisar: o texto do erro, um pod do mesmo
+// namespace ou ambos
+// +kubebuilder:validation:XValidation:rule="has(self.errorDetails) || has(self.podRef)",message="errorDetails or podRef is required"
+type DiagnosisSpec struct {
+	// Domain é o domínio de análise
+	// +kubebuilder:default=kubernetes
+	// +optional
+	Domain string `json:"domain,omitempty"`
+
+	// ErrorDetails é o texto ou log do erro
+	// +optional
+	ErrorDetails string `json:"errorDetails,omitempty"`
+
+	// Context é um texto livre com informações adicionais
+	// +optional
+	Context string `json:"context,omitempty"`
+
+	// PodRef aponta um pod do namespace do Diagnosis cujos estado, eventos e
+	// logs são usados na análise
+	// +optional
+	PodRef *PodReference `json:"podRef,omitempty"`
+}
+
+// PodReference identifica um pod e, opcionalmente, o container
+type PodReference struct {
+	Name string `json:"name"`
+	// +optional
+	Container string `json:"container,omitempty"`
+}
+
+// DiagnosisStatus traz o resultado da análise
+type DiagnosisStatus struct {
+	// +optional
+	Phase string `json:"phase,omitempty"`
+	// +optional
+	Cause string `json:"cause,omitempty"`
+	// +optional
+	Steps []string `json:"steps,omitempty"`
+	// +optional
+	References []string `json:"references,omitempty"`
+	// +optional
+	Patterns []string `json:"patterns,omitempty"`
+	// +optional
+	Message string `json:"message,omitempty"`
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// +optional
+	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// Diagnosis pede ao Hefestus a análise de um erro; o resultado fica no status
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=diag
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Cause",type=string,JSONPath=`.status.cause`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+type Diagnosis struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DiagnosisSpec   `json:"spec,omitempty"`
+	Status DiagnosisStatus `json:"status,omitempty"`
+}
+
+// DiagnosisList é a lista de Diagnosis
+// +kubebuilder:object:root=true
+type DiagnosisList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Diagnosis `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Diagnosis{}, &DiagnosisList{})
+}
@@ -0,0 +1,20 @@
+// Package v1alpha1 contém os tipos do recurso Diagnosis do grupo hefestus.io.
+// +kubebuilder:object:generate=true
+// +groupName=hefestus.io
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/scheme"
+)
+
+var (
+	// GroupVersion identifica o grupo e a versão da API
+	GroupVersion = schema.GroupVersion{Group: "hefestus.io", Version: "v1alpha1"}
+
+	// SchemeBuilder registra os tipos do grupo em um runtime.Scheme
+	SchemeBuilder = &scheme.Builder{GroupVersion: GroupVersion}
+
+	// AddToScheme adiciona os tipos do grupo ao scheme
+	AddToScheme = SchemeBuilder.AddToScheme
+)
@@ -0,0 +1,234 @@
+// Package github implementa o subconjunto da API REST do GitHub usado pela
+// integração com o GitHub Actions: jobs de uma execução, logs e comentários.
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"hefestus-api/pkg/telemetry"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	defaultBaseURL = "https://api.github.com"
+	// maxLogBytes limita o log baixado; o fim do log, onde a falha aparece, é mantido
+	maxLogBytes = 4 << 20
+)
+
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// Job é um job de uma execução de workflow
+type Job struct {
+	ID           int64  `json:"id"`
+	RunID        int64  `json:"run_id"`
+	Name         string `json:"name"`
+	Conclusion   string `json:"conclusion"`
+	HTMLURL      string `json:"html_url"`
+	HeadSHA      string `json:"head_sha"`
+	HeadBranch   string `json:"head_branch"`
+	WorkflowName string `json:"workflow_name"`
+	Steps        []Step `json:"steps"`
+}
+
+// Step é um passo de um job
+type Step struct {
+	Name       string `json:"name"`
+	Number     int    `json:"number"`
+	Conclusion string `json:"conclusion"`
+}
+
+type jobsResponse struct {
+	Jobs []Job `json:"jobs"`
+}
+
+// NewClient cria o cliente a partir de GITHUB_API_URL (padrão
+// https://api.github.com, ou o endereço do GitHub Enterprise) e GITHUB_TOKEN
+func NewClient() *Client {
+	baseURL := os.Getenv("GITHUB_API_URL")
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		token:      os.Getenv("GITHUB_TOKEN"),
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// FailedJobs lista os jobs com falha da última tentativa de uma execução
+func (c *Client) FailedJobs(ctx context.Context, repo string, runID int64) ([]Job, error) {
+	var failed []Job
+	for page := 1; ; page++ {
+		var response jobsResponse
+		path := fmt.Sprintf("/repos/%s/actions/runs/%d/jobs?filter=latest&per_page=100&page=%d", repo, runID, page)
+		if err := c.do(ctx, "github.FailedJobs", http.MethodGet, path, nil, &response); err != nil {
+			return nil, err
+		}
+
+		for _, job := range response.Jobs {
+			if job.Conclusion == "failure" || job.Conclusion == "timed_out" {
+				failed = append(failed, job)
+			}
+		}
+		if len(response.Jobs) < 100 {
+			return failed, nil
+		}
+	}
+}
+
+// JobLogs baixa o log de um job, mantendo no máximo os últimos 4 MiB
+func (c *Client) JobLogs(ctx context.Context, repo string, jobID int64) (_ string, err error) {
+	ctx, span := telemetry.StartClient(ctx, "github.JobLogs", attribute.Int64("hefestus.github.job_id", jobID))
+	defer func() {
+		telemetry.RecordError(span, err)
+		span.End()
+	}()
+
+	// O GitHub redireciona para uma URL temporária do armazenamento de logs;
+	// o http.Client não repassa o Authorization para outro host
+	resp, err := c.send(ctx, http.MethodGet, fmt.Sprintf("/repos/%s/actions/jobs/%d/logs", repo, jobID), nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	// Logs de jobs longos passam de centenas de MiB; só o final, onde costuma
+	// estar a falha, fica na memória
+	logs := &tailBuffer{max: maxLogBytes}
+	if _, err := io.Copy(logs, resp.Body); err != nil {
+		return "", fmt.Errorf("failed to read logs: %w", err)
+	}
+	return logs.String(), nil
+}
+
+// tailBuffer guarda os últimos max bytes escritos
+type tailBuffer struct {
+	buf bytes.Buffer
+	max int
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.buf.Write(p)
+	// Descarta o início só quando passa do dobro, para não copiar a cada escrita
+	if t.buf.Len() > 2*t.max {
+		t.buf.Next(t.buf.Len() - t.max)
+	}
+	return len(p), nil
+}
+
+func (t *tailBuffer) String() string {
+	data := t.buf.Bytes()
+	if len(data) > t.max {
+		data = data[len(data)-t.max:]
+	}
+	return string(data)
+}
+
+// PullRequestsForCommit retorna os números dos pull requests abertos que contêm o commit
+func (c *Client) PullRequestsForCommit(ctx context.Context, repo string, sha string) ([]int, error) {
+	var pulls []struct {
+		Number int    `json:"number"`
+		State  string `json:"state"`
+	}
+	if err := c.do(ctx, "github.PullRequestsForCommit", http.MethodGet,
+		fmt.Sprintf("/repos/%s/commits/%s/pulls", repo, sha), nil, &pulls); err != nil {
+		return nil, err
+	}
+
+	var numbers []int
+	for _, pull := range pulls {
+		if pull.State == "open" {
+			numbers = append(numbers, pull.Number)
+		}
+	}
+	return numbers, nil
+}
+
+// CreateCommitComment comenta no commit
+func (c *Client) CreateCommitComment(ctx context.Context, repo string, sha string, body string) error {
+	return c.do(ctx, "github.CreateCommitComment", http.MethodPost,
+		fmt.Sprintf("/repos/%s/commits/%s/comments", repo, sha), map[string]string{"body": body}, nil)
+}
+
+// CreateIssueComment comenta no pull request (ou issue) informado
+func (c *Client) CreateIssueComment(ctx context.Context, repo string, number int, body string) error {
+	return c.do(ctx, "github.CreateIssueComment", http.MethodPost,
+		fmt.Sprintf("/repos/%s/issues/%d/comments", repo, number), map[string]string{"body": body}, nil)
+}
+
+func (c *Client) do(ctx context.Context, name string, method string, path string, payload interface{}, out interface{}) (err error) {
+	ctx, span := telemetry.StartClient(ctx, name)
+	defer func() {
+		telemetry.RecordError(span, err)
+		span.End()
+	}()
+
+	var body []byte
+	if payload != nil {
+		if body, err = json.Marshal(payload); err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+	}
+
+	resp, err := c.send(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// send executa a requisição e converte respostas de erro em error
+func (c *Client) send(ctx context.Context, method string, path string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	telemetry.Inject(ctx, req.Header)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&apiErr) == nil && apiErr.Message != "" {
+			return nil, fmt.Errorf("github answered with status %d: %s", resp.StatusCode, apiErr.Message)
+		}
+		return nil, fmt.Errorf("github answered with status %d", resp.StatusCode)
+	}
+	return resp, nil
+}
@@ -0,0 +1,31 @@
+// Package kube cria o clientset do Kubernetes usado pelo watcher e pelo
+// enriquecimento de contexto.
+package kube
+
+import (
+	"fmt"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// NewClientset usa a service account do pod quando roda dentro do cluster e,
+// fora dele, o KUBECONFIG (ou ~/.kube/config) com o contexto atual
+func NewClientset() (kubernetes.Interface, error) {
+	config, err := rest.InClusterConfig()
+	if err == rest.ErrNotInCluster {
+		rules := clientcmd.NewDefaultClientConfigLoadingRules()
+		config, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{}).ClientConfig()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubernetes config: %w", err)
+	}
+	config.UserAgent = "hefestus-api"
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+	return clientset, nil
+}
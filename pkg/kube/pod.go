@@ -0,0 +1,198 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"hefestus-api/pkg/telemetry"
+
+	"go.opentelemetry.io/otel/attribute"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+)
+
+// maxLogBytes limita os logs lidos de um container
+const maxLogBytes = 64 << 10
+
+// DescribePod resume o pod como o kubectl describe: nó, fase, QoS, condições
+// com problema e, por container, imagem, recursos, estado e reinícios
+func DescribePod(pod *corev1.Pod) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Pod %s/%s: fase %s, nó %s, QoS %s, service account %s\n",
+		pod.Namespace, pod.Name, pod.Status.Phase, valueOr(pod.Spec.NodeName, "<nenhum>"), pod.Status.QOSClass, pod.Spec.ServiceAccountName)
+
+	for _, condition := range pod.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			fmt.Fprintf(&b, "Condição %s=%s: %s %s\n", condition.Type, condition.Status, condition.Reason, condition.Message)
+		}
+	}
+
+	statuses := make(map[string]corev1.ContainerStatus)
+	for _, status := range append(append([]corev1.ContainerStatus(nil), pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...) {
+		statuses[status.Name] = status
+	}
+	describe := func(kind string, container corev1.Container) {
+		fmt.Fprintf(&b, "%s %s: imagem %s", kind, container.Name, container.Image)
+		if requests := resourceList(container.Resources.Requests); requests != "" {
+			fmt.Fprintf(&b, ", requests %s", requests)
+		}
+		if limits := resourceList(container.Resources.Limits); limits != "" {
+			fmt.Fprintf(&b, ", limits %s", limits)
+		}
+		if status, ok := statuses[container.Name]; ok {
+			fmt.Fprintf(&b, ", estado %s, reinícios %d", containerState(status.State), status.RestartCount)
+			if status.LastTerminationState.Terminated != nil {
+				fmt.Fprintf(&b, ", último estado %s", containerState(status.LastTerminationState))
+			}
+		}
+		b.WriteString("\n")
+	}
+	for _, container := range pod.Spec.InitContainers {
+		describe("Init container", container)
+	}
+	for _, container := range pod.Spec.Containers {
+		describe("Container", container)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// DescribeDeployment resume réplicas, estratégia, condições e os containers do
+// template do Deployment
+func DescribeDeployment(deployment *appsv1.Deployment) string {
+	var b strings.Builder
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	fmt.Fprintf(&b, "Deployment %s/%s: réplicas %d desejadas, %d atualizadas, %d prontas, %d disponíveis, estratégia %s\n",
+		deployment.Namespace, deployment.Name, replicas, deployment.Status.UpdatedReplicas,
+		deployment.Status.ReadyReplicas, deployment.Status.AvailableReplicas, deployment.Spec.Strategy.Type)
+
+	for _, condition := range deployment.Status.Conditions {
+		fmt.Fprintf(&b, "Condição %s=%s: %s %s\n", condition.Type, condition.Status, condition.Reason, condition.Message)
+	}
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		fmt.Fprintf(&b, "Container %s: imagem %s", container.Name, container.Image)
+		if requests := resourceList(container.Resources.Requests); requests != "" {
+			fmt.Fprintf(&b, ", requests %s", requests)
+		}
+		if limits := resourceList(container.Resources.Limits); limits != "" {
+			fmt.Fprintf(&b, ", limits %s", limits)
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func resourceList(resources corev1.ResourceList) string {
+	names := make([]string, 0, len(resources))
+	for name := range resources {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		quantity := resources[corev1.ResourceName(name)]
+		parts = append(parts, name+"="+quantity.String())
+	}
+	return strings.Join(parts, " ")
+}
+
+func containerState(state corev1.ContainerState) string {
+	switch {
+	case state.Waiting != nil:
+		return "Waiting (" + state.Waiting.Reason + ")"
+	case state.Terminated != nil:
+		return fmt.Sprintf("Terminated (%s, exit code %d)", state.Terminated.Reason, state.Terminated.ExitCode)
+	case state.Running != nil:
+		return "Running"
+	}
+	return "desconhecido"
+}
+
+func valueOr(value string, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// PodLogs lê as últimas linhas do container; previous lê a execução anterior,
+// que é onde está o erro de um container em CrashLoopBackOff
+func PodLogs(ctx context.Context, client kubernetes.Interface, pod *corev1.Pod, container string, previous bool, lines int64) (_ string, err error) {
+	ctx, span := telemetry.StartClient(ctx, "kube.PodLogs",
+		attribute.String("k8s.pod.name", pod.Name), attribute.String("k8s.container.name", container))
+	defer func() {
+		telemetry.RecordError(span, err)
+		span.End()
+	}()
+
+	limit := int64(maxLogBytes)
+	stream, err := client.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+		Container:  container,
+		Previous:   previous,
+		TailLines:  &lines,
+		LimitBytes: &limit,
+	}).Stream(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get logs: %w", err)
+	}
+	defer stream.Close()
+
+	data, err := io.ReadAll(stream)
+	if err != nil {
+		return "", fmt.Errorf("failed to read logs: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// ObjectEvents retorna os eventos mais recentes do objeto, do mais antigo para o
+// mais novo, no formato "tipo motivo: mensagem (xN)"
+func ObjectEvents(ctx context.Context, client kubernetes.Interface, namespace string, kind string, name string, max int) (_ []string, err error) {
+	ctx, span := telemetry.StartClient(ctx, "kube.ObjectEvents",
+		attribute.String("k8s.namespace.name", namespace), attribute.String("hefestus.kube.object", kind+"/"+name))
+	defer func() {
+		telemetry.RecordError(span, err)
+		span.End()
+	}()
+
+	list, err := client.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.Set{"involvedObject.kind": kind, "involvedObject.name": name}.String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+
+	events := list.Items
+	sort.Slice(events, func(i, j int) bool { return eventTime(events[i]).Time.Before(eventTime(events[j]).Time) })
+	if len(events) > max {
+		events = events[len(events)-max:]
+	}
+
+	lines := make([]string, 0, len(events))
+	for _, event := range events {
+		line := fmt.Sprintf("%s %s: %s", event.Type, event.Reason, strings.TrimSpace(event.Message))
+		if event.Count > 1 {
+			line += fmt.Sprintf(" (x%d)", event.Count)
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+func eventTime(event corev1.Event) metav1.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp
+	case !event.EventTime.IsZero():
+		return metav1.NewTime(event.EventTime.Time)
+	}
+	return event.CreationTimestamp
+}
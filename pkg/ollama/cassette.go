@@ -0,0 +1,166 @@
+package ollama
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"hefestus-api/pkg/redact"
+)
+
+// Modos do cassete
+const (
+	// CassetteRecord chama o Ollama e grava cada interação no diretório
+	CassetteRecord = "record"
+	// CassetteReplay responde só com interações gravadas, sem chamar o Ollama
+	CassetteReplay = "replay"
+)
+
+// defaultCassetteDir é o diretório usado quando OLLAMA_CASSETTE_DIR está vazio
+const defaultCassetteDir = "cassettes"
+
+// ErrCassetteMiss indica, no modo replay, uma chamada que não foi gravada
+var ErrCassetteMiss = errors.New("no recorded LLM interaction for prompt")
+
+// Cassette grava e reproduz as chamadas ao modelo, um arquivo JSON por
+// interação, nomeado pelo hash de modelo, prompt e opções. Cada arquivo guarda o
+// prompt mascarado, as opções e a resposta completa do Ollama
+type Cassette struct {
+	mode string
+	dir  string
+}
+
+// cassetteEntry é o conteúdo de um arquivo do cassete
+type cassetteEntry struct {
+	Key        string                 `json:"key"`
+	Model      string                 `json:"model"`
+	Prompt     string                 `json:"prompt"`
+	Options    map[string]interface{} `json:"options,omitempty"`
+	Response   Response               `json:"response"`
+	RecordedAt time.Time              `json:"recorded_at"`
+}
+
+// NewCassette cria o cassete no modo record ou replay; no replay o diretório
+// precisa existir
+func NewCassette(mode string, dir string) (*Cassette, error) {
+	if dir == "" {
+		dir = defaultCassetteDir
+	}
+	// Caminho absoluto, para que Dir não dependa do diretório de trabalho
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cassette dir: %w", err)
+	}
+	switch mode {
+	case CassetteRecord:
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create cassette dir: %w", err)
+		}
+	case CassetteReplay:
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("cassette dir %s not found", dir)
+		}
+	default:
+		return nil, fmt.Errorf("invalid cassette mode %q: use %s or %s", mode, CassetteRecord, CassetteReplay)
+	}
+	return &Cassette{mode: mode, dir: dir}, nil
+}
+
+// CassetteFromEnv lê OLLAMA_CASSETTE_MODE e OLLAMA_CASSETTE_DIR; sem modo
+// retorna nil, que desativa o cassete
+func CassetteFromEnv() (*Cassette, error) {
+	mode := os.Getenv("OLLAMA_CASSETTE_MODE")
+	if mode == "" {
+		return nil, nil
+	}
+	return NewCassette(mode, os.Getenv("OLLAMA_CASSETTE_DIR"))
+}
+
+// Mode retorna record ou replay
+func (c *Cassette) Mode() string {
+	return c.mode
+}
+
+// Dir retorna o diretório dos arquivos, absoluto
+func (c *Cassette) Dir() string {
+	return c.dir
+}
+
+// CassetteKey identifica uma interação pelo modelo, pelo prompt já
+// renderizado e pelas opções normalizadas enviadas ao Ollama, de modo que
+// mudar temperature ou num_predict no domínio exige uma nova gravação
+func CassetteKey(model string, prompt string, options map[string]interface{}) string {
+	hash := sha256.New()
+	hash.Write([]byte(model + "\x00" + prompt + "\x00"))
+	if len(options) > 0 {
+		// encoding/json ordena as chaves do map, então a chave é estável
+		encoded, _ := json.Marshal(options)
+		hash.Write(encoded)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (c *Cassette) path(key string) string {
+	return filepath.Join(c.dir, key[:16]+".json")
+}
+
+// Load retorna a resposta gravada para a requisição ou ErrCassetteMiss
+func (c *Cassette) Load(request Request) (*Response, error) {
+	key := CassetteKey(request.Model, request.Prompt, request.Options)
+	data, err := os.ReadFile(c.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: key %s (model %q) not in %s; record it with OLLAMA_CASSETTE_MODE=record",
+			ErrCassetteMiss, key[:16], request.Model, c.dir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+
+	var entry cassetteEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %s: %w", c.path(key), err)
+	}
+	if entry.Key != key {
+		return nil, fmt.Errorf("%w: cassette %s belongs to another prompt", ErrCassetteMiss, c.path(key))
+	}
+	return &entry.Response, nil
+}
+
+// Save grava a interação; a escrita é atômica para que gravações concorrentes
+// do servidor não deixem arquivos pela metade
+func (c *Cassette) Save(request Request, response *Response) error {
+	key := CassetteKey(request.Model, request.Prompt, request.Options)
+	data, err := json.MarshalIndent(cassetteEntry{
+		Key:        key,
+		Model:      request.Model,
+		Prompt:     redact.Secrets(request.Prompt),
+		Options:    request.Options,
+		Response:   *response,
+		RecordedAt: time.Now().UTC(),
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal cassette: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(c.dir, ".cassette-*")
+	if err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path(key)); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
@@ -4,18 +4,54 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
+	"time"
+
+	"hefestus-api/pkg/redact"
+	"hefestus-api/pkg/telemetry"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
+const (
+	// defaultContextWindow é a janela usada pelo Ollama quando num_ctx não é informado
+	defaultContextWindow = 2048
+	defaultNumPredict    = 128
+	contextGranularity   = 1024
+
+	// ContextPolicyTrim reduz os detalhes do erro até que o prompt caiba na janela
+	ContextPolicyTrim = "trim"
+	// ContextPolicyReject recusa a análise quando o prompt não cabe na janela
+	ContextPolicyReject = "reject"
+)
+
+// ErrContextWindowExceeded indica que prompt e resposta esperada não cabem na
+// janela de contexto do modelo
+var ErrContextWindowExceeded = errors.New("prompt exceeds model context window")
+
+// ErrInvalidResponse indica uma resposta do modelo fora do formato JSON pedido
+var ErrInvalidResponse = errors.New("invalid response from LLM")
+
+// ErrUnavailable indica uma falha passageira do Ollama (rede, 429 ou 5xx, como
+// o modelo ainda carregando); repetir a chamada mais tarde pode dar certo
+var ErrUnavailable = errors.New("LLM unavailable")
+
 type Client struct {
 	baseURL    string
 	model      string
 	httpClient *http.Client
+	cassette   *Cassette
+	models     map[string]ModelConfig
+	logger     func(context.Context) *slog.Logger
+	observer   Observer
 }
 
 type Request struct {
@@ -26,24 +62,120 @@ type Request struct {
 }
 
 type Response struct {
-	Model    string `json:"model"`
-	Response string `json:"response"`
-	Error    string `json:"error,omitempty"`
-	Done     bool   `json:"done"`
+	Model           string `json:"model"`
+	Response        string `json:"response"`
+	Error           string `json:"error,omitempty"`
+	Done            bool   `json:"done"`
+	PromptEvalCount int    `json:"prompt_eval_count,omitempty"`
+	EvalCount       int    `json:"eval_count,omitempty"`
+}
+
+// defaultHost é o endereço padrão do Ollama
+const defaultHost = "http://localhost:11434"
+
+// ClientOption configura o Client criado por NewClient
+type ClientOption func(*Client)
+
+// WithBaseURL usa outro endereço do Ollama, como o de um ollamatest.Server
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *Client) {
+		c.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithModel usa outro modelo no lugar de OLLAMA_MODEL
+func WithModel(model string) ClientOption {
+	return func(c *Client) {
+		c.model = model
+	}
+}
+
+// WithHTTPClient substitui o cliente HTTP usado nas chamadas
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithCassette grava ou reproduz as chamadas ao modelo; nil desativa
+func WithCassette(cassette *Cassette) ClientOption {
+	return func(c *Client) {
+		c.cassette = cassette
+	}
+}
+
+// WithModels informa a janela de contexto de cada modelo, com a chave
+// "default" valendo para os não listados; sem ela a janela é de 2048 tokens
+func WithModels(models map[string]ModelConfig) ClientOption {
+	return func(c *Client) {
+		c.models = models
+	}
 }
 
-func NewClient() *Client {
-	return &Client{
-		baseURL:    "http://localhost:11434",
+// WithLogger define como obter o logger de cada chamada, por exemplo com o ID
+// da requisição; o padrão é slog.Default
+func WithLogger(logger func(context.Context) *slog.Logger) ClientOption {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// WithObserver recebe as métricas das chamadas e das respostas rejeitadas
+func WithObserver(observer Observer) ClientOption {
+	return func(c *Client) {
+		c.observer = observer
+	}
+}
+
+// NewClient cria o cliente com o endereço de OLLAMA_HOST e o modelo de
+// OLLAMA_MODEL; as opções têm precedência sobre o ambiente
+func NewClient(options ...ClientOption) *Client {
+	client := &Client{
+		baseURL:    hostURL(os.Getenv("OLLAMA_HOST")),
 		model:      os.Getenv("OLLAMA_MODEL"),
 		httpClient: &http.Client{},
+		logger:     func(context.Context) *slog.Logger { return slog.Default() },
+		observer:   nopObserver{},
+	}
+	for _, option := range options {
+		option(client)
+	}
+	return client
+}
+
+// hostURL interpreta OLLAMA_HOST como o próprio Ollama: sem esquema usa http
+// e a porta 11434; com esquema, a porta padrão do esquema
+func hostURL(host string) string {
+	host = strings.TrimSuffix(strings.TrimSpace(host), "/")
+	if host == "" {
+		return defaultHost
+	}
+	port := "11434"
+	if strings.Contains(host, "://") {
+		port = ""
+	} else {
+		host = "http://" + host
+	}
+	parsed, err := url.Parse(host)
+	if err != nil || parsed.Host == "" {
+		return defaultHost
 	}
+	if parsed.Port() == "" && port != "" {
+		parsed.Host = net.JoinHostPort(parsed.Hostname(), port)
+	}
+	return strings.TrimSuffix(parsed.String(), "/")
 }
 
 type DomainConfig struct {
 	Name           string                 `json:"name"`
 	PromptTemplate string                 `json:"prompt_template"`
 	Parameters     map[string]interface{} `json:"parameters"`
+	ContextPolicy  string                 `json:"context_policy"`
+}
+
+// ModelConfig descreve limites de um modelo servido pelo Ollama
+type ModelConfig struct {
+	ContextWindow int `json:"context_window"`
 }
 
 type LLMResponse struct {
@@ -63,23 +195,12 @@ func cleanResponse(response string) string {
 	return strings.TrimSpace(response)
 }
 
-func (c *Client) Query(ctx context.Context, errorDetails string, domain string, errorContext string) (string, string, error) {
-	// Load domain configuration
-	data, err := os.ReadFile("config/domains.json")
+// Query monta o prompt com a configuração do domínio (já resolvida para o
+// tenant pelo chamador), consulta o modelo e valida a resposta
+func (c *Client) Query(ctx context.Context, errorDetails string, domain string, domainConfig DomainConfig, errorContext string) (string, string, error) {
+	options, err := NormalizeOptions(domainConfig.Parameters)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to read domain config: %w", err)
-	}
-
-	var config struct {
-		Domains map[string]DomainConfig `json:"domains"`
-	}
-	if err := json.Unmarshal(data, &config); err != nil {
-		return "", "", fmt.Errorf("failed to parse domain config: %w", err)
-	}
-
-	domainConfig, exists := config.Domains[domain]
-	if !exists {
-		return "", "", fmt.Errorf("unknown domain: %s", domain)
+		return "", "", fmt.Errorf("invalid parameters for domain %s: %w", domain, err)
 	}
 
 	// Format instructions
@@ -110,77 +231,255 @@ REGRAS ESTRITAS:
 		return "", "", fmt.Errorf("failed to parse prompt template: %w", err)
 	}
 
-	var promptBuf bytes.Buffer
-	err = tmpl.Execute(&promptBuf, map[string]string{
-		"Error":   errorDetails,
-		"Context": errorContext,
-	})
+	prompt, err := renderPrompt(ctx, tmpl, errorDetails, errorContext)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to execute prompt template: %w", err)
+		return "", "", err
 	}
 
-	log.Printf("Sending prompt to LLM: %s", promptBuf.String())
+	// Garante que prompt e resposta caibam na janela de contexto do modelo
+	window := c.contextWindow(options)
+	numPredict, ok := intOption(options, "num_predict")
+	if !ok || numPredict <= 0 {
+		numPredict = defaultNumPredict
+	}
+
+	promptTokens := EstimateTokens(prompt)
+	if promptTokens+numPredict > window {
+		if domainConfig.ContextPolicy == ContextPolicyReject {
+			return "", "", fmt.Errorf("%w: %d prompt tokens + %d output tokens > %d",
+				ErrContextWindowExceeded, promptTokens, numPredict, window)
+		}
+
+		excess := promptTokens + numPredict - window
+		trimmed := TrimToTokens(errorDetails, EstimateTokens(errorDetails)-excess)
+		if trimmed == "" {
+			return "", "", fmt.Errorf("%w: prompt template alone needs %d tokens of %d",
+				ErrContextWindowExceeded, promptTokens-EstimateTokens(errorDetails)+numPredict, window)
+		}
+
+		if prompt, err = renderPrompt(ctx, tmpl, trimmed, errorContext); err != nil {
+			return "", "", err
+		}
+		c.logger(ctx).Warn("prompt reduzido para caber na janela de contexto",
+			"domain", domain,
+			"from_tokens", promptTokens,
+			"to_tokens", EstimateTokens(prompt),
+			"context_window", window)
+		promptTokens = EstimateTokens(prompt)
+	}
+
+	// Sem num_ctx o Ollama trunca silenciosamente o prompt em 2048 tokens
+	if _, set := options["num_ctx"]; !set {
+		options["num_ctx"] = fitContext(promptTokens+numPredict, window)
+	}
+
+	c.logger(ctx).Debug("sending prompt to LLM",
+		"domain", domain,
+		"model", c.model,
+		"prompt", redact.Secrets(prompt))
 
-	// Prepare request
-	reqBody := Request{
+	apiResponse, err := c.complete(ctx, domain, Request{
 		Model:   c.model,
-		Prompt:  promptBuf.String(),
+		Prompt:  prompt,
 		Stream:  false,
-		Options: domainConfig.Parameters,
+		Options: options,
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	c.logger(ctx).Debug("raw LLM response",
+		"domain", domain,
+		"response", redact.Secrets(apiResponse.Response))
+
+	return c.parseResponse(ctx, domain, apiResponse.Response)
+}
+
+// complete obtém a resposta do modelo: do cassete no modo replay, ou do
+// Ollama, gravando-a no modo record
+func (c *Client) complete(ctx context.Context, domain string, reqBody Request) (*Response, error) {
+	if c.cassette == nil {
+		return c.generate(ctx, domain, reqBody)
+	}
+
+	if c.cassette.Mode() == CassetteReplay {
+		apiResponse, err := c.cassette.Load(reqBody)
+		if err != nil {
+			c.logger(ctx).Error("resposta do modelo não encontrada no cassete", "domain", domain, "error", err)
+			return nil, err
+		}
+		c.observer.CallFinished(ctx, Call{
+			Domain:           domain,
+			Model:            reqBody.Model,
+			Outcome:          OutcomeReplay,
+			PromptTokens:     apiResponse.PromptEvalCount,
+			CompletionTokens: apiResponse.EvalCount,
+		})
+		return apiResponse, nil
+	}
+
+	apiResponse, err := c.generate(ctx, domain, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.cassette.Save(reqBody, apiResponse); err != nil {
+		c.logger(ctx).Warn("falha ao gravar cassete", "domain", domain, "error", err)
 	}
+	return apiResponse, nil
+}
+
+// generate envia o prompt para /api/generate, registrando latência e
+// consumo de tokens da chamada
+func (c *Client) generate(ctx context.Context, domain string, reqBody Request) (_ *Response, err error) {
+	ctx, span := telemetry.StartClient(ctx, "ollama.generate",
+		attribute.String("hefestus.domain", domain),
+		attribute.String("gen_ai.request.model", reqBody.Model))
+	defer func() {
+		telemetry.RecordError(span, err)
+		span.End()
+	}()
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	// Send request
 	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/generate", bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	telemetry.Inject(ctx, req.Header)
+
+	c.observer.CallStarted(ctx, domain, reqBody.Model)
+	start := time.Now()
+	call := Call{Domain: domain, Model: reqBody.Model, Outcome: OutcomeError}
+	defer func() {
+		call.Duration = time.Since(start)
+		c.observer.CallFinished(ctx, call)
+	}()
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to send request: %w", err)
+		return nil, fmt.Errorf("%w: failed to send request: %w", ErrUnavailable, err)
 	}
 	defer resp.Body.Close()
 
 	// Parse response
 	var apiResponse Response
-	if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
-		return "", "", fmt.Errorf("failed to decode response: %w", err)
+	decodeErr := json.NewDecoder(resp.Body).Decode(&apiResponse)
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		if apiResponse.Error != "" {
+			return nil, fmt.Errorf("%w: status %d: %s", ErrUnavailable, resp.StatusCode, apiResponse.Error)
+		}
+		return nil, fmt.Errorf("%w: status %d", ErrUnavailable, resp.StatusCode)
+	}
+	if decodeErr != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", decodeErr)
 	}
 
 	if apiResponse.Error != "" {
-		return "", "", fmt.Errorf("LLM error: %s", apiResponse.Error)
+		return nil, fmt.Errorf("LLM error: %s", apiResponse.Error)
 	}
 
-	log.Printf("Raw LLM response: %s", apiResponse.Response)
+	call.Outcome = OutcomeSuccess
+	call.PromptTokens = apiResponse.PromptEvalCount
+	call.CompletionTokens = apiResponse.EvalCount
+	span.SetAttributes(
+		attribute.Int("gen_ai.usage.input_tokens", apiResponse.PromptEvalCount),
+		attribute.Int("gen_ai.usage.output_tokens", apiResponse.EvalCount))
+
+	return &apiResponse, nil
+}
+
+// parseResponse valida a resposta do modelo e extrai causa e solução,
+// contabilizando o motivo de cada rejeição
+func (c *Client) parseResponse(ctx context.Context, domain string, raw string) (_ string, _ string, err error) {
+	_, span := telemetry.Start(ctx, "ollama.parseResponse", attribute.String("hefestus.domain", domain))
+	defer func() {
+		telemetry.RecordError(span, err)
+		span.End()
+	}()
+
+	logger := c.logger(ctx).With("domain", domain)
 
 	// Clean and validate response
-	cleanedResponse := cleanResponse(apiResponse.Response)
+	cleanedResponse := cleanResponse(raw)
 	if !isValidJSON(cleanedResponse) {
-		log.Printf("Invalid JSON format detected: %s", cleanedResponse)
-		return "", "", fmt.Errorf("invalid JSON response from LLM")
+		logger.Warn("invalid JSON format detected")
+		logger.Debug("rejected LLM response", "response", redact.Secrets(cleanedResponse))
+		c.observer.ParseFailed(ctx, domain, "invalid_json")
+		return "", "", fmt.Errorf("%w: invalid JSON", ErrInvalidResponse)
 	}
 
 	var llmResponse LLMResponse
 	if err := json.Unmarshal([]byte(cleanedResponse), &llmResponse); err != nil {
-		log.Printf("Failed to parse LLM response: %v\nCleaned response: %s", err, cleanedResponse)
-		return "", "", fmt.Errorf("invalid response format from LLM")
+		logger.Warn("failed to parse LLM response", "error", err)
+		logger.Debug("rejected LLM response", "response", redact.Secrets(cleanedResponse))
+		c.observer.ParseFailed(ctx, domain, "invalid_format")
+		return "", "", fmt.Errorf("%w: invalid format", ErrInvalidResponse)
 	}
 
 	// Validate response content
 	if llmResponse.Causa == "" || len(llmResponse.Solucao) == 0 {
-		return "", "", fmt.Errorf("invalid response content from LLM")
+		c.observer.ParseFailed(ctx, domain, "empty_content")
+		return "", "", fmt.Errorf("%w: empty causa or solucao", ErrInvalidResponse)
 	}
 
 	// Validate causa word count
 	if len(strings.Fields(llmResponse.Causa)) > 4 {
-		return "", "", fmt.Errorf("causa exceeds maximum word count")
+		c.observer.ParseFailed(ctx, domain, "causa_too_long")
+		return "", "", fmt.Errorf("%w: causa exceeds maximum word count", ErrInvalidResponse)
 	}
 
 	return llmResponse.Causa, strings.Join(llmResponse.Solucao, "\n"), nil
 }
+
+// renderPrompt executa o template do domínio com o erro e o contexto informados
+func renderPrompt(ctx context.Context, tmpl *template.Template, errorDetails string, errorContext string) (string, error) {
+	_, span := telemetry.Start(ctx, "ollama.renderPrompt")
+	defer span.End()
+
+	var promptBuf bytes.Buffer
+	err := tmpl.Execute(&promptBuf, map[string]string{
+		"Error":   errorDetails,
+		"Context": errorContext,
+	})
+	if err != nil {
+		telemetry.RecordError(span, err)
+		return "", fmt.Errorf("failed to execute prompt template: %w", err)
+	}
+
+	span.SetAttributes(attribute.Int("hefestus.prompt.estimated_tokens", EstimateTokens(promptBuf.String())))
+	return promptBuf.String(), nil
+}
+
+// contextWindow retorna a janela do modelo configurado, limitada por um
+// num_ctx explícito nos parâmetros do domínio
+func (c *Client) contextWindow(options map[string]interface{}) int {
+	window := defaultContextWindow
+	if model, ok := c.models[c.model]; ok && model.ContextWindow > 0 {
+		window = model.ContextWindow
+	} else if model, ok := c.models["default"]; ok && model.ContextWindow > 0 {
+		window = model.ContextWindow
+	}
+
+	if numCtx, ok := intOption(options, "num_ctx"); ok && numCtx > 0 && numCtx < window {
+		window = numCtx
+	}
+	return window
+}
+
+// fitContext arredonda a necessidade de tokens para evitar realocar o contexto
+// do modelo a cada requisição, sem ultrapassar a janela disponível
+func fitContext(needed int, window int) int {
+	size := (needed + contextGranularity - 1) / contextGranularity * contextGranularity
+	if size < defaultContextWindow {
+		size = defaultContextWindow
+	}
+	if size > window {
+		size = window
+	}
+	return size
+}
@@ -0,0 +1,231 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"hefestus-api/pkg/ollama/ollamatest"
+)
+
+func newTestClient(t *testing.T, options ...ClientOption) (*Client, *ollamatest.Server) {
+	t.Helper()
+	srv := ollamatest.NewServer()
+	t.Cleanup(srv.Close)
+	return NewClient(append([]ClientOption{WithBaseURL(srv.URL), WithModel("test-model")}, options...)...), srv
+}
+
+var testDomain = DomainConfig{
+	Name:           "kubernetes",
+	PromptTemplate: "Analise o erro do Kubernetes.",
+	Parameters:     map[string]interface{}{"temperature": 0.2, "max_tokens": 100},
+	ContextPolicy:  ContextPolicyTrim,
+}
+
+func TestQuery(t *testing.T) {
+	c, srv := newTestClient(t)
+	srv.Enqueue(ollamatest.Response{Text: "```json\n{\"causa\": \"Falta de memória\", \"solucao\": [\"kubectl top pod\", \"aumentar o limite\"]}\n```"})
+
+	causa, solucao, err := c.Query(context.Background(), "OOMKilled", "kubernetes", testDomain, "Deployment api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if causa != "Falta de memória" || solucao != "kubectl top pod\naumentar o limite" {
+		t.Errorf("Query() = %q, %q", causa, solucao)
+	}
+
+	requests := srv.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("sent %d requests, want 1", len(requests))
+	}
+	request := requests[0]
+	if request.Path != "/api/generate" || request.Model != "test-model" || request.Stream == nil || *request.Stream {
+		t.Errorf("unexpected request %+v", request)
+	}
+	if !strings.Contains(request.Prompt, "ERRO: OOMKilled") || !strings.Contains(request.Prompt, "CONTEXTO: Deployment api") {
+		t.Errorf("prompt without error and context:\n%s", request.Prompt)
+	}
+	// max_tokens é traduzido e num_ctx é definido para o Ollama não truncar o prompt
+	if request.Options["num_predict"] != float64(100) || request.Options["num_ctx"] != float64(defaultContextWindow) {
+		t.Errorf("unexpected options %v", request.Options)
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		response    ollamatest.Response
+		unavailable bool
+		invalid     bool
+	}{
+		{name: "model loading", response: ollamatest.Failure(http.StatusServiceUnavailable, "model is loading"), unavailable: true},
+		{name: "overloaded", response: ollamatest.Failure(http.StatusTooManyRequests, "server busy"), unavailable: true},
+		{name: "bad request", response: ollamatest.Failure(http.StatusBadRequest, "invalid options")},
+		{name: "error field", response: ollamatest.Response{Error: "model not found"}},
+		{name: "not json", response: ollamatest.Response{Text: "A causa é falta de memória"}, invalid: true},
+		{name: "long causa", response: ollamatest.Diagnosis("o container ficou sem memória", "kubectl top pod"), invalid: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, srv := newTestClient(t)
+			srv.Enqueue(tt.response)
+
+			_, _, err := c.Query(context.Background(), "OOMKilled", "kubernetes", testDomain, "")
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if got := errors.Is(err, ErrUnavailable); got != tt.unavailable {
+				t.Errorf("errors.Is(%v, ErrUnavailable) = %v", err, got)
+			}
+			if got := errors.Is(err, ErrInvalidResponse); got != tt.invalid {
+				t.Errorf("errors.Is(%v, ErrInvalidResponse) = %v", err, got)
+			}
+		})
+	}
+}
+
+func TestQueryUnreachable(t *testing.T) {
+	c, srv := newTestClient(t)
+	srv.Close()
+
+	if _, _, err := c.Query(context.Background(), "OOMKilled", "kubernetes", testDomain, ""); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("expected ErrUnavailable, got %v", err)
+	}
+}
+
+func TestQueryFitsContextWindow(t *testing.T) {
+	var lines []string
+	for i := 0; i < 2000; i++ {
+		lines = append(lines, "INFO reconciling deployment api replica set")
+	}
+	lines = append(lines, "FATAL OOMKilled: container api exceeded memory limit")
+	errorDetails := strings.Join(lines, "\n")
+
+	t.Run("trim", func(t *testing.T) {
+		c, srv := newTestClient(t, WithModels(map[string]ModelConfig{"default": {ContextWindow: 1024}}))
+		if _, _, err := c.Query(context.Background(), errorDetails, "kubernetes", testDomain, ""); err != nil {
+			t.Fatal(err)
+		}
+
+		request := srv.Requests()[0]
+		if !strings.Contains(request.Prompt, "ERRO: [...] ") || !strings.Contains(request.Prompt, "FATAL OOMKilled") {
+			t.Errorf("prompt not trimmed from the start:\n%.300s", request.Prompt)
+		}
+		if tokens := EstimateTokens(request.Prompt) + 100; tokens > 1024 {
+			t.Errorf("prompt + output need %d tokens, window is 1024", tokens)
+		}
+		if request.Options["num_ctx"] != float64(1024) {
+			t.Errorf("num_ctx = %v, want 1024", request.Options["num_ctx"])
+		}
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		c, srv := newTestClient(t, WithModels(map[string]ModelConfig{"default": {ContextWindow: 1024}}))
+		domain := testDomain
+		domain.ContextPolicy = ContextPolicyReject
+
+		if _, _, err := c.Query(context.Background(), errorDetails, "kubernetes", domain, ""); !errors.Is(err, ErrContextWindowExceeded) {
+			t.Fatalf("expected ErrContextWindowExceeded, got %v", err)
+		}
+		if len(srv.Requests()) != 0 {
+			t.Errorf("Ollama called for a rejected prompt")
+		}
+	})
+}
+
+// newCassetteClient aponta um cliente com cassete no modo informado para dir
+func newCassetteClient(t *testing.T, mode string, dir string) (*Client, *ollamatest.Server) {
+	t.Helper()
+	cassette, err := NewCassette(mode, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newTestClient(t, WithCassette(cassette))
+}
+
+func TestCassetteRecordAndReplay(t *testing.T) {
+	dir := t.TempDir()
+	request := Request{
+		Model:   "test-model",
+		Prompt:  "ERRO: OOMKilled",
+		Options: map[string]interface{}{"temperature": 0.2, "num_predict": 100},
+	}
+
+	recorder, srv := newCassetteClient(t, CassetteRecord, dir)
+	srv.Enqueue(ollamatest.Diagnosis("Falta de memória", "kubectl top pod"))
+	recorded, err := recorder.complete(context.Background(), "kubernetes", request)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	player, srv := newCassetteClient(t, CassetteReplay, dir)
+	replayed, err := player.complete(context.Background(), "kubernetes", request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(replayed, recorded) {
+		t.Errorf("replayed %+v, recorded %+v", replayed, recorded)
+	}
+	if len(srv.Requests()) != 0 {
+		t.Errorf("replay called Ollama %d times", len(srv.Requests()))
+	}
+
+	unseen := request
+	unseen.Prompt = "ERRO: ImagePullBackOff"
+	if _, err := player.complete(context.Background(), "kubernetes", unseen); !errors.Is(err, ErrCassetteMiss) {
+		t.Errorf("unseen prompt: expected ErrCassetteMiss, got %v", err)
+	}
+}
+
+func TestCassetteKey(t *testing.T) {
+	options := map[string]interface{}{"temperature": 0.2, "num_predict": 100}
+	key := CassetteKey("test-model", "ERRO: OOMKilled", options)
+
+	if got := CassetteKey("test-model", "ERRO: OOMKilled", map[string]interface{}{"num_predict": 100, "temperature": 0.2}); got != key {
+		t.Errorf("same options in another order changed the key")
+	}
+	for name, other := range map[string]string{
+		"temperature": CassetteKey("test-model", "ERRO: OOMKilled", map[string]interface{}{"temperature": 0.7, "num_predict": 100}),
+		"no options":  CassetteKey("test-model", "ERRO: OOMKilled", nil),
+		"model":       CassetteKey("other-model", "ERRO: OOMKilled", options),
+		"prompt":      CassetteKey("test-model", "ERRO: OOMKilled!", options),
+	} {
+		if other == key {
+			t.Errorf("changing %s kept the key", name)
+		}
+	}
+}
+
+func TestCassetteRedactsPrompt(t *testing.T) {
+	dir := t.TempDir()
+	request := Request{Model: "test-model", Prompt: "ERRO: login failed password=hunter2"}
+
+	recorder, srv := newCassetteClient(t, CassetteRecord, dir)
+	srv.Enqueue(ollamatest.Diagnosis("Senha incorreta"))
+	if _, err := recorder.complete(context.Background(), "kubernetes", request); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(recorder.cassette.path(CassetteKey(request.Model, request.Prompt, request.Options)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry cassetteEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(entry.Prompt, "hunter2") || !strings.Contains(entry.Prompt, "login failed") {
+		t.Errorf("stored prompt not redacted: %q", entry.Prompt)
+	}
+
+	// A chave usa o prompt original, então o replay continua encontrando a gravação
+	player, _ := newCassetteClient(t, CassetteReplay, dir)
+	if _, err := player.complete(context.Background(), "kubernetes", request); err != nil {
+		t.Errorf("replay after redaction: %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package ollama
+
+import (
+	"context"
+	"time"
+)
+
+// Resultados de uma chamada ao modelo informados ao Observer
+const (
+	OutcomeSuccess = "success"
+	OutcomeError   = "error"
+	// OutcomeReplay indica uma resposta reproduzida do cassete, sem chamar o Ollama
+	OutcomeReplay = "replay"
+)
+
+// Call descreve uma chamada ao modelo já concluída
+type Call struct {
+	Domain           string
+	Model            string
+	Outcome          string
+	Duration         time.Duration
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// Observer recebe os eventos do cliente; é por ele que o servidor exporta
+// métricas e contabiliza o consumo de cada requisição sem que o pacote dependa
+// dessas implementações
+type Observer interface {
+	// CallStarted é chamado antes de cada requisição ao Ollama
+	CallStarted(ctx context.Context, domain string, model string)
+	// CallFinished é chamado ao fim de cada requisição iniciada e de cada
+	// resposta reproduzida do cassete
+	CallFinished(ctx context.Context, call Call)
+	// ParseFailed recebe o motivo de cada resposta do modelo rejeitada
+	ParseFailed(ctx context.Context, domain string, reason string)
+}
+
+// nopObserver é o Observer padrão, que descarta os eventos
+type nopObserver struct{}
+
+func (nopObserver) CallStarted(context.Context, string, string) {}
+func (nopObserver) CallFinished(context.Context, Call)          {}
+func (nopObserver) ParseFailed(context.Context, string, string) {}
@@ -0,0 +1,364 @@
+// Package ollamatest fornece um Ollama falso sobre httptest, com respostas
+// roteirizadas, latência e injeção de erros, para testar o cliente, os
+// serviços e os handlers sem um modelo de verdade:
+//
+//	srv := ollamatest.NewServer()
+//	defer srv.Close()
+//	srv.Enqueue(ollamatest.Diagnosis("Falta de memória", "kubectl top pod"))
+//	client := ollama.NewClient(ollama.WithBaseURL(srv.URL))
+//
+// Código que cria o cliente com ollama.NewClient() pode ser apontado para o
+// servidor com t.Setenv("OLLAMA_HOST", srv.URL).
+package ollamatest
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+)
+
+// Response é a resposta roteirizada para uma chamada a /api/generate ou /api/chat
+type Response struct {
+	// Text é o campo response do generate ou message.content do chat
+	Text string
+	// Status diferente de 200 responde {"error": Error} com esse status
+	Status int
+	// Error com Status 0 simula o Ollama respondendo 200 com o campo error
+	Error string
+	// Delay é somado à latência configurada com SetLatency
+	Delay           time.Duration
+	PromptEvalCount int
+	EvalCount       int
+}
+
+// Diagnosis monta uma resposta no formato JSON pedido pelo prompt do Hefestus
+func Diagnosis(causa string, solucao ...string) Response {
+	data, _ := json.Marshal(map[string]interface{}{"causa": causa, "solucao": solucao})
+	return Response{Text: string(data), PromptEvalCount: 100, EvalCount: 20}
+}
+
+// Failure simula uma falha do Ollama com o status e a mensagem informados
+func Failure(status int, message string) Response {
+	return Response{Status: status, Error: message}
+}
+
+// Message é uma mensagem de /api/chat
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Request é uma chamada recebida pelo servidor, disponível em Requests
+type Request struct {
+	Path     string                 `json:"-"`
+	Header   http.Header            `json:"-"`
+	Model    string                 `json:"model"`
+	Prompt   string                 `json:"prompt"`
+	Messages []Message              `json:"messages"`
+	Stream   *bool                  `json:"stream"`
+	Options  map[string]interface{} `json:"options"`
+}
+
+// Server é o Ollama falso; URL é o endereço a usar como OLLAMA_HOST
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	queue     []Response
+	fallback  Response
+	respond   func(Request) Response
+	latency   time.Duration
+	models    []string
+	requests  []Request
+	dimension int
+}
+
+// NewServer inicia o servidor. Sem respostas roteirizadas, generate e chat
+// respondem com Diagnosis("Erro de teste", "verificar logs")
+func NewServer() *Server {
+	s := &Server{
+		fallback:  Diagnosis("Erro de teste", "verificar logs"),
+		models:    []string{"test-model"},
+		dimension: 8,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/generate", s.handleGenerate)
+	mux.HandleFunc("POST /api/chat", s.handleChat)
+	mux.HandleFunc("POST /api/embeddings", s.handleEmbeddings)
+	mux.HandleFunc("POST /api/embed", s.handleEmbeddings)
+	mux.HandleFunc("GET /api/tags", s.handleTags)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Enqueue adiciona respostas consumidas em ordem pelas próximas chamadas a
+// generate e chat
+func (s *Server) Enqueue(responses ...Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queue = append(s.queue, responses...)
+}
+
+// SetDefault define a resposta usada quando a fila está vazia
+func (s *Server) SetDefault(response Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fallback = response
+}
+
+// SetHandler calcula a resposta a partir da requisição, no lugar da resposta
+// padrão, quando a fila está vazia
+func (s *Server) SetHandler(respond func(Request) Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.respond = respond
+}
+
+// SetLatency atrasa todas as respostas; o atraso é interrompido se o cliente
+// cancelar a requisição
+func (s *Server) SetLatency(latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = latency
+}
+
+// SetModels define os modelos listados em /api/tags
+func (s *Server) SetModels(names ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.models = names
+}
+
+// Requests retorna as chamadas recebidas até agora, na ordem de chegada
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Reset descarta respostas pendentes e requisições registradas
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queue = nil
+	s.requests = nil
+}
+
+// next registra a requisição e escolhe a resposta: fila, handler ou padrão
+func (s *Server) next(request Request) (Response, time.Duration) {
+	s.mu.Lock()
+	s.requests = append(s.requests, request)
+	latency, respond := s.latency, s.respond
+	response, queued := s.fallback, len(s.queue) > 0
+	if queued {
+		response = s.queue[0]
+		s.queue = s.queue[1:]
+	}
+	s.mu.Unlock()
+
+	// O handler roda fora do lock para poder chamar os métodos do Server
+	if !queued && respond != nil {
+		response = respond(request)
+	}
+	return response, latency + response.Delay
+}
+
+func (s *Server) decode(w http.ResponseWriter, r *http.Request) (Request, bool) {
+	var request Request
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("invalid request: %v", err)})
+		return request, false
+	}
+	request.Path = r.URL.Path
+	request.Header = r.Header.Clone()
+	return request, true
+}
+
+// wait aplica a latência; retorna false se o cliente desistiu antes
+func wait(r *http.Request, delay time.Duration) bool {
+	if delay <= 0 {
+		return true
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-r.Context().Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func (s *Server) handleGenerate(w http.ResponseWriter, r *http.Request) {
+	request, ok := s.decode(w, r)
+	if !ok {
+		return
+	}
+	response, delay := s.next(request)
+	if !wait(r, delay) || writeFailure(w, response) {
+		return
+	}
+
+	body := map[string]interface{}{
+		"model":             request.Model,
+		"created_at":        time.Now().UTC().Format(time.RFC3339Nano),
+		"response":          response.Text,
+		"done":              true,
+		"done_reason":       "stop",
+		"prompt_eval_count": response.PromptEvalCount,
+		"eval_count":        response.EvalCount,
+	}
+	if response.Error != "" {
+		body["error"] = response.Error
+	}
+	writeBody(w, streaming(request), body, "response")
+}
+
+func (s *Server) handleChat(w http.ResponseWriter, r *http.Request) {
+	request, ok := s.decode(w, r)
+	if !ok {
+		return
+	}
+	response, delay := s.next(request)
+	if !wait(r, delay) || writeFailure(w, response) {
+		return
+	}
+
+	body := map[string]interface{}{
+		"model":             request.Model,
+		"created_at":        time.Now().UTC().Format(time.RFC3339Nano),
+		"message":           Message{Role: "assistant", Content: response.Text},
+		"done":              true,
+		"done_reason":       "stop",
+		"prompt_eval_count": response.PromptEvalCount,
+		"eval_count":        response.EvalCount,
+	}
+	if response.Error != "" {
+		body["error"] = response.Error
+	}
+	writeBody(w, streaming(request), body, "message")
+}
+
+// handleEmbeddings responde vetores determinísticos derivados do texto, de
+// modo que textos iguais têm o mesmo embedding
+func (s *Server) handleEmbeddings(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Model  string      `json:"model"`
+		Prompt string      `json:"prompt"`
+		Input  interface{} `json:"input"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Path: r.URL.Path, Header: r.Header.Clone(), Model: request.Model, Prompt: request.Prompt})
+	latency, dimension := s.latency, s.dimension
+	s.mu.Unlock()
+	if !wait(r, latency) {
+		return
+	}
+
+	// /api/embeddings recebe prompt; /api/embed recebe input (texto ou lista)
+	if r.URL.Path == "/api/embeddings" {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"embedding": embedding(request.Prompt, dimension)})
+		return
+	}
+	var inputs []string
+	switch input := request.Input.(type) {
+	case string:
+		inputs = []string{input}
+	case []interface{}:
+		for _, item := range input {
+			inputs = append(inputs, fmt.Sprint(item))
+		}
+	}
+	embeddings := make([][]float64, 0, len(inputs))
+	for _, input := range inputs {
+		embeddings = append(embeddings, embedding(input, dimension))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"model": request.Model, "embeddings": embeddings})
+}
+
+func (s *Server) handleTags(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	names := append([]string(nil), s.models...)
+	s.mu.Unlock()
+
+	models := make([]map[string]interface{}, 0, len(names))
+	for _, name := range names {
+		models = append(models, map[string]interface{}{
+			"name":        name,
+			"model":       name,
+			"modified_at": "2024-01-01T00:00:00Z",
+			"size":        0,
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"models": models})
+}
+
+func embedding(text string, dimension int) []float64 {
+	sum := sha256.Sum256([]byte(text))
+	vector := make([]float64, dimension)
+	for i := range vector {
+		offset := (i * 2) % (len(sum) - 1)
+		vector[i] = float64(binary.BigEndian.Uint16(sum[offset:]))/65535*2 - 1
+	}
+	return vector
+}
+
+// streaming segue o padrão do Ollama: stream é true quando omitido
+func streaming(request Request) bool {
+	return request.Stream == nil || *request.Stream
+}
+
+// writeFailure responde o erro injetado com Status; retorna true se respondeu
+func writeFailure(w http.ResponseWriter, response Response) bool {
+	if response.Status == 0 || response.Status == http.StatusOK {
+		return false
+	}
+	message := response.Error
+	if message == "" {
+		message = http.StatusText(response.Status)
+	}
+	writeJSON(w, response.Status, map[string]string{"error": message})
+	return true
+}
+
+// writeBody responde um objeto ou, em streaming, um chunk com o conteúdo e um
+// chunk final sem conteúdo, em NDJSON
+func writeBody(w http.ResponseWriter, stream bool, body map[string]interface{}, contentField string) {
+	if !stream {
+		writeJSON(w, http.StatusOK, body)
+		return
+	}
+
+	chunk := map[string]interface{}{"model": body["model"], "created_at": body["created_at"], contentField: body[contentField], "done": false}
+	final := make(map[string]interface{}, len(body))
+	for key, value := range body {
+		final[key] = value
+	}
+	if contentField == "message" {
+		final[contentField] = Message{Role: "assistant"}
+	} else {
+		final[contentField] = ""
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	encoder := json.NewEncoder(w)
+	encoder.Encode(chunk)
+	encoder.Encode(final)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,75 @@
+package ollama
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// validOptions lista os parâmetros aceitos pelo campo options da API do Ollama
+var validOptions = map[string]bool{
+	"numa": true, "num_ctx": true, "num_batch": true, "num_gpu": true,
+	"main_gpu": true, "low_vram": true, "f16_kv": true, "logits_all": true,
+	"vocab_only": true, "use_mmap": true, "use_mlock": true, "num_thread": true,
+	"num_keep": true, "seed": true, "num_predict": true, "top_k": true,
+	"top_p": true, "min_p": true, "typical_p": true, "repeat_last_n": true,
+	"temperature": true, "repeat_penalty": true, "presence_penalty": true,
+	"frequency_penalty": true, "mirostat": true, "mirostat_tau": true,
+	"mirostat_eta": true, "penalize_newline": true, "stop": true, "tfs_z": true,
+}
+
+// optionAliases traduz nomes usados por outras APIs de LLM para os do Ollama
+var optionAliases = map[string]string{
+	"max_tokens":     "num_predict",
+	"max_new_tokens": "num_predict",
+	"context_window": "num_ctx",
+	"context_length": "num_ctx",
+	"stop_sequences": "stop",
+}
+
+// NormalizeOptions traduz aliases conhecidos e rejeita parâmetros que o Ollama
+// ignoraria silenciosamente
+func NormalizeOptions(params map[string]interface{}) (map[string]interface{}, error) {
+	options := make(map[string]interface{}, len(params))
+
+	// Ordena as chaves para que mensagens de erro sejam determinísticas
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var unknown []string
+	for _, key := range keys {
+		name := key
+		if alias, ok := optionAliases[key]; ok {
+			name = alias
+		}
+		if !validOptions[name] {
+			unknown = append(unknown, key)
+			continue
+		}
+		if _, exists := options[name]; exists {
+			return nil, fmt.Errorf("option %q conflicts with another parameter mapped to %q", key, name)
+		}
+		options[name] = params[key]
+	}
+
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("unknown ollama options: %s", strings.Join(unknown, ", "))
+	}
+
+	return options, nil
+}
+
+// intOption lê um parâmetro numérico decodificado de JSON
+func intOption(options map[string]interface{}, name string) (int, bool) {
+	switch v := options[name].(type) {
+	case float64:
+		return int(v), true
+	case int:
+		return v, true
+	default:
+		return 0, false
+	}
+}
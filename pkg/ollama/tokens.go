@@ -0,0 +1,67 @@
+package ollama
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// EstimateTokens aproxima a quantidade de tokens de um texto sem depender do
+// tokenizer do modelo. Palavras contam um token a cada ~4 caracteres,
+// pontuação e símbolos contam um token cada e caracteres fora do ASCII pesam
+// mais, pois tokenizers BPE costumam dividi-los em vários pedaços.
+func EstimateTokens(text string) int {
+	tokens := 0
+	wordRunes := 0
+
+	flushWord := func() {
+		if wordRunes > 0 {
+			tokens += (wordRunes + 3) / 4
+			wordRunes = 0
+		}
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.IsSpace(r):
+			flushWord()
+		case r >= utf8.RuneSelf && unicode.IsLetter(r):
+			wordRunes += 2
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			wordRunes++
+		default:
+			flushWord()
+			tokens++
+		}
+	}
+	flushWord()
+
+	return tokens
+}
+
+// TrimToTokens descarta o início do texto até que ele caiba no limite de
+// tokens de EstimateTokens, preservando o final, onde costuma estar a falha.
+// O resultado começa com o marcador "[...] " e é vazio se nem ele couber.
+func TrimToTokens(text string, limit int) string {
+	const marker = "[...] "
+	limit -= EstimateTokens(marker)
+	if limit <= 0 {
+		return ""
+	}
+
+	runes := []rune(text)
+	for len(runes) > 0 {
+		tokens := EstimateTokens(string(runes))
+		if tokens <= limit {
+			break
+		}
+		drop := len(runes) - len(runes)*limit/tokens
+		if drop < 1 {
+			drop = 1
+		}
+		runes = runes[drop:]
+	}
+	if len(runes) == 0 {
+		return ""
+	}
+	return marker + string(runes)
+}
@@ -0,0 +1,31 @@
+// Package redact mascara credenciais em textos que vão para logs, prompts
+// gravados e históricos.
+package redact
+
+import "regexp"
+
+const redacted = "[REDACTED]"
+
+// secretPatterns identifica credenciais comuns em logs de CI e de clusters
+var secretPatterns = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`), redacted},
+	{regexp.MustCompile(`(?i)(authorization:\s*)(bearer|basic|token)\s+[^\s"']+`), "${1}${2} " + redacted},
+	{regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]{16,}=*`), "Bearer " + redacted},
+	{regexp.MustCompile(`(?i)\b((?:[a-z0-9]+[_-])*(?:password|passwd|pwd|secret|token|api[_-]?key|access[_-]?key))(\s*[:=]\s*)("[^"]*"|'[^']*'|[^\s,;&]+)`), "${1}${2}" + redacted},
+	{regexp.MustCompile(`(://[^/\s:@]+:)[^@\s/]+@`), "${1}" + redacted + "@"},
+	{regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`), redacted},
+	{regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`), redacted},
+	{regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}\b`), redacted},
+	{regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\b`), redacted},
+}
+
+// Secrets mascara tokens, senhas, chaves privadas e credenciais em URLs
+func Secrets(text string) string {
+	for _, secret := range secretPatterns {
+		text = secret.pattern.ReplaceAllString(text, secret.replacement)
+	}
+	return text
+}
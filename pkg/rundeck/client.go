@@ -0,0 +1,131 @@
+// Package rundeck implementa o subconjunto da API do Rundeck usado pelas
+// ações de autocorreção: disparar um job e consultar a execução.
+package rundeck
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"hefestus-api/pkg/telemetry"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const defaultAPIVersion = "41"
+
+// ErrNotConfigured indica que RUNDECK_URL ou RUNDECK_TOKEN não foram definidos
+var ErrNotConfigured = errors.New("rundeck is not configured")
+
+type Client struct {
+	baseURL    string
+	token      string
+	apiVersion string
+	httpClient *http.Client
+}
+
+// Execution é a execução de um job retornada pelo Rundeck
+type Execution struct {
+	ID        int    `json:"id"`
+	Status    string `json:"status"`
+	Permalink string `json:"permalink"`
+}
+
+type runRequest struct {
+	Options map[string]string `json:"options,omitempty"`
+}
+
+type apiError struct {
+	Message string `json:"message"`
+}
+
+// NewClient cria o cliente a partir de RUNDECK_URL, RUNDECK_TOKEN e
+// RUNDECK_API_VERSION
+func NewClient() *Client {
+	version := os.Getenv("RUNDECK_API_VERSION")
+	if version == "" {
+		version = defaultAPIVersion
+	}
+
+	return &Client{
+		baseURL:    strings.TrimSuffix(os.Getenv("RUNDECK_URL"), "/"),
+		token:      os.Getenv("RUNDECK_TOKEN"),
+		apiVersion: version,
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// Enabled informa se o cliente tem URL e token configurados
+func (c *Client) Enabled() bool {
+	return c.baseURL != "" && c.token != ""
+}
+
+// RunJob dispara o job com as opções informadas
+func (c *Client) RunJob(ctx context.Context, jobID string, options map[string]string) (*Execution, error) {
+	body, err := json.Marshal(runRequest{Options: options})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	return c.do(ctx, "rundeck.RunJob", http.MethodPost, "/job/"+jobID+"/run", body,
+		attribute.String("hefestus.rundeck.job_id", jobID))
+}
+
+// GetExecution consulta o estado de uma execução
+func (c *Client) GetExecution(ctx context.Context, id int) (*Execution, error) {
+	return c.do(ctx, "rundeck.GetExecution", http.MethodGet, fmt.Sprintf("/execution/%d", id), nil,
+		attribute.Int("hefestus.rundeck.execution_id", id))
+}
+
+func (c *Client) do(ctx context.Context, name string, method string, path string, body []byte, attrs ...attribute.KeyValue) (_ *Execution, err error) {
+	if !c.Enabled() {
+		return nil, ErrNotConfigured
+	}
+
+	ctx, span := telemetry.StartClient(ctx, name, attrs...)
+	defer func() {
+		telemetry.RecordError(span, err)
+		span.End()
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+"/api/"+c.apiVersion+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Rundeck-Auth-Token", c.token)
+	telemetry.Inject(ctx, req.Header)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode >= 300 {
+		var apiErr apiError
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != "" {
+			return nil, fmt.Errorf("rundeck answered with status %d: %s", resp.StatusCode, apiErr.Message)
+		}
+		return nil, fmt.Errorf("rundeck answered with status %d", resp.StatusCode)
+	}
+
+	var execution Execution
+	if err := json.Unmarshal(data, &execution); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &execution, nil
+}
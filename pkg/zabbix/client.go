@@ -0,0 +1,127 @@
+// Package zabbix implementa o subconjunto da API JSON-RPC do Zabbix usado para
+// registrar o diagnóstico como comentário de reconhecimento do problema.
+package zabbix
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"hefestus-api/pkg/telemetry"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// actionAddMessage é a flag de event.acknowledge que adiciona um comentário
+	actionAddMessage = 4
+	// maxMessageLength é o limite do Zabbix para comentários de reconhecimento
+	maxMessageLength = 2048
+)
+
+// ErrNotConfigured indica que ZABBIX_URL ou ZABBIX_API_TOKEN não foram definidos
+var ErrNotConfigured = errors.New("zabbix is not configured")
+
+type Client struct {
+	url        string
+	token      string
+	httpClient *http.Client
+}
+
+type rpcRequest struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+	ID      int         `json:"id"`
+}
+
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    string `json:"data"`
+}
+
+type acknowledgeParams struct {
+	EventIDs string `json:"eventids"`
+	Action   int    `json:"action"`
+	Message  string `json:"message"`
+}
+
+// NewClient cria o cliente a partir de ZABBIX_URL (endereço do
+// api_jsonrpc.php) e ZABBIX_API_TOKEN
+func NewClient() *Client {
+	return &Client{
+		url:        os.Getenv("ZABBIX_URL"),
+		token:      os.Getenv("ZABBIX_API_TOKEN"),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Enabled informa se o cliente tem URL e token configurados
+func (c *Client) Enabled() bool {
+	return c.url != "" && c.token != ""
+}
+
+// Acknowledge adiciona um comentário ao problema sem fechá-lo nem reconhecê-lo
+func (c *Client) Acknowledge(ctx context.Context, eventID string, message string) (err error) {
+	if !c.Enabled() {
+		return ErrNotConfigured
+	}
+
+	ctx, span := telemetry.StartClient(ctx, "zabbix.Acknowledge", attribute.String("hefestus.zabbix.event_id", eventID))
+	defer func() {
+		telemetry.RecordError(span, err)
+		span.End()
+	}()
+
+	if runes := []rune(message); len(runes) > maxMessageLength {
+		message = string(runes[:maxMessageLength])
+	}
+
+	body, err := json.Marshal(rpcRequest{
+		JSONRPC: "2.0",
+		Method:  "event.acknowledge",
+		Params:  acknowledgeParams{EventIDs: eventID, Action: actionAddMessage, Message: message},
+		ID:      1,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json-rpc")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	telemetry.Inject(ctx, req.Header)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("zabbix answered with status %d", resp.StatusCode)
+	}
+
+	var rpc rpcResponse
+	if err := json.NewDecoder(resp.Body).Decode(&rpc); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if rpc.Error != nil {
+		return fmt.Errorf("zabbix error %d: %s %s", rpc.Error.Code, rpc.Error.Message, rpc.Error.Data)
+	}
+	return nil
+}